@@ -5,11 +5,13 @@ go 1.23.0
 toolchain go1.23.7
 
 require (
+	github.com/andybalholm/brotli v1.1.1
 	github.com/getkin/kin-openapi v0.131.0
 	github.com/google/uuid v1.6.0
 	github.com/lestrrat-go/jsref v0.0.0-20211028120858-c0bcbb5abf20
 	github.com/mark3labs/mcp-go v0.17.0
 	github.com/urfave/cli/v2 v2.27.6
+	google.golang.org/protobuf v1.36.6
 	gopkg.in/yaml.v3 v3.0.1
 	sigs.k8s.io/yaml v1.4.0
 )
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// WithAccept sets the Accept header sent with every upstream request, e.g. "application/json".
+// By default tools generated from a specification accept the content types their operation
+// declares for successful responses, preferring JSON, and other tools send no Accept header.
+func WithAccept(accept string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.accept = accept
+	}
+}
+
+// acceptFor returns the Accept header of an operation: the OperationConfig override, the global
+// setting, or else the header derived from the declared responses
+func (c *adapterConfig) acceptFor(opCfg OperationConfig, responses map[string]Response) string {
+	switch {
+	case opCfg.Accept != "":
+		return opCfg.Accept
+	case c.accept != "":
+		return c.accept
+	}
+	return acceptHeader(responses)
+}
+
+// acceptHeader lists the media types of the successful and default responses, JSON types first.
+// Other types get a lower weight, so an upstream that can choose picks JSON.
+func acceptHeader(responses map[string]Response) string {
+	seen := map[string]bool{}
+	var preferred, others []string
+	for status, response := range responses {
+		if status != "default" && !strings.HasPrefix(status, "2") {
+			continue
+		}
+		for mediaType := range response.Content {
+			mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+			if mediaType == "" || seen[mediaType] {
+				continue
+			}
+			seen[mediaType] = true
+			if isJSONContentType(mediaType) {
+				preferred = append(preferred, mediaType)
+			} else {
+				others = append(others, mediaType)
+			}
+		}
+	}
+	sort.Strings(preferred)
+	sort.Strings(others)
+
+	// application/json itself comes before vendor types such as application/problem+json
+	sort.SliceStable(preferred, func(i, j int) bool {
+		return preferred[i] == "application/json" && preferred[j] != "application/json"
+	})
+	if len(preferred) == 0 || len(others) == 0 {
+		return strings.Join(append(preferred, others...), ", ")
+	}
+	for i, mediaType := range others {
+		others[i] = mediaType + ";q=0.9"
+	}
+	return strings.Join(append(preferred, others...), ", ")
+}
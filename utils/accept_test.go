@@ -0,0 +1,38 @@
+package utils
+
+import "testing"
+
+func Test_AcceptHeader(t *testing.T) {
+	content := func(types ...string) Response {
+		response := Response{Content: map[string]MediaType{}}
+		for _, mediaType := range types {
+			response.Content[mediaType] = MediaType{}
+		}
+		return response
+	}
+	tests := []struct {
+		responses map[string]Response
+		want      string
+	}{
+		{map[string]Response{"200": content("application/json")}, "application/json"},
+		{map[string]Response{"200": content("application/xml", "application/json"), "404": content("text/html")},
+			"application/json, application/xml;q=0.9"},
+		{map[string]Response{"201": content("application/problem+json"), "default": content("application/json", "text/plain")},
+			"application/json, application/problem+json, text/plain;q=0.9"},
+		{map[string]Response{"204": {}}, ""},
+	}
+	for _, tt := range tests {
+		if got := acceptHeader(tt.responses); got != tt.want {
+			t.Errorf("acceptHeader(%v) = %q; want %q", tt.responses, got, tt.want)
+		}
+	}
+
+	cfg := newAdapterConfig(WithAccept("text/csv"))
+	responses := map[string]Response{"200": content("application/json")}
+	if got := cfg.acceptFor(OperationConfig{}, responses); got != "text/csv" {
+		t.Errorf("Got %q; want the global Accept header", got)
+	}
+	if got := cfg.acceptFor(OperationConfig{Accept: "application/xml"}, responses); got != "application/xml" {
+		t.Errorf("Got %q; want the operation's Accept header", got)
+	}
+}
@@ -1,14 +1,15 @@
 package utils
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	neturl "net/url"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -30,6 +31,108 @@ func isRequiredField(field string, requiredList []string) bool {
 	return false
 }
 
+// maxExampleLength limits the length of the example appended to a property description, in characters
+const maxExampleLength = 100
+
+// describeExample returns the example of a schema to append to a property description, as compact
+// JSON truncated to maxExampleLength, or an empty string if the schema has none
+func describeExample(schema Schema) string {
+	if schema.Example == nil {
+		return ""
+	}
+	encoded, err := encodeJSON(schema.Example, false)
+	if err != nil {
+		return ""
+	}
+	example := string(encoded)
+	if runes := []rune(example); len(runes) > maxExampleLength {
+		example = string(runes[:maxExampleLength]) + "…"
+	}
+	return "Example: " + example
+}
+
+// maxSchemaDepth limits how deeply nested objects and arrays are expanded into tool input
+// schemas. Deeper levels, and $refs that could not be expanded, are left as untyped values.
+const maxSchemaDepth = 8
+
+// schemaProperty converts a schema into a tool input property, expanding nested properties
+// and array items recursively up to maxSchemaDepth
+func schemaProperty(schema Schema, description string, depth int) map[string]interface{} {
+	prop := map[string]interface{}{}
+	if schema.Ref != "" {
+		if description != "" {
+			prop["description"] = description
+		}
+		return prop
+	}
+	if t := schema.jsonType(); t != "" {
+		prop["type"] = t
+	}
+	// Examples help models produce well-formed arguments on the first try
+	if example := describeExample(schema); example != "" {
+		description = strings.TrimSpace(description + " " + example)
+	}
+	if description != "" {
+		prop["description"] = description
+	}
+	if schema.Enum != nil {
+		prop["enum"] = schema.Enum
+	}
+	if schema.Format != "" {
+		prop["format"] = schema.Format
+	}
+	if schema.Default != nil {
+		prop["default"] = schema.Default
+	}
+	addConstraints(prop, schema)
+	if depth >= maxSchemaDepth {
+		return prop
+	}
+
+	if schema.Items != nil {
+		prop["items"] = schemaProperty(*schema.Items, schema.Items.Description, depth+1)
+	}
+	if schema.Properties != nil {
+		properties := make(map[string]interface{}, len(schema.Properties))
+		for name, propSchema := range schema.Properties {
+			properties[name] = schemaProperty(propSchema, propSchema.Description, depth+1)
+		}
+		prop["properties"] = properties
+		var required []string
+		for _, name := range schema.Required {
+			if _, ok := schema.Properties[name]; ok {
+				required = append(required, name)
+			}
+		}
+		if len(required) > 0 {
+			prop["required"] = required
+		}
+	}
+	return prop
+}
+
+// addConstraints copies the validation keywords of a schema into a tool input property
+func addConstraints(prop map[string]interface{}, schema Schema) {
+	if schema.Minimum != nil {
+		prop["minimum"] = *schema.Minimum
+	}
+	if schema.Maximum != nil {
+		prop["maximum"] = *schema.Maximum
+	}
+	if schema.MultipleOf != nil {
+		prop["multipleOf"] = *schema.MultipleOf
+	}
+	if schema.MinLength != nil {
+		prop["minLength"] = *schema.MinLength
+	}
+	if schema.MaxLength != nil {
+		prop["maxLength"] = *schema.MaxLength
+	}
+	if schema.Pattern != "" {
+		prop["pattern"] = schema.Pattern
+	}
+}
+
 func sanitizeToolName(name string) string {
 	s := strings.ToLower(name)
 	s = strings.ReplaceAll(s, " ", "_")
@@ -54,12 +157,249 @@ func sanitizeToolName(name string) string {
 	return s
 }
 
-func NewToolHandler(method string, url string, extraHeaders map[string]string) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// toolEndpoint describes the upstream request performed by a generated tool
+type toolEndpoint struct {
+	name         string // Tool name reported to metrics
+	method       string
+	url          string
+	extraHeaders map[string]string
+	timeout      time.Duration
+	maxResponse  int64 // Response size limit in bytes; zero or negative means no limit
+	auths        []*Auth
+	limiters     []*tokenBucket
+	slots        []semaphore   // Concurrency limits the call holds a slot of while sending requests
+	circuit      *circuit      // Circuit breaker of the upstream host; nil if disabled
+	cacheTTL     time.Duration // How long responses are cached; zero disables caching
+	pagination   *Pagination   // How further pages are fetched; nil returns only the first page
+	extract      *jsonPath     // Part of JSON responses to return; nil returns the full response
+	template     *template.Template
+	mock         *mockResponse // Response returned instead of calling the upstream API in mock mode
+	parameters   []Parameter
+	bodySchema   *Schema
+	bodyMedia    string // Media type of the request body, which selects how body arguments are encoded
+	bodyRequired bool
+	accept       string      // Accept header sent with the request; empty sends none
+	byteRange    string      // Range header sent unless the call gives a range argument; empty sends none
+	rangeArg     bool        // Whether the tool takes a range argument
+	graphQLQuery string      // GraphQL document sent with the body arguments as variables, for GraphQL operations
+	grpcMethod   *grpcMethod // RPC the body arguments are sent to as a protobuf message, for gRPC operations
+
+	// required lists the required argument names for each argument object, e.g. "pathNames"
+	required map[string][]string
+}
+
+// missingRequired returns the required arguments that are absent, as "object.name".
+// Path parameters must also be non-empty, since an empty value collapses the path, e.g. /users//orders.
+func (e toolEndpoint) missingRequired(args map[string]map[string]interface{}) []string {
+	var missing []string
+	for _, object := range []string{"pathNames", "searchParams", "headerNames", "cookieNames", "requestBody"} {
+		for _, name := range e.required[object] {
+			value := args[object][name]
+			switch {
+			case value == nil:
+				missing = append(missing, object+"."+name)
+			case object == "pathNames" && formatScalar(value) == "":
+				missing = append(missing, object+"."+name+" (must not be empty)")
+			}
+		}
+	}
+
+	// Placeholders of the URL template are always required, even if the spec does not declare them
+	for _, name := range pathPlaceholders(e.url) {
+		if isRequiredField(name, e.required["pathNames"]) {
+			continue
+		}
+		value, declared := args["pathNames"][name]
+		switch {
+		case !declared || value == nil:
+			missing = append(missing, "pathNames."+name)
+		case formatScalar(value) == "":
+			missing = append(missing, "pathNames."+name+" (must not be empty)")
+		}
+	}
+	return missing
+}
+
+// pathPlaceholders returns the names of the {placeholders} in a URL template
+func pathPlaceholders(url string) []string {
+	var names []string
+	for {
+		start := strings.Index(url, "{")
+		if start < 0 {
+			return names
+		}
+		end := strings.Index(url[start:], "}")
+		if end < 0 {
+			return names
+		}
+		names = append(names, url[start+1:start+end])
+		url = url[start+end+1:]
+	}
+}
+
+// queryProperties returns the schemas of the query parameters by name
+func (e toolEndpoint) queryProperties() map[string]Schema {
+	return e.parameterProperties("query")
+}
+
+// parameterProperties returns the schemas of the parameters in the given location by name
+func (e toolEndpoint) parameterProperties(in string) map[string]Schema {
+	properties := map[string]Schema{}
+	for _, param := range e.parameters {
+		if param.In == in && param.Schema != nil {
+			properties[param.Name] = *param.Schema
+		}
+	}
+	return properties
+}
+
+// coercePath trims the whitespace models sometimes add around path arguments, so a value that is
+// only whitespace counts as empty, and converts them to the types declared by their parameter schemas
+func (e toolEndpoint) coercePath(args map[string]interface{}) (map[string]interface{}, error) {
+	trimmed := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if s, ok := value.(string); ok {
+			value = strings.TrimSpace(s)
+		}
+		trimmed[name] = value
+	}
+	return coerceProperties(trimmed, e.parameterProperties("path"))
+}
+
+// coerceQuery converts query arguments to the types declared by their parameter schemas
+func (e toolEndpoint) coerceQuery(args map[string]interface{}) (map[string]interface{}, error) {
+	return coerceProperties(args, e.queryProperties())
+}
+
+// coerceBody converts body arguments to the types declared by the request body schema
+func (e toolEndpoint) coerceBody(args map[string]interface{}) (map[string]interface{}, error) {
+	if e.bodySchema == nil {
+		return args, nil
+	}
+	return coerceProperties(args, e.bodySchema.Properties)
+}
+
+// applyQueryDefaults returns the query arguments with schema defaults filled in for omitted parameters.
+// Parameters explicitly set to null are left untouched.
+func (e toolEndpoint) applyQueryDefaults(args map[string]interface{}) map[string]interface{} {
+	defaults := map[string]interface{}{}
+	for _, param := range e.parameters {
+		if param.In == "query" && param.Schema != nil && param.Schema.Default != nil {
+			defaults[param.Name] = param.Schema.Default
+		}
+	}
+	return withDefaults(args, defaults)
+}
+
+// applyBodyDefaults returns the body arguments with schema defaults filled in for omitted properties
+func (e toolEndpoint) applyBodyDefaults(args map[string]interface{}) map[string]interface{} {
+	if e.bodySchema == nil {
+		return args
+	}
+	defaults := map[string]interface{}{}
+	for name, prop := range e.bodySchema.Properties {
+		if prop.Default != nil {
+			defaults[name] = prop.Default
+		}
+	}
+	return withDefaults(args, defaults)
+}
+
+// withDefaults copies args and adds the defaults whose keys are absent. args itself is not modified.
+func withDefaults(args map[string]interface{}, defaults map[string]interface{}) map[string]interface{} {
+	if len(defaults) == 0 {
+		return args
+	}
+	result := make(map[string]interface{}, len(args)+len(defaults))
+	for key, value := range args {
+		result[key] = value
+	}
+	for key, value := range defaults {
+		if _, ok := result[key]; !ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// parameter returns the declared parameter with the given location and name
+func (e toolEndpoint) parameter(in string, name string) (Parameter, bool) {
+	for _, param := range e.parameters {
+		if param.In == in && param.Name == name {
+			return param, true
+		}
+	}
+	return Parameter{}, false
+}
+
+// NewToolHandler creates a tool handler that forwards the tool call to the given API endpoint
+func NewToolHandler(method string, url string, extraHeaders map[string]string, opts ...AdapterOption) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cfg := newAdapterConfig(opts...)
+	_, extraHeaders = cfg.upstream("", extraHeaders)
+	extraHeaders, err := cfg.expandHeaderEnv(extraHeaders)
+	if err != nil {
+		cfg.setError(err)
+	}
+	if cfg.err != nil {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return newToolResultError(fmt.Sprintf("Invalid tool configuration: %v", cfg.err)), nil
+		}
+	}
+	return newToolHandler(toolEndpoint{
+		method:       method,
+		url:          url,
+		extraHeaders: extraHeaders,
+		timeout:      cfg.timeout,
+		maxResponse:  cfg.maxResponse,
+		auths:        cfg.authFor(OperationConfig{}, nil),
+		limiters:     cfg.limitersFor("", url),
+		slots:        cfg.semaphoresFor(url),
+		circuit:      cfg.circuits.forURL(url),
+		cacheTTL:     cfg.cacheTTL,
+		pagination:   cfg.pagination,
+		accept:       cfg.accept,
+	}, cfg)
+}
+
+// newToolHandler creates a tool handler using an already resolved adapter configuration,
+// so that handlers of the same server share one HTTP client
+func newToolHandler(endpoint toolEndpoint, cfg *adapterConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := endpoint.method
+	url := endpoint.url
+	extraHeaders := endpoint.extraHeaders
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		// Error results may quote the request URL or echo credentials back, so every result is redacted
+		var sent *http.Request
+		var reqBody *requestBody
+		defer func() {
+			secrets := cfg.redactor.secrets(sent, endpoint.auths)
+			if cfg.showCurl && sent != nil && result != nil {
+				result.Content = append(result.Content, mcp.NewTextContent("Equivalent curl command: "+cfg.curlCommand(sent, reqBody, secrets)))
+			}
+			result = cfg.redactor.redactResult(result, secrets)
+		}()
+
 		params := request.Params.Arguments
+		byteRange, err := endpoint.byteRangeFor(params)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Invalid value for range: %v", err)), nil
+		}
+		if _, ok := params[rangeArgument]; ok && endpoint.rangeArg {
+			// The range is not a parameter of the operation, so it must not end up in the body below
+			withoutRange := make(map[string]interface{}, len(params))
+			for name, value := range params {
+				if name != rangeArgument {
+					withoutRange[name] = value
+				}
+			}
+			params = withoutRange
+		}
 		pathParams := make(map[string]interface{})
 		queryParams := make(map[string]interface{})
 		bodyParams := make(map[string]interface{})
+		headerParams := make(map[string]interface{})
+		cookieParams := make(map[string]interface{})
 
 		if pathParamsMap, ok := params["pathNames"].(map[string]interface{}); ok {
 			pathParams = pathParamsMap
@@ -70,8 +410,18 @@ func NewToolHandler(method string, url string, extraHeaders map[string]string) f
 		if requestBodyMap, ok := params["requestBody"].(map[string]interface{}); ok {
 			bodyParams = requestBodyMap
 		}
+		if headerParamsMap, ok := params["headerNames"].(map[string]interface{}); ok {
+			headerParams = headerParamsMap
+		}
+		if cookieParamsMap, ok := params["cookieNames"].(map[string]interface{}); ok {
+			cookieParams = cookieParamsMap
+		}
+		var bodyItems []interface{} // Items of a JSON array body
+		if isArrayBody(endpoint.bodyMedia, endpoint.bodySchema) {
+			bodyItems, _ = params["requestBody"].([]interface{})
+		}
 
-		if len(pathParams) == 0 && len(queryParams) == 0 && len(bodyParams) == 0 {
+		if len(pathParams) == 0 && len(queryParams) == 0 && len(bodyParams) == 0 && len(headerParams) == 0 && len(cookieParams) == 0 && bodyItems == nil {
 			for paramName, paramValue := range params {
 				placeholder := fmt.Sprintf("{%s}", paramName)
 				if strings.Contains(url, placeholder) {
@@ -82,18 +432,75 @@ func NewToolHandler(method string, url string, extraHeaders map[string]string) f
 			}
 		}
 
+		queryParams = endpoint.applyQueryDefaults(queryParams)
+		bodyParams = endpoint.applyBodyDefaults(bodyParams)
+
+		pathParams, err = endpoint.coercePath(pathParams)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Invalid value for pathNames.%v", err)), nil
+		}
+		queryParams, err = endpoint.coerceQuery(queryParams)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Invalid value for searchParams.%v", err)), nil
+		}
+		bodyParams, err = endpoint.coerceBody(bodyParams)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Invalid value for requestBody.%v", err)), nil
+		}
+		if bodyItems != nil {
+			coerced, err := coerceValue(bodyItems, endpoint.bodySchema)
+			if err != nil {
+				return newToolResultError(fmt.Sprintf("Invalid value for requestBody: %v", err)), nil
+			}
+			bodyItems = coerced.([]interface{})
+		}
+		if cfg.strictEnums {
+			if err := checkEnums(queryParams, endpoint.queryProperties()); err != nil {
+				return newToolResultError(fmt.Sprintf("Invalid value for searchParams.%v", err)), nil
+			}
+			if endpoint.bodySchema != nil {
+				if err := checkEnums(bodyParams, endpoint.bodySchema.Properties); err != nil {
+					return newToolResultError(fmt.Sprintf("Invalid value for requestBody.%v", err)), nil
+				}
+			}
+			if bodyItems != nil {
+				if err := checkEnum(bodyItems, *endpoint.bodySchema); err != nil {
+					return newToolResultError(fmt.Sprintf("Invalid value for requestBody: %v", err)), nil
+				}
+			}
+		}
+
+		missing := endpoint.missingRequired(map[string]map[string]interface{}{
+			"pathNames":    pathParams,
+			"searchParams": queryParams,
+			"headerNames":  headerParams,
+			"cookieNames":  cookieParams,
+			"requestBody":  bodyParams,
+		})
+		if isArrayBody(endpoint.bodyMedia, endpoint.bodySchema) && endpoint.bodyRequired && bodyItems == nil {
+			missing = append(missing, "requestBody")
+		}
+		if len(missing) > 0 {
+			return newToolResultError(fmt.Sprintf("Missing required parameters: %s", strings.Join(missing, ", "))), nil
+		}
+
+		pathParams = cfg.formatParams(pathParams, "path", endpoint)
+		queryParams = cfg.formatParams(queryParams, "query", endpoint)
+		headerParams = cfg.formatParams(headerParams, "header", endpoint)
+
 		finalURL := url
 		for paramName, paramValue := range pathParams {
 			placeholder := fmt.Sprintf("{%s}", paramName)
 			if strings.Contains(finalURL, placeholder) {
-				var strValue string
-				switch v := paramValue.(type) {
-				case string:
-					strValue = v
-				case nil:
-					strValue = ""
+				strValue := formatScalar(paramValue)
+				// Escape the value so characters such as "/", "?" or "#" cannot change the URL structure
+				param, _ := endpoint.parameter("path", paramName)
+				switch {
+				case cfg.rawPathParams[paramName]:
+				case param.AllowReserved:
+					strValue = escapePathRemainder(strValue)
 				default:
-					strValue = fmt.Sprintf("%v", v)
+					strValue = neturl.PathEscape(strValue)
 				}
 				finalURL = strings.ReplaceAll(finalURL, placeholder, strValue)
 			}
@@ -106,60 +513,536 @@ func NewToolHandler(method string, url string, extraHeaders map[string]string) f
 			}
 			q := parsedURL.Query()
 			for paramName, paramValue := range queryParams {
-				var strValue string
-				switch v := paramValue.(type) {
-				case string:
-					strValue = v
-				case nil:
-					continue
-				default:
-					strValue = fmt.Sprintf("%v", v)
-				}
-				q.Add(paramName, strValue)
+				param, _ := endpoint.parameter("query", paramName)
+				addQueryParam(q, paramName, paramValue, param)
 			}
 			parsedURL.RawQuery = q.Encode()
 			finalURL = parsedURL.String()
 		}
 
-		var reqBody io.Reader = nil
-		if len(bodyParams) > 0 {
-			jsonParams, err := json.Marshal(bodyParams)
+		if cfg.omitEmpty != nil {
+			bodyParams = cfg.omitEmpty.apply(bodyParams, endpoint.bodySchema, "")
+			if bodyItems != nil {
+				bodyItems = cfg.omitEmpty.applyItems(bodyItems, endpoint.bodySchema.Items, "")
+			}
+		}
+		if endpoint.grpcMethod != nil {
+			if err := endpoint.grpcMethod.checkUnary(); err != nil {
+				return newToolResultError(err.Error()), nil
+			}
+			reqBody, err = endpoint.grpcMethod.encodeRequest(bodyParams)
+			if err != nil {
+				return newToolResultError(fmt.Sprintf("Error encoding body parameters: %v", err)), nil
+			}
+		} else if endpoint.graphQLQuery != "" {
+			reqBody, err = cfg.encodeGraphQLBody(endpoint.graphQLQuery, bodyParams)
+			if err != nil {
+				return newToolResultError(fmt.Sprintf("Error encoding body parameters: %v", err)), nil
+			}
+		} else if len(bodyParams) > 0 {
+			reqBody, err = cfg.encodeRequestBody(endpoint.bodyMedia, endpoint.bodySchema, bodyParams)
+			if err != nil {
+				return newToolResultError(fmt.Sprintf("Error encoding body parameters: %v", err)), nil
+			}
+		} else if bodyItems != nil {
+			data, err := cfg.marshalBody(bodyItems, endpoint.bodySchema)
 			if err != nil {
-				return mcp.NewToolResultText(fmt.Sprintf("Error marshaling body parameters: %v", err)), nil
+				return newToolResultError(fmt.Sprintf("Error encoding body parameters: %v", err)), nil
 			}
-			reqBody = bytes.NewBuffer(jsonParams)
+			reqBody = newBytesBody(data, "application/json")
 		}
 
-		req, err := http.NewRequestWithContext(ctx, method, finalURL, reqBody)
+		headers, err := cfg.requestHeaders(ctx, extraHeaders)
 		if err != nil {
-			return mcp.NewToolResultText(fmt.Sprintf("Error creating request: %v", err)), nil
+			return newToolResultError(fmt.Sprintf("Error obtaining request headers: %v", err)), nil
 		}
 
-		if reqBody != nil {
-			req.Header.Set("Content-Type", "application/json")
+		if endpoint.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, endpoint.timeout)
+			defer cancel()
 		}
-		for key, value := range extraHeaders {
-			req.Header.Set(key, value)
+
+		// Retries of this call send the same key, so the upstream can recognize them as repeats
+		idempotencyKey := cfg.idempotencyKey(method)
+
+		var entryKey string
+		var csrfToken string
+		var stale *cachedResponse // Expired cached response to revalidate, if any
+		requestURL := finalURL    // Changed to the URL of further pages when paginating
+
+		// The request is rebuilt for every attempt so the body can be replayed on retries.
+		// newUnsignedRequest builds it without credentials, which newRequest adds.
+		newUnsignedRequest := func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+			if err != nil {
+				return nil, err
+			}
+			sent = req
+
+			if reqBody != nil {
+				if req.Body, err = reqBody.open(); err != nil {
+					return nil, err
+				}
+				req.GetBody = reqBody.open
+				req.ContentLength = reqBody.length
+				req.Header.Set("Content-Type", reqBody.contentType)
+			}
+			if endpoint.accept != "" {
+				req.Header.Set("Accept", endpoint.accept)
+			}
+			if endpoint.grpcMethod != nil {
+				// gRPC servers require clients to declare that they accept trailers
+				req.Header.Set("TE", "trailers")
+			}
+			if byteRange != "" {
+				req.Header.Set("Range", byteRange)
+			}
+			for key, value := range headers {
+				req.Header.Set(key, value)
+			}
+			for key, value := range headerParams {
+				if value == nil {
+					continue
+				}
+				req.Header.Set(key, fmt.Sprintf("%v", value))
+			}
+			if csrfToken != "" {
+				req.Header.Set(cfg.csrf.Header, csrfToken)
+			}
+			if idempotencyKey != "" && req.Header.Get(cfg.idempotencyKeys) == "" {
+				req.Header.Set(cfg.idempotencyKeys, idempotencyKey)
+			}
+			addCookieParams(req, cookieParams)
+			return req, nil
+		}
+		newRequest := func() (*http.Request, error) {
+			req, err := newUnsignedRequest()
+			if err != nil {
+				return nil, err
+			}
+			for _, auth := range endpoint.auths {
+				if err := auth.apply(req); err != nil {
+					return nil, err
+				}
+			}
+			if stale != nil {
+				stale.addConditions(req)
+			}
+			cfg.injectTraceContext(ctx, req)
+			return req, nil
+		}
+
+		if endpoint.mock != nil {
+			return cfg.newToolResult(endpoint, finalURL, endpoint.mock.response(), endpoint.mock.body, false, 1, 0), nil
+		}
+
+		if cfg.dryRun {
+			req, err := newRequest()
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("Error executing request: %v", err)), nil
+			}
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return cfg.newDryRunResult(req, reqBody, cfg.redactor.secrets(req, endpoint.auths)), nil
+		}
+
+		// Safe requests are answered from the cache when possible, keyed on the request as it would be
+		// sent without credentials: signatures and tokens change from one call to the next, and
+		// applying them could fetch a token for a request answered from the cache
+		if endpoint.cacheTTL > 0 && cfg.cache != nil && isSafeMethod(method) {
+			req, err := newUnsignedRequest()
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("Error executing request: %v", err)), nil
+			}
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			entryKey = cacheKey(req)
+			cached, fresh := cfg.cache.get(entryKey)
+			if fresh {
+				return cfg.newToolResult(endpoint, finalURL, cached.response(), cached.body, cached.truncated, 1, 0), nil
+			}
+			stale = cached
 		}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		if err := waitAll(ctx, endpoint.limiters); err != nil {
+			return newToolResultError(fmt.Sprintf("Request not sent: %v", err)), nil
+		}
+		release, err := acquireAll(ctx, endpoint.slots)
 		if err != nil {
+			return newToolResultError(fmt.Sprintf("Request not sent: %v", err)), nil
+		}
+		defer release()
+		// The token is fetched before the circuit breaker lets the request through, since every
+		// request it allows must report its outcome
+		if cfg.csrf != nil && needsCSRFToken(method) {
+			if csrfToken, err = cfg.csrf.get(ctx, cfg.httpClient, finalURL, false, headers, endpoint.auths); err != nil {
+				return newToolResultError(fmt.Sprintf("Error obtaining CSRF token: %v", err)), nil
+			}
+		}
+		if err := endpoint.circuit.allow(); err != nil {
+			return newToolResultError(fmt.Sprintf("Request not sent: %v", err)), nil
+		}
+
+		ctx, span := cfg.startSpan(ctx, method, finalURL)
+		defer span.End()
+		start := time.Now()
+
+		resp, attempts, err := doWithRetry(ctx, cfg.httpClient, cfg.retry, method, newRequest)
+		if err == nil && resp.StatusCode == http.StatusForbidden && csrfToken != "" {
+			// The token may have expired; the request is sent once more with a fresh one
+			if token, tokenErr := cfg.csrf.get(ctx, cfg.httpClient, finalURL, true, headers, endpoint.auths); tokenErr == nil && token != csrfToken {
+				drainResponse(resp)
+				csrfToken = token
+				var more int
+				resp, more, err = doWithRetry(ctx, cfg.httpClient, cfg.retry, method, newRequest)
+				attempts += more
+			}
+		}
+		if err != nil {
+			endpoint.circuit.done(err, 0)
+			cfg.logFailure(ctx, method, finalURL, err, time.Since(start), attempts, cfg.redactor.secrets(sent, endpoint.auths))
+			cfg.observeCall(CallMetrics{Tool: endpoint.name, Method: method, Duration: time.Since(start), RequestBytes: requestLength(reqBody), Err: err})
+			span.RecordError(err)
+			if ctx.Err() == context.DeadlineExceeded {
+				return mcp.NewToolResultText(timeoutMessage(endpoint.timeout, start)), nil
+			}
+			if attempts > 1 {
+				return mcp.NewToolResultText(fmt.Sprintf("Error executing request after %d attempts: %v", attempts, err)), nil
+			}
 			return mcp.NewToolResultText(fmt.Sprintf("Error executing request: %v", err)), nil
 		}
 		defer resp.Body.Close()
+		endpoint.circuit.done(nil, resp.StatusCode)
+		span.SetAttribute("http.response.status_code", resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			span.RecordError(fmt.Errorf("HTTP %s", resp.Status))
+		}
+
+		bodyReader, err := decodeResponseBody(resp, cfg.decoders)
+		if err != nil {
+			cfg.observeCall(CallMetrics{Tool: endpoint.name, Method: method, StatusCode: resp.StatusCode, Duration: time.Since(start), RequestBytes: requestLength(reqBody), Err: err})
+			return mcp.NewToolResultText(fmt.Sprintf("Error decoding response: %v", err)), nil
+		}
+
+		// Downloads are streamed to a file instead of being read into memory
+		if cfg.downloads != nil && isSuccessStatus(resp.StatusCode) && !strings.EqualFold(method, http.MethodHead) {
+			saved, rest, err := cfg.downloads.save(endpoint.name, resp, bodyReader)
+			if err != nil {
+				cfg.observeCall(CallMetrics{Tool: endpoint.name, Method: method, StatusCode: resp.StatusCode, Duration: time.Since(start), RequestBytes: requestLength(reqBody), Err: err})
+				return newToolResultError(fmt.Sprintf("Error saving response: %v", err)), nil
+			}
+			if saved != nil {
+				cfg.observeCall(CallMetrics{Tool: endpoint.name, Method: method, StatusCode: resp.StatusCode, Duration: time.Since(start), RequestBytes: requestLength(reqBody), ResponseBytes: saved.Size})
+				return newDownloadResult(saved), nil
+			}
+			bodyReader = rest
+		}
 
-		body, err := io.ReadAll(resp.Body)
+		body, truncated, err := readLimited(bodyReader, endpoint.maxResponse)
+		cfg.observeCall(CallMetrics{
+			Tool:          endpoint.name,
+			Method:        method,
+			StatusCode:    resp.StatusCode,
+			Duration:      time.Since(start),
+			RequestBytes:  requestLength(reqBody),
+			ResponseBytes: int64(len(body)),
+			Err:           err,
+		})
 		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return mcp.NewToolResultText(timeoutMessage(endpoint.timeout, start)), nil
+			}
 			return mcp.NewToolResultText(fmt.Sprintf("Error reading response: %v", err)), nil
 		}
+		cfg.logResponse(ctx, sent, reqBody, resp, body, time.Since(start), attempts, cfg.redactor.secrets(sent, endpoint.auths))
+
+		if stale != nil && resp.StatusCode == http.StatusNotModified {
+			refreshed := stale.revalidated(resp, endpoint.cacheTTL)
+			cfg.cache.put(refreshed)
+			return cfg.newToolResult(endpoint, finalURL, refreshed.response(), refreshed.body, refreshed.truncated, attempts, time.Since(start)), nil
+		}
+
+		if endpoint.pagination != nil && isSuccessStatus(resp.StatusCode) && !truncated {
+			// Further pages are sent unconditionally and never served from the cache
+			fetchPage := func(pageURL string) (*http.Response, []byte, error) {
+				requestURL, stale = pageURL, nil
+				if err := waitAll(ctx, endpoint.limiters); err != nil {
+					return nil, nil, err
+				}
+				if err := endpoint.circuit.allow(); err != nil {
+					return nil, nil, err
+				}
+				start := time.Now()
+				resp, attempts, err := doWithRetry(ctx, cfg.httpClient, cfg.retry, method, newRequest)
+				if err != nil {
+					endpoint.circuit.done(err, 0)
+					cfg.observeCall(CallMetrics{Tool: endpoint.name, Method: method, Duration: time.Since(start), RequestBytes: requestLength(reqBody), Err: err})
+					return nil, nil, err
+				}
+				defer resp.Body.Close()
+				endpoint.circuit.done(nil, resp.StatusCode)
+
+				bodyReader, err := decodeResponseBody(resp, cfg.decoders)
+				if err != nil {
+					return nil, nil, err
+				}
+				body, truncated, err := readLimited(bodyReader, endpoint.maxResponse)
+				cfg.observeCall(CallMetrics{
+					Tool:          endpoint.name,
+					Method:        method,
+					StatusCode:    resp.StatusCode,
+					Duration:      time.Since(start),
+					RequestBytes:  requestLength(reqBody),
+					ResponseBytes: int64(len(body)),
+					Err:           err,
+				})
+				if err != nil {
+					return nil, nil, err
+				}
+				cfg.logResponse(ctx, sent, reqBody, resp, body, time.Since(start), attempts, cfg.redactor.secrets(sent, endpoint.auths))
+				if truncated {
+					return nil, nil, fmt.Errorf("response exceeds the size limit of %d bytes", endpoint.maxResponse)
+				}
+				return resp, body, nil
+			}
+
+			body, err = endpoint.pagination.follow(finalURL, resp, body, fetchPage)
+			if err != nil {
+				return newToolResultError(fmt.Sprintf("Error fetching the next page: %v", err)), nil
+			}
+			if endpoint.maxResponse > 0 && int64(len(body)) > endpoint.maxResponse {
+				body, truncated = body[:endpoint.maxResponse], true
+			}
+		}
+
+		if entryKey != "" && isCacheableResponse(resp) {
+			cfg.cache.put(newCachedResponse(entryKey, resp, body, truncated, endpoint.cacheTTL))
+		}
+
+		return cfg.newToolResult(endpoint, finalURL, resp, body, truncated, attempts, time.Since(start)), nil
+	}
+}
+
+// newToolResult converts an upstream response whose body has been read into a tool result.
+// elapsed is the time spent on the upstream requests; it is zero for responses not requested now.
+func (c *adapterConfig) newToolResult(endpoint toolEndpoint, uri string, resp *http.Response, body []byte, truncated bool, attempts int, elapsed time.Duration) (result *mcp.CallToolResult) {
+	enveloped := false
+	defer func() {
+		if enveloped {
+			return
+		}
+		if c.showURL {
+			result.Content = append([]mcp.Content{mcp.NewTextContent("Request URL: " + c.redactor.redactURL(uri, nil))}, result.Content...)
+		}
+		if c.showDuration && elapsed > 0 {
+			result.Content = append(result.Content, mcp.NewTextContent("Duration: "+elapsed.Round(time.Millisecond).String()))
+		}
+	}()
+
+	if c.isErrorStatus(resp.StatusCode) {
+		return newToolResultError(statusErrorMessage(resp, body, attempts))
+	}
+	// GraphQL reports failures in the body of successful responses
+	if endpoint.graphQLQuery != "" && !truncated {
+		if messages := graphQLErrors(body); messages != "" {
+			return newToolResultError(fmt.Sprintf("GraphQL request failed: %s. Response: %s", messages, body))
+		}
+	}
+	// gRPC reports failures in the trailers of successful responses, and sends protobuf messages
+	if endpoint.grpcMethod != nil && isGRPCResponse(resp) {
+		if truncated {
+			return newToolResultError(fmt.Sprintf("gRPC response exceeds the size limit of %d bytes", endpoint.maxResponse))
+		}
+		converted, err := endpoint.grpcMethod.decodeResponse(resp, body)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("gRPC request failed: %v", err))
+		}
+		resp, body = withJSONContentType(resp), converted
+	}
+	if method := strings.ToUpper(endpoint.method); (method == http.MethodHead || method == http.MethodOptions) && !c.envelope {
+		return newHeadersResult(method, resp, body)
+	}
+	if len(body) == 0 && isSuccessStatus(resp.StatusCode) && c.emptyMessage != "" && !c.envelope {
+		return newEmptyResult(resp, c.emptyMessage, c.emptyHeaders)
+	}
+
+	// Binary bodies would be corrupted by a conversion to text
+	if contentType := resp.Header.Get("Content-Type"); isBinaryContentType(contentType, c.binaryContentTypes) {
+		if truncated {
+			return newToolResultError(fmt.Sprintf("Binary response exceeds the size limit of %d bytes%s", endpoint.maxResponse, totalSizeNote(resp)))
+		}
+		return newBinaryResult(uri, contentType, body)
+	}
+
+	// A truncated body cannot be parsed, so it is returned as is
+	contentType := resp.Header.Get("Content-Type")
+	if c.xmlToJSON && !truncated && isXMLMediaType(contentType) {
+		if converted, err := xmlToJSON(body); err != nil {
+			c.logger.Warn("XML response conversion failed", "tool", endpoint.name, "error", err)
+		} else {
+			body, contentType = converted, "application/json"
+		}
+	}
+	if endpoint.extract != nil && !truncated {
+		body = c.extractBody(endpoint, body)
+	}
+	if endpoint.template != nil && !truncated {
+		rendered, err := renderTemplate(endpoint.template, body)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Error rendering response template: %v. Response: %s", err, body))
+		}
+		return mcp.NewToolResultText(string(rendered))
+	}
+
+	if truncated {
+		body = append(trimPartialRune(body), truncationNote(endpoint.maxResponse, resp)...)
+	}
+	if note := partialContentNote(resp); note != "" && !c.envelope {
+		body = append(body, note...)
+	}
+
+	if c.envelope {
+		envelope := newResponseEnvelope(resp, body, c.envelopeHeaders)
+		if c.showURL {
+			envelope.URL = c.redactor.redactURL(uri, nil)
+		}
+		if c.showDuration {
+			envelope.DurationMs = elapsed.Milliseconds()
+		}
+		envelopeJSON, err := json.Marshal(envelope)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("Error marshaling response: %v", err))
+		}
+		enveloped = true
+		return mcp.NewToolResultText(string(envelopeJSON))
+	}
+
+	if c.jsonContent && isJSONContentType(contentType) {
+		if result := newJSONResult(uri, body); result != nil {
+			return result
+		}
+	}
+
+	return mcp.NewToolResultText(string(body))
+}
+
+// requestBodySchema returns the media type and schema the handler uses to encode the request body,
+// preferring application/json and otherwise the first media type in lexical order
+func requestBodySchema(body *RequestBody) (string, *Schema) {
+	if body == nil || len(body.Content) == 0 {
+		return "", nil
+	}
+	if mediaType, ok := body.Content["application/json"]; ok {
+		return "application/json", mediaType.Schema
+	}
+
+	names := make([]string, 0, len(body.Content))
+	for name := range body.Content {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names[0], body.Content[names[0]].Schema
+}
+
+// bodyMediaTypes returns the media types of a request body in sorted order, except that the media
+// type the body is sent as comes last, so its properties win over those of the other media types
+func bodyMediaTypes(body *RequestBody, selected string) []string {
+	names := make([]string, 0, len(body.Content))
+	for name := range body.Content {
+		if name != selected {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := body.Content[selected]; ok {
+		names = append(names, selected)
+	}
+	return names
+}
+
+// addCookieParams adds cookie parameters to the request in a stable order, URL-encoding their values
+func addCookieParams(req *http.Request, cookieParams map[string]interface{}) {
+	names := make([]string, 0, len(cookieParams))
+	for name, value := range cookieParams {
+		if value != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		req.AddCookie(&http.Cookie{
+			Name:  name,
+			Value: neturl.QueryEscape(fmt.Sprintf("%v", cookieParams[name])),
+		})
+	}
+}
+
+// isReservedHeaderParam reports whether a header parameter must be ignored per the OpenAPI specification
+func isReservedHeaderParam(name string) bool {
+	return strings.EqualFold(name, "Accept") ||
+		strings.EqualFold(name, "Content-Type") ||
+		strings.EqualFold(name, "Authorization")
+}
+
+// isSuccessStatus reports whether the status code is in the 2xx range
+func isSuccessStatus(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// statusErrorMessage describes a non-2xx upstream response, keeping its body for context
+func statusErrorMessage(resp *http.Response, body []byte, attempts int) string {
+	msg := fmt.Sprintf("Request failed with HTTP %s", resp.Status)
+	if attempts > 1 {
+		msg += fmt.Sprintf(" after %d attempts", attempts)
+	}
+	if len(body) > 0 {
+		msg += ": " + string(body)
+	}
+	return msg
+}
+
+// newToolResultError creates a text tool result flagged as an error
+func newToolResultError(text string) *mcp.CallToolResult {
+	result := mcp.NewToolResultText(text)
+	result.IsError = true
+	return result
+}
+
+// timeoutMessage describes a request that was aborted by its timeout
+func timeoutMessage(timeout time.Duration, start time.Time) string {
+	elapsed := time.Since(start).Round(time.Millisecond)
+	return fmt.Sprintf("Error executing request: timed out after %s (timeout %s)", elapsed, timeout)
+}
 
-		return mcp.NewToolResultText(string(body)), nil
+// NewMCPServer creates an MCP server exposing one tool per API endpoint of the parser, configured
+// entirely with options. Unless WithUpstreamURL is given, the servers declared by the specification
+// are used, with their variables set to their default values.
+func NewMCPServer(parser OpenAPIParser, opts ...AdapterOption) (*server.MCPServer, error) {
+	cfg := newAdapterConfig(opts...)
+	if cfg.err != nil {
+		return nil, cfg.err
 	}
+	return newMCPFromParser(cfg, "", nil, parser)
 }
 
+// NewMCPFromCustomParser creates an MCP server exposing one tool per API endpoint of the parser.
+// If baseURL is empty, the servers declared by the specification are used, with their variables
+// set to their default values. Operations declaring their own servers use them even if baseURL is set.
+// The arguments take precedence over WithUpstreamURL and WithExtraHeaders.
+func NewMCPFromCustomParser(baseURL string, extraHeaders map[string]string, parser OpenAPIParser, opts ...AdapterOption) (*server.MCPServer, error) {
+	cfg := newAdapterConfig(opts...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	return newMCPFromParser(cfg, baseURL, extraHeaders, parser)
+}
 
-func NewMCPFromCustomParser(baseURL string, extraHeaders map[string]string, parser OpenAPIParser) (*server.MCPServer, error) {
+// newMCPFromParser creates the MCP server using an already resolved adapter configuration
+func newMCPFromParser(cfg *adapterConfig, baseURL string, extraHeaders map[string]string, parser OpenAPIParser) (*server.MCPServer, error) {
 	apiInfo := parser.Info()
 	prefix := sanitizeToolName(apiInfo.Title)
 
@@ -168,12 +1051,68 @@ func NewMCPFromCustomParser(baseURL string, extraHeaders map[string]string, pars
 		apiInfo.Version,
 		server.WithResourceCapabilities(true, true),
 		server.WithLogging(),
+		server.WithHooks(cfg.resources.hooks()),
 	)
 
-	for _, api := range parser.APIs() {
-		name := sanitizeToolName(fmt.Sprintf("%s", api.OperationID))
+	if cfg.specResource {
+		if err := addSpecResource(s, parser, apiInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	tools, err := buildTools(cfg, s, prefix, baseURL, extraHeaders, parser)
+	if err != nil {
+		return nil, err
+	}
+	if len(tools) > 0 {
+		s.AddTools(tools...)
+	}
+	return s, nil
+}
+
+// buildTools creates one tool per operation of the parser. Resources for GET operations,
+// if enabled, are registered on s directly.
+func buildTools(cfg *adapterConfig, s *server.MCPServer, prefix string, baseURL string, extraHeaders map[string]string, parser OpenAPIParser) ([]server.ServerTool, error) {
+	baseURL, extraHeaders = cfg.upstream(baseURL, extraHeaders)
+	extraHeaders, err := cfg.expandHeaderEnv(extraHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fall back to the first server declared by the specification
+	defaultURL := baseURL
+	if defaultURL == "" {
+		if servers := parser.Servers(); len(servers) > 0 {
+			var err error
+			if defaultURL, err = cfg.serverURL(servers[0]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	security := newSecurityResolver(cfg, securitySchemes(parser))
+
+	apis := parser.APIs()
+	sortOperations(apis)
+
+	var tools []server.ServerTool
+	names := newToolNames(cfg.maxToolNameLength)
+	for _, api := range apis {
+		if !cfg.includeOperation(api) {
+			continue
+		}
+
+		name := names.resolve(cfg.toolName(api), api)
+		description := api.OperationID + " " + api.Summary + " " + api.Description
+		if requirement := securityDescription(api.Security); requirement != "" {
+			description += " " + requirement
+		}
+		// mcp-go has no output schema on tools, so the response shape is described instead
+		if output := api.SuccessResponseSchema(); output != nil {
+			description += " Returns: " + describeSchemaShape(output, 0)
+		}
 		opts := []mcp.ToolOption{
-			mcp.WithDescription(api.OperationID + " " + api.Summary + " " + api.Description),
+			mcp.WithDescription(description),
 		}
 
 		queryProps := map[string]interface{}{}
@@ -182,26 +1121,14 @@ func NewMCPFromCustomParser(baseURL string, extraHeaders map[string]string, pars
 		pathProps := map[string]interface{}{}
 		requiredPathParams := []string{}
 
+		headerProps := map[string]interface{}{}
+		requiredHeaderParams := []string{}
+
+		cookieProps := map[string]interface{}{}
+		requiredCookieParams := []string{}
+
 		for _, param := range api.Parameters {
-			prop := map[string]interface{}{
-				"type":        param.Schema.Type,
-				"description": prefixRequired(param.Required, param.Description),
-			}
-			if param.Schema.Enum != nil {
-				prop["enum"] = param.Schema.Enum
-			}
-			if param.Schema.Format != "" {
-				prop["format"] = param.Schema.Format
-			}
-			if param.Schema.Default != nil {
-				prop["default"] = param.Schema.Default
-			}
-			if param.Schema.Items != nil {
-				prop["items"] = param.Schema.Items
-			}
-			if param.Schema.Properties != nil {
-				prop["properties"] = param.Schema.Properties
-			}
+			prop := schemaProperty(*param.Schema, prefixRequired(param.Required, param.Description), 0)
 
 			switch param.In {
 			case "query":
@@ -214,6 +1141,20 @@ func NewMCPFromCustomParser(baseURL string, extraHeaders map[string]string, pars
 				if param.Required {
 					requiredPathParams = append(requiredPathParams, param.Name)
 				}
+			case "header":
+				// OpenAPI requires these header parameters to be ignored, they are controlled by the adapter
+				if isReservedHeaderParam(param.Name) {
+					continue
+				}
+				headerProps[param.Name] = prop
+				if param.Required {
+					requiredHeaderParams = append(requiredHeaderParams, param.Name)
+				}
+			case "cookie":
+				cookieProps[param.Name] = prop
+				if param.Required {
+					requiredCookieParams = append(requiredCookieParams, param.Name)
+				}
 			}
 		}
 
@@ -235,53 +1176,147 @@ func NewMCPFromCustomParser(baseURL string, extraHeaders map[string]string, pars
 				},
 			))
 		}
+		if len(headerProps) > 0 {
+			opts = append(opts, mcp.WithObject("headerNames",
+				mcp.Description("header parameters for the tool"),
+				mcp.Properties(headerProps),
+				func(schema map[string]interface{}) {
+					schema["required"] = requiredHeaderParams
+				},
+			))
+		}
+		if len(cookieProps) > 0 {
+			opts = append(opts, mcp.WithObject("cookieNames",
+				mcp.Description("cookie parameters for the tool"),
+				mcp.Properties(cookieProps),
+				func(schema map[string]interface{}) {
+					schema["required"] = requiredCookieParams
+				},
+			))
+		}
+		rangeArg := cfg.rangeRequests && strings.EqualFold(api.Method, http.MethodGet)
+		if rangeArg {
+			opts = append(opts, mcp.WithString(rangeArgument,
+				mcp.Description(`byte range of the response to fetch, e.g. "bytes=0-1023" for the first 1024 bytes`),
+			))
+		}
 
 		bodyProps := map[string]interface{}{}
 		requiredBodyParams := []string{}
+		bodyMedia, bodySchema := requestBodySchema(api.RequestBody)
 
 		if api.RequestBody != nil && len(api.RequestBody.Content) > 0 {
-			for _, mediaType := range api.RequestBody.Content {
+			for _, mediaName := range bodyMediaTypes(api.RequestBody, bodyMedia) {
+				mediaType := api.RequestBody.Content[mediaName]
 				if mediaType.Schema != nil {
-					for propName, propSchema := range mediaType.Schema.Properties {
-						prop := map[string]interface{}{
-							"type":        propSchema.Type,
-							"description": prefixRequired(isRequiredField(propName, mediaType.Schema.Required), propSchema.Description),
-						}
-						if propSchema.Enum != nil {
-							prop["enum"] = propSchema.Enum
+					for _, propName := range sortedSchemaNames(mediaType.Schema.Properties) {
+						propSchema := mediaType.Schema.Properties[propName]
+						propDescription := propSchema.Description
+						if isBinarySchema(propSchema) && len(cfg.uploadDirs) > 0 {
+							propDescription = strings.TrimSpace(propDescription + " (path of the local file to upload)")
 						}
-						if propSchema.Format != "" {
-							prop["format"] = propSchema.Format
-						}
-						if propSchema.Default != nil {
-							prop["default"] = propSchema.Default
-						}
-						if propSchema.Items != nil {
-							prop["items"] = propSchema.Items
-						}
-						if propSchema.Properties != nil {
-							prop["properties"] = propSchema.Properties
-						}
-						bodyProps[propName] = prop
-						if isRequiredField(propName, mediaType.Schema.Required) {
+						required := isRequiredField(propName, mediaType.Schema.Required)
+						bodyProps[propName] = schemaProperty(propSchema, prefixRequired(required, propDescription), 0)
+						if required && !isRequiredField(propName, requiredBodyParams) {
 							requiredBodyParams = append(requiredBodyParams, propName)
 						}
 					}
 				}
 			}
-			opts = append(opts, mcp.WithObject("requestBody",
-				mcp.Description("request body for the tool"),
-				mcp.Properties(bodyProps),
-				func(schema map[string]interface{}) {
-					schema["required"] = requiredBodyParams
-				},
-			))
+			if isRawBody(bodyMedia, bodySchema) {
+				rawDescription := "raw request body content"
+				if len(cfg.uploadDirs) > 0 && (bodySchema == nil || isBinarySchema(*bodySchema)) {
+					rawDescription = "path of the local file to upload as the request body"
+				}
+				bodyProps[rawBodyProperty] = map[string]interface{}{
+					"type":        "string",
+					"description": prefixRequired(api.RequestBody.Required, rawDescription),
+				}
+				if api.RequestBody.Required {
+					requiredBodyParams = append(requiredBodyParams, rawBodyProperty)
+				}
+			}
+			if isArrayBody(bodyMedia, bodySchema) {
+				arrayOpts := []mcp.PropertyOption{mcp.Description("request body for the tool, a JSON array")}
+				if bodySchema.Items != nil {
+					arrayOpts = append(arrayOpts, mcp.Items(schemaProperty(*bodySchema.Items, bodySchema.Items.Description, 1)))
+				}
+				if api.RequestBody.Required {
+					arrayOpts = append(arrayOpts, mcp.Required())
+				}
+				opts = append(opts, mcp.WithArray("requestBody", arrayOpts...))
+			} else {
+				opts = append(opts, mcp.WithObject("requestBody",
+					mcp.Description("request body for the tool"),
+					mcp.Properties(bodyProps),
+					func(schema map[string]interface{}) {
+						schema["required"] = requiredBodyParams
+					},
+				))
+			}
+		}
+
+		operationURL, err := cfg.operationURL(baseURL, defaultURL, api)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", api.OperationID, err)
+		}
+
+		opCfg := cfg.operation(api.OperationID)
+		timeout, err := cfg.timeoutFor(opCfg, api)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", api.OperationID, err)
+		}
+		opHeaders, err := cfg.headersFor(opCfg, api, extraHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", api.OperationID, err)
+		}
+
+		var mock *mockResponse
+		if cfg.mockResponses {
+			if mock, err = newMockResponse(api); err != nil {
+				return nil, fmt.Errorf("operation %s: %w", api.OperationID, err)
+			}
 		}
 
 		tool := mcp.NewTool(name, opts...)
-		handler := NewToolHandler(api.Method, baseURL+api.Path, extraHeaders)
-		s.AddTool(tool, handler)
+		handler := newToolHandler(toolEndpoint{
+			name:         name,
+			method:       api.Method,
+			url:          operationURL,
+			extraHeaders: opHeaders,
+			timeout:      timeout,
+			maxResponse:  cfg.maxResponseFor(opCfg),
+			auths:        cfg.authFor(opCfg, security.resolve(api.Security)),
+			limiters:     cfg.limitersFor(api.OperationID, operationURL),
+			slots:        cfg.semaphoresFor(operationURL),
+			circuit:      cfg.circuits.forURL(operationURL),
+			cacheTTL:     cfg.cacheTTLFor(opCfg),
+			pagination:   cfg.paginationFor(opCfg),
+			extract:      cfg.extractFor(opCfg),
+			template:     cfg.templateFor(api.OperationID, opCfg),
+			mock:         mock,
+			parameters:   api.Parameters,
+			bodySchema:   bodySchema,
+			bodyMedia:    bodyMedia,
+			bodyRequired: api.RequestBody != nil && api.RequestBody.Required,
+			accept:       cfg.acceptFor(opCfg, api.Responses),
+			byteRange:    opCfg.Range,
+			rangeArg:     rangeArg,
+			graphQLQuery: graphQLQueryFor(api),
+			grpcMethod:   grpcMethodFor(api),
+			required: map[string][]string{
+				"pathNames":    requiredPathParams,
+				"searchParams": requiredQueryParams,
+				"headerNames":  requiredHeaderParams,
+				"cookieNames":  requiredCookieParams,
+				"requestBody":  requiredBodyParams,
+			},
+		}, cfg)
+		tools = append(tools, server.ServerTool{Tool: tool, Handler: handler})
+		if cfg.getResources && isResourceOperation(api) {
+			addResource(s, cfg.resources, prefix, api, description, handler)
+		}
 	}
 
-	return s, nil
+	return tools, nil
 }
@@ -0,0 +1,640 @@
+package utils
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resultText returns the text of the first content item of a tool result
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	if len(result.Content) == 0 {
+		t.Fatalf("Tool result has no content")
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("Expected text content, got %T", result.Content[0])
+	}
+	return text.Text
+}
+
+func Test_NonSuccessStatusIsError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	}))
+	defer ts.Close()
+
+	result, err := NewToolHandler(http.MethodGet, ts.URL, nil)(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatalf("Expected an error result")
+	}
+	if text := resultText(t, result); !strings.Contains(text, "404") || !strings.Contains(text, "not found") {
+		t.Fatalf("Unexpected result: %q", text)
+	}
+
+	result, err = NewToolHandler(http.MethodGet, ts.URL, nil, WithErrorPassThrough(true))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsError || resultText(t, result) != `{"error":"not found"}` {
+		t.Fatalf("Expected the raw body to be passed through, got %+v", result)
+	}
+}
+
+func Test_ResponseEnvelope(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", "42")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(`[1,2,3]`))
+	}))
+	defer ts.Close()
+
+	result, err := NewToolHandler(http.MethodGet, ts.URL, nil, WithResponseEnvelope("*"))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var envelope struct {
+		Status  int               `json:"status"`
+		Headers map[string]string `json:"headers"`
+		Body    []int             `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(resultText(t, result)), &envelope); err != nil {
+		t.Fatalf("Error unmarshaling envelope: %v", err)
+	}
+	if envelope.Status != http.StatusOK || len(envelope.Body) != 3 {
+		t.Fatalf("Unexpected envelope: %+v", envelope)
+	}
+	if envelope.Headers["X-Total-Count"] != "42" {
+		t.Fatalf("Expected X-Total-Count header, got %v", envelope.Headers)
+	}
+	if _, ok := envelope.Headers["Set-Cookie"]; ok {
+		t.Fatalf("Set-Cookie must not be included by the wildcard")
+	}
+}
+
+func Test_RequestURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	url := ts.URL + "/items?api_key=secret&page=2"
+	result, err := NewToolHandler(http.MethodGet, url, nil, WithRequestURL(true), WithRedactedQueryParams("api_key"))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := resultText(t, result); got != "Request URL: "+ts.URL+"/items?api_key=%5BREDACTED%5D&page=2" {
+		t.Errorf("Got first content %q", got)
+	}
+	if len(result.Content) != 2 {
+		t.Errorf("Got %d contents; want the URL and the response", len(result.Content))
+	}
+
+	result, _ = NewToolHandler(http.MethodGet, ts.URL, nil, WithRequestURL(true), WithResponseEnvelope())(context.Background(), mcp.CallToolRequest{})
+	var envelope responseEnvelope
+	if err := json.Unmarshal([]byte(resultText(t, result)), &envelope); err != nil || envelope.URL != ts.URL {
+		t.Errorf("Got envelope URL %q (%v); want %q", envelope.URL, err, ts.URL)
+	}
+}
+
+func Test_RequestDuration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	result, err := NewToolHandler(http.MethodGet, ts.URL, nil, WithRequestDuration(true))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	last, ok := result.Content[len(result.Content)-1].(mcp.TextContent)
+	if len(result.Content) != 2 || !ok || !strings.HasPrefix(last.Text, "Duration: ") {
+		t.Errorf("Got contents %+v; want the response followed by the duration", result.Content)
+	}
+
+	result, _ = NewToolHandler(http.MethodGet, ts.URL, nil, WithRequestDuration(true), WithResponseEnvelope())(context.Background(), mcp.CallToolRequest{})
+	var envelope responseEnvelope
+	if err := json.Unmarshal([]byte(resultText(t, result)), &envelope); err != nil || envelope.DurationMs < 20 {
+		t.Errorf("Got envelope duration %dms (%v); want at least 20ms", envelope.DurationMs, err)
+	}
+}
+
+func Test_EmptyResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/pets/7")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	result, err := NewToolHandler(http.MethodDelete, ts.URL, nil)(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultText(t, result); got != "Request succeeded (204 No Content)" {
+		t.Errorf("Got %q", got)
+	}
+
+	handler := NewToolHandler(http.MethodDelete, ts.URL, nil, WithEmptyResponseMessage("Done: {status}", "Location"))
+	result, err = handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultText(t, result); got != "Done: 204 No Content\nLocation: /pets/7" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func Test_HeadAndOptionsResults(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		w.Header().Set("Set-Cookie", "session=secret")
+	}))
+	defer ts.Close()
+
+	result, err := NewToolHandler(http.MethodHead, ts.URL, nil)(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var head headersResult
+	if err := json.Unmarshal([]byte(resultText(t, result)), &head); err != nil {
+		t.Fatal(err)
+	}
+	if head.Status != http.StatusOK || head.Headers["Etag"] != `"v1"` || head.Headers["Set-Cookie"] != "" {
+		t.Errorf("Got HEAD result %+v", head)
+	}
+
+	result, err = NewToolHandler(http.MethodOptions, ts.URL, nil)(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resultText(t, result), `{"status":200,"headers":{"Allow":"GET, HEAD, OPTIONS"}}`; got != want {
+		t.Errorf("Got OPTIONS result %s; want %s", got, want)
+	}
+}
+
+func Test_DecodeCompressedResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte("hello"))
+		gz.Close()
+	}))
+	defer ts.Close()
+
+	// An explicit Accept-Encoding header disables the transparent decompression of net/http
+	handler := NewToolHandler(http.MethodGet, ts.URL, map[string]string{"Accept-Encoding": "gzip"})
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text := resultText(t, result); text != "hello" {
+		t.Fatalf("Unexpected result: %q", text)
+	}
+}
+
+func Test_DecodeBrotliResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		bw := brotli.NewWriter(w)
+		bw.Write([]byte("hello"))
+		bw.Close()
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, map[string]string{"Accept-Encoding": "br"})
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text := resultText(t, result); text != "hello" {
+		t.Fatalf("Unexpected result: %q", text)
+	}
+}
+
+func Test_RedirectStripsHeadersAcrossHosts(t *testing.T) {
+	var gotKey string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-Api-Key")
+		w.Write([]byte("moved"))
+	}))
+	defer target.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer origin.Close()
+
+	headers := map[string]string{"X-Api-Key": "secret"}
+	result, err := NewToolHandler(http.MethodGet, origin.URL, headers)(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resultText(t, result) != "moved" || gotKey != "" {
+		t.Fatalf("Expected the redirect to be followed without the API key, got key %q", gotKey)
+	}
+
+	// Clients given without a redirect policy get the default one
+	gotKey = "unset"
+	result, err = NewToolHandler(http.MethodGet, origin.URL, headers, WithHTTPClient(&http.Client{}))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resultText(t, result) != "moved" || gotKey != "" {
+		t.Fatalf("Expected the custom client to follow the redirect without the API key, got key %q", gotKey)
+	}
+
+	result, err = NewToolHandler(http.MethodGet, origin.URL, headers, WithRedirectPolicy(RedirectPolicy{Disabled: true}))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsError || !strings.Contains(resultText(t, result), "Found") {
+		t.Fatalf("Expected the 3xx response as the result, got %+v", result)
+	}
+}
+
+func Test_CookieJar(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			return
+		}
+		if cookie, err := r.Cookie("session"); err == nil {
+			w.Write([]byte(cookie.Value))
+		}
+	}))
+	defer ts.Close()
+
+	cfg := newAdapterConfig(WithCookieJar(nil))
+	login := newToolHandler(toolEndpoint{method: http.MethodPost, url: ts.URL + "/login"}, cfg)
+	list := newToolHandler(toolEndpoint{method: http.MethodGet, url: ts.URL + "/items"}, cfg)
+	if _, err := login(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	result, err := list(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text := resultText(t, result); text != "abc" {
+		t.Errorf("Got %q; want the session cookie set by the login call", text)
+	}
+}
+
+func Test_HeaderAndCookieParams(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"headerNames": map[string]interface{}{"X-Tenant-ID": "acme"},
+		"cookieNames": map[string]interface{}{"session": "a b;c"},
+	}
+
+	if _, err := NewToolHandler(http.MethodGet, ts.URL, nil)(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.Header.Get("X-Tenant-ID") != "acme" {
+		t.Fatalf("Expected X-Tenant-ID header, got %v", got.Header)
+	}
+	cookie, err := got.Cookie("session")
+	if err != nil || cookie.Value != "a+b%3Bc" {
+		t.Fatalf("Expected URL-encoded session cookie, got %v (%v)", cookie, err)
+	}
+}
+
+func Test_QueryDefaultsApplied(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method: http.MethodGet,
+		url:    ts.URL,
+		parameters: []Parameter{
+			{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Default: float64(20)}},
+			{Name: "sort", In: "query", Schema: &Schema{Type: "string", Default: "asc"}},
+		},
+	}, newAdapterConfig())
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"searchParams": map[string]interface{}{"sort": nil},
+	}
+	if _, err := handler(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.URL.RawQuery != "limit=20" {
+		t.Fatalf("Expected only the omitted parameter to be defaulted, got %q", got.URL.RawQuery)
+	}
+}
+
+func Test_MissingRequiredParams(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method: http.MethodGet,
+		url:    ts.URL + "/users/{id}/orders",
+		required: map[string][]string{
+			"pathNames":    {"id"},
+			"searchParams": {"status"},
+		},
+	}, newAdapterConfig())
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"pathNames": map[string]interface{}{"id": ""},
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if called {
+		t.Fatalf("The request must not be sent when required parameters are missing")
+	}
+	text := resultText(t, result)
+	if !result.IsError || !strings.Contains(text, "pathNames.id") || !strings.Contains(text, "searchParams.status") {
+		t.Fatalf("Unexpected result: %q", text)
+	}
+}
+
+func Test_PathParamsTrimmedAndNonEmpty(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.EscapedPath()
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method: http.MethodGet,
+		url:    ts.URL + "/users/{id}/orders/{order}",
+		parameters: []Parameter{
+			{Name: "order", In: "path", Schema: &Schema{Type: "integer"}},
+		},
+	}, newAdapterConfig())
+
+	call := func(pathNames map[string]interface{}) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pathNames": pathNames}
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return result
+	}
+
+	if result := call(map[string]interface{}{"id": " 42\n", "order": " 7 "}); result.IsError || got != "/users/42/orders/7" {
+		t.Fatalf("Expected trimmed path parameters, got %q: %q", got, resultText(t, result))
+	}
+
+	got = ""
+	result := call(map[string]interface{}{"id": "  ", "order": "7"})
+	if text := resultText(t, result); !result.IsError || !strings.Contains(text, "pathNames.id (must not be empty)") || got != "" {
+		t.Fatalf("Expected an error for an empty path parameter, got %q", text)
+	}
+}
+
+func Test_DescribeSchemaShape(t *testing.T) {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]Schema{
+			"id":     {Type: "integer"},
+			"status": {Type: "string", Enum: []interface{}{"open", "closed"}},
+			"tags":   {Type: "array", Items: &Schema{Type: "string"}},
+			"owner":  {Type: "object", Nullable: true, Properties: map[string]Schema{"name": {Type: "string"}}},
+		},
+		Required: []string{"id"},
+	}
+
+	want := `{id: integer, owner?: {name?: string} | null, status?: "open" | "closed", tags?: string[]}`
+	if got := describeSchemaShape(schema, 0); got != want {
+		t.Fatalf("describeSchemaShape() = %s; want %s", got, want)
+	}
+}
+
+func Test_JSONContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{ "id": 1,  "name": "x" }`))
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, nil, WithJSONContent(true))
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	resource, ok := result.Content[0].(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("Expected an embedded resource, got %T", result.Content[0])
+	}
+	contents, ok := resource.Resource.(mcp.TextResourceContents)
+	if !ok || contents.MIMEType != "application/json" || contents.Text != `{"id":1,"name":"x"}` {
+		t.Fatalf("Unexpected resource contents: %+v", resource.Resource)
+	}
+}
+
+func Test_BinaryResponse(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/report" {
+			w.Header().Set("Content-Type", "application/pdf")
+		} else {
+			w.Header().Set("Content-Type", "image/png")
+		}
+		w.Write(png)
+	}))
+	defer ts.Close()
+
+	result, err := NewToolHandler(http.MethodGet, ts.URL+"/chart", nil)(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	image, ok := result.Content[0].(mcp.ImageContent)
+	if !ok || image.MIMEType != "image/png" || image.Data != base64.StdEncoding.EncodeToString(png) {
+		t.Fatalf("Expected base64 image content, got %+v", result.Content[0])
+	}
+
+	result, err = NewToolHandler(http.MethodGet, ts.URL+"/report", nil)(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resource, ok := result.Content[0].(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("Expected an embedded resource, got %T", result.Content[0])
+	}
+	if blob, ok := resource.Resource.(mcp.BlobResourceContents); !ok || blob.MIMEType != "application/pdf" {
+		t.Fatalf("Unexpected resource contents: %+v", resource.Resource)
+	}
+}
+
+func Test_MaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("héllo world"))
+	}))
+	defer ts.Close()
+
+	// The limit falls inside the two-byte "é", which must not be split
+	result, err := NewToolHandler(http.MethodGet, ts.URL, nil, WithMaxResponseBytes(2))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text := resultText(t, result); text != "h\n\n[Response truncated after 2 bytes (total size 12 bytes)]" {
+		t.Fatalf("Unexpected result: %q", text)
+	}
+}
+
+func Test_SchemaConstraints(t *testing.T) {
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {"/items": {"post": {
+		"operationId": "createItem",
+		"parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 100, "multipleOf": 5}}],
+		"requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {
+			"code": {"type": "string", "minLength": 2, "maxLength": 8, "pattern": "^[A-Z]+$"}}}}}}}}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := buildTools(newAdapterConfig(), server.NewMCPServer("t", "1"), "t", "http://localhost", nil, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, _ := json.Marshal(tools[0].Tool.InputSchema)
+	for _, want := range []string{`"minimum":1`, `"maximum":100`, `"multipleOf":5`, `"minLength":2`, `"maxLength":8`, `"pattern":"^[A-Z]+$"`} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("Got input schema %s; want it to contain %s", encoded, want)
+		}
+	}
+}
+
+func Test_SchemaProperty(t *testing.T) {
+	one := 1.0
+	order := Schema{Type: "object", Required: []string{"items"}, Properties: map[string]Schema{
+		"items": {Type: "array", Items: &Schema{Type: "object", Required: []string{"sku"}, Properties: map[string]Schema{
+			"sku":      {Type: "string", Description: "Stock keeping unit", Pattern: "^[A-Z0-9]+$"},
+			"quantity": {Type: "integer", Minimum: &one},
+		}}},
+	}}
+	encoded, _ := json.Marshal(schemaProperty(order, "The order", 0))
+	want := `{"description":"The order","properties":{"items":{"items":{"properties":{"quantity":{"minimum":1,"type":"integer"},` +
+		`"sku":{"description":"Stock keeping unit","pattern":"^[A-Z0-9]+$","type":"string"}},"required":["sku"],"type":"object"},` +
+		`"type":"array"}},"required":["items"],"type":"object"}`
+	if string(encoded) != want {
+		t.Errorf("Got %s; want %s", encoded, want)
+	}
+
+	nested := Schema{Type: "string"}
+	for i := 0; i < maxSchemaDepth+5; i++ {
+		nested = Schema{Type: "object", Properties: map[string]Schema{"child": nested}}
+	}
+	depth := 0
+	for prop := schemaProperty(nested, "", 0); prop["properties"] != nil; depth++ {
+		prop = prop["properties"].(map[string]interface{})["child"].(map[string]interface{})
+	}
+	if depth != maxSchemaDepth {
+		t.Errorf("Expanded %d levels; want %d", depth, maxSchemaDepth)
+	}
+}
+
+func Test_DryRun(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodPost, ts.URL+"/pets/{id}", map[string]string{"X-Client": "test"},
+		WithDryRun(true), WithAuth(Auth{BearerToken: "secret-token"}))
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"pathNames":    map[string]interface{}{"id": "7"},
+		"searchParams": map[string]interface{}{"verbose": true},
+		"requestBody":  map[string]interface{}{"name": "Rex"},
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Error("Dry run sent the request")
+	}
+
+	var got dryRunRequest
+	if err := json.Unmarshal([]byte(resultText(t, result)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPost || got.URL != ts.URL+"/pets/7?verbose=true" || got.Body != `{"name":"Rex"}` {
+		t.Errorf("Got request %+v", got)
+	}
+	if got.Headers["X-Client"] != "test" || got.Headers["Authorization"] != redactedValue {
+		t.Errorf("Got headers %v; want X-Client and a redacted Authorization", got.Headers)
+	}
+}
+
+func Test_CurlCommand(t *testing.T) {
+	cfg := newAdapterConfig()
+	req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/notes?api_key=k1", nil)
+	req.Header.Set("Authorization", "Bearer t1")
+	req.Header.Set("Content-Type", "application/json")
+	got := cfg.curlCommand(req, newBytesBody([]byte(`{"text":"it's"}`), "application/json"), nil)
+	want := `curl -X POST 'https://api.example.com/notes?api_key=%5BREDACTED%5D' -H 'Authorization: [REDACTED]' -H 'Content-Type: application/json' --data-binary '{"text":"it'\''s"}'`
+	if got != want {
+		t.Errorf("Got %s\nwant %s", got, want)
+	}
+}
+
+func Test_UpstreamOptions(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "servers": [{"url": "http://unused.invalid"}],
+		"paths": {"/items": {"get": {"operationId": "listItems"}}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMCPServer(parser, WithUpstreamURL(ts.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newAdapterConfig(WithUpstreamURL(ts.URL), WithExtraHeaders(map[string]string{"X-Tenant": "acme", "X-Env": "prod"}))
+	tools, err := buildTools(cfg, server.NewMCPServer("t", "1"), "t", "", map[string]string{"X-Env": "dev"}, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tools[0].Handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.URL.Path != "/items" || got.Header.Get("X-Tenant") != "acme" || got.Header.Get("X-Env") != "dev" {
+		t.Errorf("Got request %+v; want the upstream URL, the option headers and the explicit header", got)
+	}
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Auth configures the credentials attached to every upstream request.
+// Credentials are applied after the extra headers, so they are never overwritten by them.
+// Only one authentication mechanism may be set.
+type Auth struct {
+	BearerToken string     // Sent as "Authorization: Bearer <token>"
+	Basic       *BasicAuth // Sent as HTTP Basic credentials
+	APIKey      *APIKeyAuth
+	OAuth2      *OAuth2ClientCredentials // Access token sent as "Authorization: Bearer <token>"
+	SigV4       *SigV4Auth               // Requests signed with AWS Signature Version 4
+	HMAC        *HMACAuth                // Requests signed with an HMAC of a configurable canonical string
+
+	oauth2Tokens *oauth2TokenSource
+	hmacTemplate *template.Template // Parsed HMAC template, set by validate
+}
+
+// Locations an API key can be sent in, matching the "in" field of OpenAPI apiKey security schemes
+const (
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+	APIKeyInCookie = "cookie"
+)
+
+// APIKeyAuth holds an API key and where to send it
+type APIKeyAuth struct {
+	Name  string // Header, query parameter or cookie name, e.g. "X-API-Key"
+	Value string
+	In    string // One of APIKeyInHeader, APIKeyInQuery or APIKeyInCookie; empty means header
+}
+
+// String describes the API key without revealing its value
+func (k APIKeyAuth) String() string {
+	return fmt.Sprintf("apiKey(%s in %s)", k.Name, k.location())
+}
+
+// location returns where the key is sent, defaulting to a header
+func (k APIKeyAuth) location() string {
+	if k.In == "" {
+		return APIKeyInHeader
+	}
+	return k.In
+}
+
+// BasicAuth holds HTTP Basic authentication credentials
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// String describes the credentials without revealing the password
+func (b BasicAuth) String() string {
+	return fmt.Sprintf("basic(%s:***)", b.Username)
+}
+
+// String describes the configured mechanisms without revealing any secret,
+// so Auth values can be logged safely
+func (a Auth) String() string {
+	mechanisms := a.mechanisms()
+	if len(mechanisms) == 0 {
+		return "none"
+	}
+	return strings.Join(mechanisms, ",")
+}
+
+// mechanisms returns the names of the configured authentication mechanisms
+func (a Auth) mechanisms() []string {
+	var mechanisms []string
+	if a.BearerToken != "" {
+		mechanisms = append(mechanisms, "bearer")
+	}
+	if a.Basic != nil {
+		mechanisms = append(mechanisms, "basic")
+	}
+	if a.APIKey != nil {
+		mechanisms = append(mechanisms, "apiKey")
+	}
+	if a.OAuth2 != nil {
+		mechanisms = append(mechanisms, "oauth2")
+	}
+	if a.SigV4 != nil {
+		mechanisms = append(mechanisms, "sigv4")
+	}
+	if a.HMAC != nil {
+		mechanisms = append(mechanisms, "hmac")
+	}
+	return mechanisms
+}
+
+// validate checks that at most one authentication mechanism is configured.
+// It parses the HMAC signing template once, for every request to reuse.
+func (a *Auth) validate() error {
+	if mechanisms := a.mechanisms(); len(mechanisms) > 1 {
+		return fmt.Errorf("only one authentication mechanism may be configured, got %s", strings.Join(mechanisms, " and "))
+	}
+	if a.APIKey != nil {
+		if a.APIKey.Name == "" {
+			return fmt.Errorf("API key name is required")
+		}
+		switch a.APIKey.location() {
+		case APIKeyInHeader, APIKeyInQuery, APIKeyInCookie:
+		default:
+			return fmt.Errorf("invalid API key location %q", a.APIKey.In)
+		}
+	}
+	if a.OAuth2 != nil && a.OAuth2.TokenURL == "" {
+		return fmt.Errorf("OAuth2 token URL is required")
+	}
+	if a.SigV4 != nil {
+		return a.SigV4.validate()
+	}
+	if a.HMAC != nil {
+		tmpl, err := a.HMAC.compile()
+		if err != nil {
+			return err
+		}
+		a.hmacTemplate = tmpl
+	}
+	return nil
+}
+
+// WithAuth sets the credentials used for all generated tools.
+// Individual operations can override them with OperationConfig.Auth.
+func WithAuth(auth Auth) AdapterOption {
+	return func(c *adapterConfig) {
+		if err := auth.validate(); err != nil {
+			c.setError(err)
+			return
+		}
+		c.auth = &auth
+	}
+}
+
+// apply attaches the credentials to the request
+func (a *Auth) apply(req *http.Request) error {
+	if a == nil {
+		return nil
+	}
+	switch {
+	case a.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+a.BearerToken)
+	case a.Basic != nil:
+		req.SetBasicAuth(a.Basic.Username, a.Basic.Password)
+	case a.APIKey != nil:
+		a.APIKey.apply(req)
+	case a.oauth2Tokens != nil:
+		token, err := a.oauth2Tokens.Token(req.Context())
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case a.SigV4 != nil:
+		// Signing must come last, since headers changed afterwards would invalidate the signature
+		return a.SigV4.sign(req, time.Now())
+	case a.HMAC != nil:
+		return a.HMAC.sign(req, a.hmacTemplate, time.Now())
+	}
+	return nil
+}
+
+// varyingHeaders returns the headers the credentials set to a different value on every request,
+// such as the signing time, so they are not compared when matching requests
+func (a *Auth) varyingHeaders() []string {
+	switch {
+	case a == nil:
+		return nil
+	case a.SigV4 != nil:
+		return []string{"X-Amz-Date"}
+	case a.HMAC != nil:
+		var headers []string
+		for _, name := range []string{a.HMAC.TimestampHeader, a.HMAC.NonceHeader} {
+			if name != "" {
+				headers = append(headers, name)
+			}
+		}
+		return headers
+	}
+	return nil
+}
+
+// configuredAuths returns the credentials of the adapter and of every operation; entries may be nil
+func (c *adapterConfig) configuredAuths() []*Auth {
+	auths := []*Auth{c.auth}
+	for _, opCfg := range c.operations {
+		auths = append(auths, opCfg.Auth)
+	}
+	return auths
+}
+
+// prepare sets up the state needed to apply the credentials, such as the OAuth2 token cache
+func (a *Auth) prepare(client *http.Client) {
+	if a != nil && a.OAuth2 != nil {
+		a.oauth2Tokens = newOAuth2TokenSource(*a.OAuth2, client)
+	}
+}
+
+// authFor returns the credentials to use for the given operation.
+// Explicitly configured credentials take precedence over those derived from the spec's security requirements.
+func (c *adapterConfig) authFor(opCfg OperationConfig, derived []*Auth) []*Auth {
+	if opCfg.Auth != nil {
+		return []*Auth{opCfg.Auth}
+	}
+	if c.auth != nil {
+		return []*Auth{c.auth}
+	}
+	return derived
+}
+
+// apply sends the key in its configured location
+func (k *APIKeyAuth) apply(req *http.Request) {
+	switch k.location() {
+	case APIKeyInQuery:
+		q := req.URL.Query()
+		q.Set(k.Name, k.Value)
+		req.URL.RawQuery = q.Encode()
+	case APIKeyInCookie:
+		req.AddCookie(&http.Cookie{Name: k.Name, Value: k.Value})
+	default:
+		req.Header.Set(k.Name, k.Value)
+	}
+}
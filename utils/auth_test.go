@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_AuthAPIKeyLocations(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		in    string
+		value func(r *http.Request) string
+	}{
+		{APIKeyInHeader, func(r *http.Request) string { return r.Header.Get("api_key") }},
+		{APIKeyInQuery, func(r *http.Request) string { return r.URL.Query().Get("api_key") }},
+		{APIKeyInCookie, func(r *http.Request) string {
+			c, err := r.Cookie("api_key")
+			if err != nil {
+				return ""
+			}
+			return c.Value
+		}},
+	}
+
+	for _, tt := range tests {
+		handler := NewToolHandler(http.MethodGet, ts.URL+"?page=2", nil, WithAuth(Auth{
+			APIKey: &APIKeyAuth{Name: "api_key", Value: "secret", In: tt.in},
+		}))
+		if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if v := tt.value(got); v != "secret" {
+			t.Errorf("API key in %s: got %q", tt.in, v)
+		}
+		if got.URL.Query().Get("page") != "2" {
+			t.Errorf("API key in %s: existing query was lost", tt.in)
+		}
+	}
+}
+
+func Test_AuthBearerToken(t *testing.T) {
+	var gotAuth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	auth := WithAuth(Auth{BearerToken: "secret-token"})
+	if _, err := NewToolHandler(http.MethodGet, ts.URL, nil, auth, WithLogger(logger))(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if gotAuth != "Bearer secret-token" {
+		t.Errorf("Got Authorization %q; want the bearer token", gotAuth)
+	}
+	if logged := buf.String(); !strings.Contains(logged, "Authorization:"+redactedValue) || strings.Contains(logged, "secret-token") {
+		t.Errorf("Log %q does not redact the bearer token", logged)
+	}
+
+	result, err := NewToolHandler(http.MethodGet, ts.URL, nil, auth, WithDryRun(true))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, `"Authorization": "`+redactedValue+`"`) || strings.Contains(text, "secret-token") {
+		t.Errorf("Dry run %s does not redact the bearer token", text)
+	}
+}
+
+func Test_AuthBasic(t *testing.T) {
+	var gotAuth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, nil, WithAuth(Auth{Basic: &BasicAuth{Username: "user", Password: "p@ss:word"}}))
+	if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:p@ss:word")); gotAuth != want {
+		t.Errorf("Got Authorization %q; want %q", gotAuth, want)
+	}
+
+	for _, auth := range []Auth{
+		{Basic: &BasicAuth{Username: "user"}, APIKey: &APIKeyAuth{Name: "api_key", Value: "secret"}},
+		{Basic: &BasicAuth{Username: "user"}, OAuth2: &OAuth2ClientCredentials{TokenURL: "https://auth.example.com/token"}},
+	} {
+		if err := auth.validate(); err == nil || !strings.Contains(err.Error(), "only one authentication mechanism") {
+			t.Errorf("Got error %v for %v; want basic auth to conflict with other mechanisms", err, auth)
+		}
+	}
+}
+
+func Test_AuthRejectsMultipleMechanisms(t *testing.T) {
+	_, err := NewMCPFromCustomParser("", nil, &SimpleOpenAPIParser{}, WithAuth(Auth{
+		BearerToken: "token",
+		Basic:       &BasicAuth{Username: "user", Password: "pass"},
+	}))
+	if err == nil {
+		t.Fatalf("Expected an error for conflicting auth mechanisms")
+	}
+}
+
+func Test_AuthOAuth2TokenIsCached(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		if user, pass, ok := r.BasicAuth(); !ok || user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	var gotAuth string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer api.Close()
+
+	handler := NewToolHandler(http.MethodGet, api.URL, nil, WithAuth(Auth{
+		OAuth2: &OAuth2ClientCredentials{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+		},
+	}))
+	for i := 0; i < 3; i++ {
+		if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if gotAuth != "Bearer abc" {
+		t.Fatalf("Unexpected Authorization header: %q", gotAuth)
+	}
+	if tokenRequests.Load() != 1 {
+		t.Fatalf("Expected a single token request, got %d", tokenRequests.Load())
+	}
+}
+
+// plainParser implements only OpenAPIParser, like parsers written before security schemes existed
+type plainParser struct{}
+
+func (plainParser) Servers() []Server { return nil }
+func (plainParser) Info() APIInfo     { return APIInfo{Title: "plain", Version: "1"} }
+func (plainParser) APIs() []APIEndpoint {
+	return []APIEndpoint{{Path: "/items", Method: http.MethodGet, OperationID: "listItems"}}
+}
+
+func Test_ParserWithoutSecuritySchemes(t *testing.T) {
+	var _ OpenAPIParser = plainParser{}
+	if _, ok := interface{}(plainParser{}).(SecuritySchemeSource); ok {
+		t.Fatal("plainParser must not declare security schemes")
+	}
+	if _, err := NewMCPFromCustomParser("http://localhost", nil, plainParser{}, WithSecurityCredentials("*", Credentials{Token: "t"})); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,356 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	neturl "net/url"
+	"strings"
+)
+
+// rawBodyProperty is the requestBody argument holding the payload of operations whose body is
+// not an object, such as file uploads with application/octet-stream or plain text bodies
+const rawBodyProperty = "content"
+
+// requestBody is an encoded request body that can be opened once per attempt,
+// so that streamed bodies such as uploaded files can be replayed on retries and redirects
+type requestBody struct {
+	contentType string
+	length      int64  // Content length in bytes, or -1 if unknown
+	data        []byte // In-memory content, nil for streamed bodies
+	open        func() (io.ReadCloser, error)
+}
+
+// newBytesBody returns a request body backed by an in-memory buffer
+func newBytesBody(data []byte, contentType string) *requestBody {
+	return &requestBody{
+		contentType: contentType,
+		length:      int64(len(data)),
+		data:        data,
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+	}
+}
+
+// requestLength returns the size of a request body, or zero if there is none or its size is unknown
+func requestLength(body *requestBody) int64 {
+	if body == nil || body.length < 0 {
+		return 0
+	}
+	return body.length
+}
+
+// encodeRequestBody encodes the body arguments according to the request body media type
+// declared by the operation. Binary values are read from local files when uploadDirs is not empty.
+func (c *adapterConfig) encodeRequestBody(mediaType string, schema *Schema, params map[string]interface{}) (*requestBody, error) {
+	switch {
+	case strings.EqualFold(mediaType, "multipart/form-data"):
+		return encodeMultipartBody(schema, params, c.uploadDirs)
+	case strings.EqualFold(mediaType, "application/x-www-form-urlencoded"):
+		return newBytesBody(encodeFormBody(params), "application/x-www-form-urlencoded"), nil
+	case isRawBody(mediaType, schema):
+		return encodeRawBody(mediaType, schema, params[rawBodyProperty], c.uploadDirs)
+	case isXMLMediaType(mediaType):
+		return encodeXMLBody(mediaType, schema, params)
+	default:
+		body, err := c.marshalBody(params, schema)
+		if err != nil {
+			return nil, err
+		}
+		return newBytesBody(body, "application/json"), nil
+	}
+}
+
+// marshalBody encodes a JSON request body
+func (c *adapterConfig) marshalBody(value interface{}, schema *Schema) ([]byte, error) {
+	if c.orderedBody {
+		return marshalOrdered(value, schema, c.escapeHTML)
+	}
+	return encodeJSON(value, c.escapeHTML)
+}
+
+// WithHTMLEscaping escapes <, > and & in JSON request bodies as \u003c, \u003e and \u0026, as
+// json.Marshal does. By default they are sent verbatim, since upstreams expect URLs and HTML in
+// string values unchanged.
+func WithHTMLEscaping(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.escapeHTML = enabled
+	}
+}
+
+// encodeJSON encodes a value like json.Marshal, escaping HTML characters only if escapeHTML is set
+func encodeJSON(value interface{}, escapeHTML bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// isRawBody reports whether the request body is sent as is rather than encoded from an object,
+// e.g. an application/octet-stream upload or a text/plain body
+func isRawBody(mediaType string, schema *Schema) bool {
+	if mediaType == "" || strings.Contains(mediaType, "json") {
+		return false
+	}
+	return schema == nil || schema.Type == "string"
+}
+
+// isArrayBody reports whether the request body is a JSON array, e.g. for bulk operations. Its items
+// are passed as the requestBody argument itself rather than as properties of an object.
+func isArrayBody(mediaType string, schema *Schema) bool {
+	return strings.Contains(mediaType, "json") && schema != nil && schema.Type == "array"
+}
+
+// encodeRawBody sends the raw body argument as the request body. Binary bodies are streamed
+// from a local file if uploads are enabled; otherwise the argument is the body itself.
+func encodeRawBody(mediaType string, schema *Schema, value interface{}, uploadDirs []string) (*requestBody, error) {
+	contentType := mediaType
+	if strings.Contains(contentType, "*") {
+		contentType = "application/octet-stream"
+	}
+
+	if len(uploadDirs) > 0 && (schema == nil || isBinarySchema(*schema)) {
+		path, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be the path of the file to upload", rawBodyProperty)
+		}
+		file, err := newUploadFile(path, uploadDirs)
+		if err != nil {
+			return nil, err
+		}
+		if contentType == "application/octet-stream" {
+			contentType = file.contentType()
+		}
+		return &requestBody{
+			contentType: contentType,
+			length:      file.size,
+			open:        file.open,
+		}, nil
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+	return newBytesBody([]byte(formatFieldValue(value)), contentType), nil
+}
+
+// encodeMultipartBody writes each body argument as a multipart field. Properties declared as
+// binary strings are written as file parts, arrays as repeated fields and objects as JSON.
+// When uploads are enabled, file parts are streamed from the local files named by their values.
+func encodeMultipartBody(schema *Schema, params map[string]interface{}, uploadDirs []string) (*requestBody, error) {
+	files := map[string]*uploadFile{}
+	for name, value := range params {
+		var prop Schema
+		if schema != nil {
+			prop = schema.Properties[name]
+		}
+		if len(uploadDirs) == 0 || !isBinarySchema(prop) || value == nil {
+			continue
+		}
+		path, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be the path of the file to upload", name)
+		}
+		file, err := newUploadFile(path, uploadDirs)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = file
+	}
+
+	// The boundary is fixed up front so every attempt produces the same Content-Type
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	write := func(w io.Writer) error {
+		writer := multipart.NewWriter(w)
+		if err := writer.SetBoundary(boundary); err != nil {
+			return err
+		}
+		if err := writeMultipartFields(writer, schema, params, files); err != nil {
+			return err
+		}
+		return writer.Close()
+	}
+	contentType := "multipart/form-data; boundary=" + boundary
+
+	if len(files) == 0 {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			return nil, err
+		}
+		return newBytesBody(buf.Bytes(), contentType), nil
+	}
+
+	return &requestBody{
+		contentType: contentType,
+		length:      -1,
+		open: func() (io.ReadCloser, error) {
+			pr, pw := io.Pipe()
+			go func() {
+				pw.CloseWithError(write(pw))
+			}()
+			return pr, nil
+		},
+	}, nil
+}
+
+// writeMultipartFields writes the fields of a multipart body in a stable order
+func writeMultipartFields(writer *multipart.Writer, schema *Schema, params map[string]interface{}, files map[string]*uploadFile) error {
+	for _, name := range sortedKeys(params) {
+		value := params[name]
+		if value == nil {
+			continue
+		}
+
+		if file, ok := files[name]; ok {
+			if err := file.writePart(writer, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var prop Schema
+		if schema != nil {
+			prop = schema.Properties[name]
+		}
+
+		if isBinarySchema(prop) {
+			part, err := writer.CreateFormFile(name, name)
+			if err != nil {
+				return err
+			}
+			if _, err := part.Write([]byte(formatFieldValue(value))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, item := range values {
+			if err := writer.WriteField(name, formatFieldValue(item)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encodeFormBody encodes the body arguments as a URL-encoded form. Values are serialized like
+// form-style query parameters, the OpenAPI default: arrays produce repeated keys and object
+// properties become separate fields.
+func encodeFormBody(params map[string]interface{}) []byte {
+	values := neturl.Values{}
+	for name, value := range params {
+		addQueryParam(values, name, value, Parameter{})
+	}
+	return []byte(values.Encode())
+}
+
+// isBinarySchema reports whether the schema describes raw file contents
+func isBinarySchema(schema Schema) bool {
+	return schema.Type == "string" && schema.Format == "binary"
+}
+
+// formatFieldValue renders a value as a form field; objects and arrays are encoded as JSON
+func formatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	default:
+		return formatScalar(v)
+	}
+}
+
+// OmitEmpty selects body fields that are left out of request bodies, for upstreams that reject
+// explicit nulls or treat the presence of a field as meaningful
+type OmitEmpty struct {
+	// Nulls drops fields set to null, except those whose schema is nullable
+	Nulls bool
+	// Empty also drops empty strings, arrays and objects
+	Empty bool
+	// Keep lists dot-separated paths of fields that are always sent as given, e.g. "profile.middleName"
+	Keep []string
+}
+
+// WithOmitEmpty drops null and, optionally, empty fields from request bodies, including nested
+// objects and the objects of array bodies
+func WithOmitEmpty(omit OmitEmpty) AdapterOption {
+	return func(c *adapterConfig) {
+		c.omitEmpty = &omit
+	}
+}
+
+// apply returns a copy of the body arguments without the omitted fields; values is not modified
+func (o *OmitEmpty) apply(values map[string]interface{}, schema *Schema, path string) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		fieldPath := path + name
+		if containsString(o.Keep, fieldPath) {
+			result[name] = value
+			continue
+		}
+		var prop *Schema
+		if schema != nil {
+			if propSchema, ok := schema.Properties[name]; ok {
+				prop = &propSchema
+			}
+		}
+
+		switch v := value.(type) {
+		case nil:
+			if o.Nulls && (prop == nil || !prop.Nullable) {
+				continue
+			}
+		case map[string]interface{}:
+			value = o.apply(v, prop, fieldPath+".")
+		case []interface{}:
+			var items *Schema
+			if prop != nil {
+				items = prop.Items
+			}
+			value = o.applyItems(v, items, fieldPath+".")
+		}
+		if o.Empty && isEmptyValue(value) {
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// applyItems returns a copy of the items of an array with the omitted fields of each object removed
+func (o *OmitEmpty) applyItems(values []interface{}, items *Schema, path string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, item := range values {
+		if object, ok := item.(map[string]interface{}); ok {
+			item = o.apply(object, items, path)
+		}
+		result[i] = item
+	}
+	return result
+}
+
+// isEmptyValue reports whether a value is an empty string, array or object
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
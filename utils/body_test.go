@@ -0,0 +1,327 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func Test_MultipartRequestBody(t *testing.T) {
+	var fields map[string][]string
+	var fileContent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse multipart body: %v", err)
+			return
+		}
+		fields = r.MultipartForm.Value
+		file, _, err := r.FormFile("document")
+		if err != nil {
+			t.Errorf("Missing file part: %v", err)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		fileContent = string(content)
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method:    http.MethodPost,
+		url:       ts.URL,
+		bodyMedia: "multipart/form-data",
+		bodySchema: &Schema{
+			Type: "object",
+			Properties: map[string]Schema{
+				"document": {Type: "string", Format: "binary"},
+				"tags":     {Type: "array", Items: &Schema{Type: "string"}},
+			},
+		},
+	}, newAdapterConfig())
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"requestBody": map[string]interface{}{
+			"document": "hello",
+			"tags":     []interface{}{"a", "b"},
+			"count":    float64(2),
+		},
+	}
+	if _, err := handler(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if fileContent != "hello" {
+		t.Errorf("Unexpected file content: %q", fileContent)
+	}
+	want := map[string][]string{"tags": {"a", "b"}, "count": {"2"}}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Unexpected fields: %v", fields)
+	}
+}
+
+func Test_FormURLEncodedRequestBody(t *testing.T) {
+	var contentType, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method:    http.MethodPost,
+		url:       ts.URL,
+		bodyMedia: "application/x-www-form-urlencoded",
+	}, newAdapterConfig())
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"requestBody": map[string]interface{}{
+			"name": "a b",
+			"ids":  []interface{}{float64(1), float64(2)},
+		},
+	}
+	if _, err := handler(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Unexpected Content-Type: %q", contentType)
+	}
+	if body != "ids=1&ids=2&name=a+b" {
+		t.Errorf("Unexpected body: %q", body)
+	}
+}
+
+func Test_OmitEmpty(t *testing.T) {
+	schema := &Schema{Type: "object", Properties: map[string]Schema{
+		"deletedAt": {Type: "string", Nullable: true},
+		"profile":   {Type: "object", Properties: map[string]Schema{"middleName": {Type: "string"}}},
+	}}
+	values := map[string]interface{}{
+		"name":      "Ann",
+		"nickname":  nil,
+		"deletedAt": nil,
+		"tags":      []interface{}{},
+		"note":      "",
+		"profile":   map[string]interface{}{"middleName": nil, "title": ""},
+		"items":     []interface{}{map[string]interface{}{"id": 1.0, "parent": nil}},
+	}
+
+	tests := []struct {
+		omit OmitEmpty
+		want map[string]interface{}
+	}{
+		{OmitEmpty{Nulls: true}, map[string]interface{}{
+			"name": "Ann", "deletedAt": nil, "tags": []interface{}{}, "note": "",
+			"profile": map[string]interface{}{"title": ""},
+			"items":   []interface{}{map[string]interface{}{"id": 1.0}},
+		}},
+		{OmitEmpty{Nulls: true, Empty: true, Keep: []string{"profile.middleName"}}, map[string]interface{}{
+			"name": "Ann", "deletedAt": nil,
+			"profile": map[string]interface{}{"middleName": nil},
+			"items":   []interface{}{map[string]interface{}{"id": 1.0}},
+		}},
+	}
+	for _, tt := range tests {
+		if got := tt.omit.apply(values, schema, ""); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: got %v; want %v", tt.omit, got, tt.want)
+		}
+	}
+}
+
+func Test_OmitEmptyArrayBody(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method:     http.MethodPost,
+		url:        ts.URL,
+		bodyMedia:  "application/json",
+		bodySchema: &Schema{Type: "array", Items: &Schema{Type: "object", Properties: map[string]Schema{"a": {Type: "string"}}}},
+	}, newAdapterConfig(WithOmitEmpty(OmitEmpty{Nulls: true, Empty: true})))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"requestBody": []interface{}{map[string]interface{}{"a": "", "b": "x", "c": nil}, map[string]interface{}{"a": "y"}},
+	}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got != `[{"b":"x"},{"a":"y"}]` {
+		t.Errorf("Got body %s (result %q); want the empty fields of each item omitted", got, resultText(t, result))
+	}
+}
+
+func Test_OrderedBody(t *testing.T) {
+	jsonSpec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {"/orders": {"post": {
+		"requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {
+			"zeta": {"type": "string"}, "alpha": {"type": "object", "properties": {"y": {"type": "integer"}, "x": {"type": "integer"}}}, "mid": {"type": "string"}}}}}}}}}}`
+	yamlSpec := `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths:
+  /orders:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                zeta: {type: string}
+                alpha:
+                  type: object
+                  properties:
+                    y: {type: integer}
+                    x: {type: integer}
+                mid: {type: string}
+`
+	jsonParser, err := ParseOpenAPIFromJSON([]byte(jsonSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlParser, err := ParseOpenAPIFromYAML([]byte(yamlSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := map[string]interface{}{"mid": "m", "extra": true, "alpha": map[string]interface{}{"x": 1, "y": 2}, "zeta": "z"}
+	for name, parser := range map[string]OpenAPIParser{"json": jsonParser, "yaml": yamlParser} {
+		_, schema := requestBodySchema(parser.APIs()[0].RequestBody)
+		body, err := newAdapterConfig(WithOrderedBody(true)).encodeRequestBody("application/json", schema, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"zeta":"z","alpha":{"y":2,"x":1},"mid":"m","extra":true}`
+		if string(body.data) != want {
+			t.Errorf("%s: got body %s; want %s", name, body.data, want)
+		}
+	}
+}
+
+func Test_PropertyOrderSkipsData(t *testing.T) {
+	jsonSpec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {"/features": {"post": {
+		"requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {
+			"default": {"type": "object", "properties": {"b": {"type": "string"}, "a": {"type": "string"}}},
+			"feature": {"type": "object", "default": {"type": "Feature", "properties": {"name": "x"}}}}}}}}}}}}`
+	yamlSpec := `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths:
+  /features:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                default:
+                  type: object
+                  properties:
+                    b: {type: string}
+                    a: {type: string}
+                feature:
+                  type: object
+                  default: {type: Feature, properties: {name: x}}
+`
+	jsonParser, err := ParseOpenAPIFromJSON([]byte(jsonSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlParser, err := ParseOpenAPIFromYAML([]byte(yamlSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"type": "Feature", "properties": map[string]interface{}{"name": "x"}}
+	for name, parser := range map[string]OpenAPIParser{"json": jsonParser, "yaml": yamlParser} {
+		_, schema := requestBodySchema(parser.APIs()[0].RequestBody)
+		if got := schema.Properties["feature"].Default; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: the default value must be left unchanged, got %v", name, got)
+		}
+		if got := schema.Properties["default"].PropertyOrder; !reflect.DeepEqual(got, []string{"b", "a"}) {
+			t.Errorf("%s: unexpected property order of the property named default: %v", name, got)
+		}
+	}
+}
+
+func Test_ArrayRequestBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {"/pets/bulk": {"post": {"operationId": "createPets",
+		"requestBody": {"required": true, "content": {"application/json": {"schema": {"type": "array", "items": {
+			"type": "object", "properties": {"name": {"type": "string"}, "age": {"type": "integer"}}}}}}}}}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := buildTools(newAdapterConfig(), server.NewMCPServer("t", "1"), "t", ts.URL, nil, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if property := tools[0].Tool.InputSchema.Properties["requestBody"].(map[string]interface{}); property["type"] != "array" {
+		t.Errorf("Got requestBody property %v; want an array", property)
+	}
+
+	tests := []struct {
+		body interface{}
+		want string
+	}{
+		{[]interface{}{map[string]interface{}{"name": "Rex", "age": "3"}}, `[{"age":3,"name":"Rex"}]`},
+		{[]interface{}{map[string]interface{}{"age": "old"}}, `Invalid value for requestBody: item 0: age: expected integer, got "old"`},
+		{nil, "Missing required parameters: requestBody"},
+	}
+	for _, tt := range tests {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{}
+		if tt.body != nil {
+			request.Params.Arguments["requestBody"] = tt.body
+		}
+		result, err := tools[0].Handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultText(t, result); got != tt.want {
+			t.Errorf("Got %q; want %q", got, tt.want)
+		}
+	}
+}
+
+func Test_HTMLEscaping(t *testing.T) {
+	params := map[string]interface{}{"link": "<a href=\"https://example.com/?a=1&b=2\">"}
+	tests := []struct {
+		opts []AdapterOption
+		want string
+	}{
+		{nil, `{"link":"<a href=\"https://example.com/?a=1&b=2\">"}`},
+		{[]AdapterOption{WithOrderedBody(true)}, `{"link":"<a href=\"https://example.com/?a=1&b=2\">"}`},
+		{[]AdapterOption{WithHTMLEscaping(true)}, `{"link":"\u003ca href=\"https://example.com/?a=1\u0026b=2\"\u003e"}`},
+	}
+	for _, tt := range tests {
+		body, err := newAdapterConfig(tt.opts...).marshalBody(params, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != tt.want {
+			t.Errorf("Got %s; want %s", body, tt.want)
+		}
+	}
+}
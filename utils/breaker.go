@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker of an upstream host
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests immediately until the cooldown has passed
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through to test whether the host has recovered
+	CircuitHalfOpen
+)
+
+// String returns the name of the state, e.g. for a metrics label
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker configures a circuit breaker per upstream host. Transport errors, timeouts and
+// 5xx responses count as failures; calls cancelled by the client are not counted.
+type CircuitBreaker struct {
+	FailureThreshold int           // Consecutive failures that open the circuit
+	Cooldown         time.Duration // How long an open circuit fails fast before a probe request is let through
+	// OnStateChange, if set, is called whenever the circuit of a host changes state, e.g. to export it
+	// as a gauge. It is called while the circuit is locked and must not block.
+	OnStateChange func(host string, from, to CircuitState)
+}
+
+// WithCircuitBreaker stops calling an upstream host after repeated failures, so that tool calls
+// fail immediately with a descriptive error instead of each waiting for a timeout
+func WithCircuitBreaker(breaker CircuitBreaker) AdapterOption {
+	return func(c *adapterConfig) {
+		if breaker.FailureThreshold < 1 || breaker.Cooldown <= 0 {
+			c.setError(fmt.Errorf("circuit breaker needs a positive failure threshold and cooldown"))
+			return
+		}
+		c.circuits = &circuits{breaker: breaker, hosts: map[string]*circuit{}}
+	}
+}
+
+// circuits holds the circuit of every upstream host, shared by all handlers of an adapter
+type circuits struct {
+	breaker CircuitBreaker
+	mu      sync.Mutex
+	hosts   map[string]*circuit
+}
+
+// forURL returns the circuit of the host of rawURL, or nil if circuit breaking is disabled
+func (c *circuits) forURL(rawURL string) *circuit {
+	if c == nil {
+		return nil
+	}
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	host := strings.ToLower(u.Host)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.hosts[host]; !ok {
+		c.hosts[host] = &circuit{breaker: c.breaker, host: host}
+	}
+	return c.hosts[host]
+}
+
+// circuit tracks the failures of a single host
+type circuit struct {
+	breaker  CircuitBreaker
+	host     string
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a request may be sent, returning a descriptive error if the circuit is open.
+// Every allowed request must be followed by a call to done.
+func (c *circuit) allow() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == CircuitOpen {
+		remaining := c.breaker.Cooldown - time.Since(c.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("circuit open for %s after %d consecutive failures, retrying in %s",
+				c.host, c.failures, remaining.Round(time.Second))
+		}
+		c.setState(CircuitHalfOpen)
+	}
+	if c.state == CircuitHalfOpen {
+		if c.probing {
+			return fmt.Errorf("circuit half-open for %s, waiting for a probe request to complete", c.host)
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+// done records the outcome of an allowed request
+func (c *circuit) done(err error, status int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+
+	// A call abandoned by the client says nothing about the health of the host
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil && status < 500 {
+		c.failures = 0
+		c.setState(CircuitClosed)
+		return
+	}
+
+	c.failures++
+	if c.state == CircuitHalfOpen || c.failures >= c.breaker.FailureThreshold {
+		c.openedAt = time.Now()
+		c.setState(CircuitOpen)
+	}
+}
+
+// setState changes the state and reports the change; the caller must hold c.mu
+func (c *circuit) setState(state CircuitState) {
+	if state == c.state {
+		return
+	}
+	from := c.state
+	c.state = state
+	if c.breaker.OnStateChange != nil {
+		c.breaker.OnStateChange(c.host, from, state)
+	}
+}
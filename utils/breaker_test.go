@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_CircuitBreakerOpensAfterFailures(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	var transitions []string
+	handler := NewToolHandler(http.MethodGet, ts.URL, nil, WithCircuitBreaker(CircuitBreaker{
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+		OnStateChange: func(host string, from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	}))
+
+	var result *mcp.CallToolResult
+	for i := 0; i < 3; i++ {
+		var err error
+		if result, err = handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Upstream was called %d times; want 2", calls)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "circuit open") {
+		t.Errorf("Got result %q; want a circuit open error", text)
+	}
+	if len(transitions) != 1 || transitions[0] != "closed->open" {
+		t.Errorf("Got transitions %v; want [closed->open]", transitions)
+	}
+}
+
+func Test_CircuitHalfOpenProbe(t *testing.T) {
+	c := &circuit{breaker: CircuitBreaker{FailureThreshold: 1, Cooldown: time.Millisecond}, host: "api.example.com"}
+	c.allow()
+	c.done(nil, http.StatusBadGateway)
+	if c.state != CircuitOpen {
+		t.Fatalf("Got state %s; want open", c.state)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if err := c.allow(); err != nil {
+		t.Fatalf("Probe was rejected: %v", err)
+	}
+	if err := c.allow(); err == nil {
+		t.Fatalf("Second request during the probe was allowed")
+	}
+	c.done(nil, http.StatusOK)
+	if c.state != CircuitClosed {
+		t.Fatalf("Got state %s after a successful probe; want closed", c.state)
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheEntries is the size of the response cache when only per-operation TTLs are configured
+const defaultCacheEntries = 1000
+
+// WithResponseCache caches successful responses to GET and HEAD requests in memory for the given TTL,
+// keeping at most maxEntries responses and evicting the least recently used ones.
+// Responses are cached per URL and request headers, so callers with different credentials never
+// share entries. Responses marked Cache-Control: no-store are not cached.
+// Once the TTL has passed, responses with an ETag or Last-Modified header are revalidated with
+// If-None-Match or If-Modified-Since, and a 304 Not Modified answer is served from the cache.
+// Responses marked Cache-Control: no-cache are revalidated on every call.
+// Operations can change the TTL or opt out with OperationConfig.CacheTTL.
+func WithResponseCache(ttl time.Duration, maxEntries int) AdapterOption {
+	return func(c *adapterConfig) {
+		if ttl <= 0 || maxEntries < 1 {
+			c.setError(fmt.Errorf("response cache needs a positive TTL and size, got %s and %d", ttl, maxEntries))
+			return
+		}
+		c.cacheTTL = ttl
+		c.cacheEntries = maxEntries
+	}
+}
+
+// cacheTTLFor returns how long responses of the given operation are cached; zero disables caching
+func (c *adapterConfig) cacheTTLFor(opCfg OperationConfig) time.Duration {
+	switch {
+	case opCfg.CacheTTL > 0:
+		return opCfg.CacheTTL
+	case opCfg.CacheTTL < 0:
+		return 0
+	default:
+		return c.cacheTTL
+	}
+}
+
+// newResponseCache creates the cache if any operation caches responses
+func (c *adapterConfig) newResponseCache() *responseCache {
+	enabled := c.cacheTTL > 0
+	for _, opCfg := range c.operations {
+		enabled = enabled || opCfg.CacheTTL > 0
+	}
+	if !enabled {
+		return nil
+	}
+	size := c.cacheEntries
+	if size < 1 {
+		size = defaultCacheEntries
+	}
+	return &responseCache{maxEntries: size, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// isSafeMethod reports whether requests with the method can be answered from the cache
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// isCacheableResponse reports whether a response may be stored in the cache
+func isCacheableResponse(resp *http.Response) bool {
+	return isSuccessStatus(resp.StatusCode) && !hasCacheDirective(resp.Header, "no-store")
+}
+
+// hasCacheDirective reports whether the Cache-Control header of a response contains the directive
+func hasCacheDirective(header http.Header, directive string) bool {
+	for _, value := range strings.Split(strings.Join(header.Values("Cache-Control"), ","), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKey identifies a request by method, URL and headers. Trace context headers, which differ for
+// every call, are left out. The key is hashed so credentials are not kept in memory as plain text.
+func cacheKey(req *http.Request) string {
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if name != "Traceparent" && name != "Tracestate" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.String())
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %s\n", name, strings.Join(req.Header.Values(name), ", "))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedResponse is a response body read by a handler along with the metadata needed to build a tool result
+type cachedResponse struct {
+	key           string
+	status        string
+	statusCode    int
+	header        http.Header
+	contentLength int64
+	body          []byte
+	truncated     bool
+	expires       time.Time
+}
+
+// newCachedResponse captures a response whose body has been read
+func newCachedResponse(key string, resp *http.Response, body []byte, truncated bool, ttl time.Duration) *cachedResponse {
+	if hasCacheDirective(resp.Header, "no-cache") {
+		ttl = 0
+	}
+	return &cachedResponse{
+		key:           key,
+		status:        resp.Status,
+		statusCode:    resp.StatusCode,
+		header:        resp.Header.Clone(),
+		contentLength: resp.ContentLength,
+		body:          body,
+		truncated:     truncated,
+		expires:       time.Now().Add(ttl),
+	}
+}
+
+// response rebuilds the response metadata, without a body
+func (r *cachedResponse) response() *http.Response {
+	return &http.Response{
+		Status:        r.status,
+		StatusCode:    r.statusCode,
+		Header:        r.header.Clone(),
+		ContentLength: r.contentLength,
+	}
+}
+
+// hasValidators reports whether the response can be revalidated with a conditional request
+func (r *cachedResponse) hasValidators() bool {
+	return r.header.Get("ETag") != "" || r.header.Get("Last-Modified") != ""
+}
+
+// addConditions makes req conditional on the cached response having changed
+func (r *cachedResponse) addConditions(req *http.Request) {
+	if etag := r.header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := r.header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+// revalidated returns a copy of the response that is fresh for another TTL, with the headers
+// sent along with a 304 Not Modified answer replacing the stored ones
+func (r *cachedResponse) revalidated(notModified *http.Response, ttl time.Duration) *cachedResponse {
+	refreshed := *r
+	refreshed.header = r.header.Clone()
+	for name, values := range notModified.Header {
+		// A 304 has no body, so its framing headers do not describe the cached one
+		if name != "Content-Length" && name != "Content-Encoding" && name != "Transfer-Encoding" {
+			refreshed.header[name] = values
+		}
+	}
+	if hasCacheDirective(refreshed.header, "no-cache") {
+		ttl = 0
+	}
+	refreshed.expires = time.Now().Add(ttl)
+	return &refreshed
+}
+
+// responseCache is an LRU cache of responses, shared by all handlers of an adapter
+type responseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Most recently used first
+}
+
+// get returns the response stored under key and whether it is still fresh. Expired responses are
+// kept for revalidation if they carry an ETag or Last-Modified header and dropped otherwise.
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cachedResponse)
+	c.order.MoveToFront(elem)
+	if time.Now().Before(entry.expires) {
+		return entry, true
+	}
+	if entry.hasValidators() {
+		return entry, false
+	}
+	c.order.Remove(elem)
+	delete(c.entries, key)
+	return nil, false
+}
+
+// put stores a response, evicting the least recently used one if the cache is full
+func (c *responseCache) put(entry *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
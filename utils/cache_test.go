@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_ResponseCache(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/live" {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		method    string
+		path      string
+		wantCalls int
+	}{
+		{http.MethodGet, "/items", 1},
+		{http.MethodGet, "/live", 2},
+		{http.MethodPost, "/items", 2},
+	}
+	for _, tt := range tests {
+		calls = 0
+		handler := NewToolHandler(tt.method, ts.URL+tt.path, nil, WithResponseCache(time.Minute, 10))
+		for i := 0; i < 2; i++ {
+			result, err := handler(context.Background(), mcp.CallToolRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text := result.Content[0].(mcp.TextContent).Text; text != "hello" {
+				t.Fatalf("%s %s: got result %q; want hello", tt.method, tt.path, text)
+			}
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%s %s: upstream was called %d times; want %d", tt.method, tt.path, calls, tt.wantCalls)
+		}
+	}
+}
+
+func Test_ResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newAdapterConfig(WithResponseCache(time.Minute, 2)).cache
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	for _, key := range []string{"a", "b"} {
+		cache.put(newCachedResponse(key, resp, nil, false, time.Minute))
+	}
+	cache.get("a")
+	cache.put(newCachedResponse("c", resp, nil, false, time.Minute))
+
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("Least recently used entry b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("Entry %s was evicted", key)
+		}
+	}
+}
+
+func Test_RevalidateWithETag(t *testing.T) {
+	calls, notModified := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("large report"))
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, nil, WithResponseCache(time.Minute, 10))
+	for i := 0; i < 3; i++ {
+		result, err := handler(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; result.IsError || text != "large report" {
+			t.Fatalf("Call %d: got result %q; want the cached body", i+1, text)
+		}
+	}
+	if calls != 3 || notModified != 2 {
+		t.Errorf("Got %d calls with %d revalidations; want 3 calls with 2 revalidations", calls, notModified)
+	}
+}
+
+func Test_ResponseCacheWithSignedRequests(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	// The nonce makes every signed request different, but they all ask for the same response
+	handler := NewToolHandler(http.MethodGet, ts.URL+"/items", nil, WithResponseCache(time.Minute, 10),
+		WithAuth(Auth{HMAC: &HMACAuth{Secret: "secret", Header: "X-Signature", NonceHeader: "X-Nonce"}}))
+	for i := 0; i < 2; i++ {
+		if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Upstream was called %d times; want 1", calls)
+	}
+}
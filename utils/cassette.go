@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CassetteMode selects whether upstream interactions are recorded or replayed
+type CassetteMode int
+
+const (
+	// CassetteReplay answers requests from the cassette without calling the upstream API.
+	// Requests that were not recorded fail.
+	CassetteReplay CassetteMode = iota
+	// CassetteRecord calls the upstream API and saves every interaction to the cassette,
+	// replacing its previous content
+	CassetteRecord
+)
+
+// Cassette records upstream requests and responses to a file and replays them later, for
+// deterministic tests and offline demos. Requests match a recorded interaction if their method,
+// URL and body are equal, as well as their headers other than those in IgnoreHeaders.
+// Credentials are redacted in the file and never compared, nor are the signing time and nonce
+// headers of SigV4 and HMAC signatures; identical requests are replayed
+// in the order they were recorded.
+type Cassette struct {
+	Path string
+	Mode CassetteMode
+	// IgnoreHeaders lists headers that may differ between recording and replay, e.g. "X-Request-Id".
+	// Trace context, User-Agent and framing headers are always ignored.
+	IgnoreHeaders []string
+	// IgnoreQueryParams lists query parameters that may differ, e.g. a timestamp
+	IgnoreQueryParams []string
+}
+
+// alwaysIgnoredHeaders vary between runs without changing the meaning of a request
+var alwaysIgnoredHeaders = []string{"Traceparent", "Tracestate", "User-Agent", "Content-Length", "Accept-Encoding", "If-None-Match", "If-Modified-Since"}
+
+// WithCassette records or replays upstream interactions with a cassette file
+func WithCassette(cassette Cassette) AdapterOption {
+	return func(c *adapterConfig) {
+		if cassette.Path == "" {
+			c.setError(fmt.Errorf("cassette needs a file path"))
+			return
+		}
+		c.cassette = &cassette
+	}
+}
+
+// cassetteFile is the JSON document stored in a cassette file
+type cassetteFile struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+// interaction is a recorded request and its response
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    recordedBody      `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	Status  int          `json:"status"`
+	Headers http.Header  `json:"headers,omitempty"`
+	Body    recordedBody `json:"body,omitempty"`
+}
+
+// recordedBody is stored as text if it is valid UTF-8 and as base64 otherwise
+type recordedBody []byte
+
+func (b recordedBody) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *recordedBody) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = []byte(text)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	*b = decoded
+	return err
+}
+
+// cassetteTransport is an http.RoundTripper recording to or replaying from a cassette
+type cassetteTransport struct {
+	base     http.RoundTripper
+	cassette Cassette
+	redactor *redactor
+	ignored  map[string]bool // Lowercase names of the headers left out of matching
+
+	mu       sync.Mutex
+	recorded cassetteFile
+	replay   map[string][]interaction // Interactions not replayed yet, by request key
+	last     map[string]interaction   // Last interaction replayed, served again once a key is used up
+	loadErr  error
+}
+
+// newCassetteTransport wraps base, loading the cassette file in replay mode
+func newCassetteTransport(base http.RoundTripper, cassette Cassette, r *redactor) *cassetteTransport {
+	t := &cassetteTransport{base: base, cassette: cassette, redactor: r, ignored: map[string]bool{}}
+	for _, name := range append(alwaysIgnoredHeaders, cassette.IgnoreHeaders...) {
+		t.ignored[strings.ToLower(name)] = true
+	}
+	if cassette.Mode != CassetteReplay {
+		return t
+	}
+
+	t.replay, t.last = map[string][]interaction{}, map[string]interaction{}
+	data, err := os.ReadFile(cassette.Path)
+	if err != nil {
+		t.loadErr = fmt.Errorf("failed to read cassette: %w", err)
+		return t
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.loadErr = fmt.Errorf("failed to parse cassette %s: %w", cassette.Path, err)
+		return t
+	}
+	for _, recorded := range file.Interactions {
+		key := t.key(recorded.Request)
+		t.replay[key] = append(t.replay[key], recorded)
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recordedReq := recordedRequest{
+		Method:  req.Method,
+		URL:     t.redactor.redactURL(req.URL.String(), nil),
+		Headers: t.redactor.redactHeaders(req.Header),
+		Body:    body,
+	}
+
+	if t.cassette.Mode == CassetteReplay {
+		recorded, err := t.next(t.key(recordedReq))
+		if err != nil {
+			return nil, fmt.Errorf("%w for %s %s", err, req.Method, recordedReq.URL)
+		}
+		return recorded.Response.response(req), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded := recordedResponse{Status: resp.StatusCode, Headers: resp.Header.Clone(), Body: respBody}
+	if err := t.record(interaction{Request: recordedReq, Response: recorded}); err != nil {
+		return nil, err
+	}
+	return recorded.response(req), nil
+}
+
+// next returns the next recorded interaction for the key
+func (t *cassetteTransport) next(key string) (interaction, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.loadErr != nil {
+		return interaction{}, t.loadErr
+	}
+	if pending := t.replay[key]; len(pending) > 0 {
+		t.replay[key] = pending[1:]
+		t.last[key] = pending[0]
+		return pending[0], nil
+	}
+	if recorded, ok := t.last[key]; ok {
+		return recorded, nil
+	}
+	return interaction{}, errors.New("no recorded interaction")
+}
+
+// record appends an interaction and saves the cassette
+func (t *cassetteTransport) record(recorded interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recorded.Interactions = append(t.recorded.Interactions, recorded)
+	data, err := json.MarshalIndent(t.recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so an interrupted run does not leave a truncated cassette
+	tmp, err := os.CreateTemp(filepath.Dir(t.cassette.Path), ".cassette-*")
+	if err != nil {
+		return fmt.Errorf("failed to save cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), t.cassette.Path); err != nil {
+		return fmt.Errorf("failed to save cassette: %w", err)
+	}
+	return nil
+}
+
+// key identifies a request for matching, leaving out ignored query parameters and headers
+// and headers whose values are redacted
+func (t *cassetteTransport) key(req recordedRequest) string {
+	url := req.URL
+	if u, err := neturl.Parse(url); err == nil && len(t.cassette.IgnoreQueryParams) > 0 {
+		q := u.Query()
+		for _, name := range t.cassette.IgnoreQueryParams {
+			q.Del(name)
+		}
+		u.RawQuery = q.Encode()
+		url = u.String()
+	}
+
+	names := make([]string, 0, len(req.Headers))
+	for name, value := range req.Headers {
+		if !t.ignored[strings.ToLower(name)] && value != redactedValue {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, url)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %s\n", http.CanonicalHeaderKey(name), req.Headers[name])
+	}
+	h.Write(req.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// response rebuilds the recorded response for req
+func (r recordedResponse) response(req *http.Request) *http.Response {
+	header := r.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// readRequestBody returns the body of a request without consuming it
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, err
+}
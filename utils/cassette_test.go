@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_CassetteRecordAndReplay(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("hello " + r.URL.Query().Get("name")))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	call := func(opts ...AdapterOption) string {
+		handler := NewToolHandler(http.MethodGet, ts.URL+"/greet", nil, opts...)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"searchParams": map[string]interface{}{"name": "bob", "ts": "1"}}
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resultText(t, result)
+	}
+
+	recording := Cassette{Path: path, Mode: CassetteRecord}
+	if got := call(WithCassette(recording), WithAuth(Auth{BearerToken: "secret-token"})); got != "hello bob" {
+		t.Fatalf("Got %q while recording", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Errorf("Cassette contains the bearer token: %s", data)
+	}
+	ts.Close()
+
+	// The upstream is gone, so the response must come from the cassette, even with other credentials
+	replay := Cassette{Path: path, Mode: CassetteReplay, IgnoreQueryParams: []string{"ts"}}
+	if got := call(WithCassette(replay), WithAuth(Auth{BearerToken: "other-token"})); got != "hello bob" {
+		t.Errorf("Got %q while replaying", got)
+	}
+	if calls != 1 {
+		t.Errorf("Upstream was called %d times; want 1", calls)
+	}
+
+	handler := NewToolHandler(http.MethodGet, ts.URL+"/unknown", nil, WithCassette(replay))
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultText(t, result); !strings.Contains(got, "no recorded interaction") {
+		t.Errorf("Got %q; want an error for a request that was not recorded", got)
+	}
+}
+
+func Test_CassetteReplaySignedRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("signed"))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	auth := WithAuth(Auth{SigV4: &SigV4Auth{AccessKeyID: "id", SecretAccessKey: "secret", SessionToken: "session-token", Region: "us-east-1", Service: "execute-api"}})
+
+	call := func(cassette Cassette) string {
+		handler := NewToolHandler(http.MethodGet, ts.URL+"/items", nil, WithCassette(cassette), auth)
+		result, err := handler(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resultText(t, result)
+	}
+
+	if got := call(Cassette{Path: path, Mode: CassetteRecord}); got != "signed" {
+		t.Fatalf("Got %q while recording", got)
+	}
+	ts.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "session-token") {
+		t.Errorf("Cassette contains the session token: %s", data)
+	}
+
+	// Replayed a second later, the request has another signing time and signature
+	time.Sleep(time.Second)
+	if got := call(Cassette{Path: path, Mode: CassetteReplay}); got != "signed" {
+		t.Errorf("Got %q while replaying", got)
+	}
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// coerceValue converts an argument to the JSON type declared by its schema.
+// MCP clients sometimes send numbers as strings or vice versa; values that cannot be
+// converted produce an error instead of a malformed request.
+func coerceValue(value interface{}, schema *Schema) (interface{}, error) {
+	if value == nil || schema == nil {
+		return value, nil
+	}
+
+	switch schema.Type {
+	case "integer":
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("expected integer, got %v", v)
+			}
+			return v, nil
+		case string:
+			i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				// Accept integral values written in float notation, e.g. "5.0"
+				f, ferr := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if ferr != nil || f != math.Trunc(f) {
+					return nil, fmt.Errorf("expected integer, got %q", v)
+				}
+				return f, nil
+			}
+			return i, nil
+		}
+	case "number":
+		if v, ok := value.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected number, got %q", v)
+			}
+			return f, nil
+		}
+	case "boolean":
+		if v, ok := value.(string); ok {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("expected boolean, got %q", v)
+			}
+			return b, nil
+		}
+	case "string":
+		switch v := value.(type) {
+		case float64, bool:
+			return formatScalar(v), nil
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			// A single value is treated as a one-element array
+			items = []interface{}{value}
+		}
+		result := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			coerced, err := coerceValue(item, schema.Items)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			result = append(result, coerced)
+		}
+		return result, nil
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			// Models occasionally send nested objects as JSON strings
+			str, isString := value.(string)
+			if !isString || json.Unmarshal([]byte(str), &obj) != nil {
+				return nil, fmt.Errorf("expected object, got %v", value)
+			}
+		}
+		coerced, err := coerceProperties(obj, schema.Properties)
+		if err != nil {
+			return nil, err
+		}
+		return coerced, nil
+	}
+	return value, nil
+}
+
+// coerceProperties coerces each value of an object to the type of the matching property schema.
+// Properties without a schema are passed through unchanged. The input map is not modified.
+func coerceProperties(values map[string]interface{}, properties map[string]Schema) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		prop, ok := properties[name]
+		if !ok {
+			result[name] = value
+			continue
+		}
+		coerced, err := coerceValue(value, &prop)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		result[name] = coerced
+	}
+	return result, nil
+}
+
+// WithStrictEnums rejects query and body arguments whose value is not one of the values declared by
+// their schema's enum, before the request is sent. It is off by default, since enums in some
+// specifications are incomplete.
+func WithStrictEnums(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.strictEnums = enabled
+	}
+}
+
+// checkEnums verifies that each value of an object, including nested objects and array items,
+// is allowed by the enum of the matching property schema
+func checkEnums(values map[string]interface{}, properties map[string]Schema) error {
+	for name, value := range values {
+		prop, ok := properties[name]
+		if !ok {
+			continue
+		}
+		if err := checkEnum(value, prop); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkEnum verifies that a coerced value is allowed by the schema's enum
+func checkEnum(value interface{}, schema Schema) error {
+	if value == nil {
+		return nil
+	}
+	if len(schema.Enum) > 0 {
+		allowed := false
+		for _, option := range schema.Enum {
+			allowed = allowed || enumEqual(value, option)
+		}
+		if !allowed {
+			options := make([]string, len(schema.Enum))
+			for i, option := range schema.Enum {
+				encoded, _ := json.Marshal(option)
+				options[i] = string(encoded)
+			}
+			encoded, _ := json.Marshal(value)
+			return fmt.Errorf("%s is not one of the allowed values %s", encoded, strings.Join(options, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range v {
+				if err := checkEnum(item, *schema.Items); err != nil {
+					return fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+		}
+	case map[string]interface{}:
+		return checkEnums(v, schema.Properties)
+	}
+	return nil
+}
+
+// enumEqual compares an argument with an enum value, treating numbers of different Go types as equal
+func enumEqual(value, option interface{}) bool {
+	if a, ok := toFloat(value); ok {
+		b, ok := toFloat(option)
+		return ok && a == b
+	}
+	switch value.(type) {
+	case string, bool:
+		return value == option
+	}
+	return false
+}
+
+// toFloat converts the numeric types produced by decoding and coercion to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
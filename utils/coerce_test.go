@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_CoerceValue(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		schema *Schema
+		want   interface{}
+	}{
+		{"42", &Schema{Type: "integer"}, int64(42)},
+		{"1.5", &Schema{Type: "number"}, 1.5},
+		{"true", &Schema{Type: "boolean"}, true},
+		{float64(7), &Schema{Type: "string"}, "7"},
+		{"a", &Schema{Type: "array", Items: &Schema{Type: "string"}}, []interface{}{"a"}},
+		{`{"n":"3"}`, &Schema{Type: "object", Properties: map[string]Schema{"n": {Type: "integer"}}}, map[string]interface{}{"n": int64(3)}},
+	}
+
+	for _, tt := range tests {
+		got, err := coerceValue(tt.value, tt.schema)
+		if err != nil {
+			t.Errorf("coerceValue(%v) returned error: %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("coerceValue(%v) = %#v; want %#v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := coerceValue("abc", &Schema{Type: "integer"}); err == nil {
+		t.Error("Expected an error coercing \"abc\" to integer")
+	}
+	if _, err := coerceValue(1.5, &Schema{Type: "integer"}); err == nil {
+		t.Error("Expected an error coercing 1.5 to integer")
+	}
+}
+
+func Test_CheckEnums(t *testing.T) {
+	properties := map[string]Schema{
+		"status": {Type: "string", Enum: []interface{}{"available", "sold"}},
+		"level":  {Type: "integer", Enum: []interface{}{float64(1), float64(2)}},
+		"tags":   {Type: "array", Items: &Schema{Type: "string", Enum: []interface{}{"a", "b"}}},
+	}
+	tests := []struct {
+		values  map[string]interface{}
+		wantErr string
+	}{
+		{map[string]interface{}{"status": "sold", "level": int64(2), "tags": []interface{}{"a"}, "other": "x"}, ""},
+		{map[string]interface{}{"status": nil}, ""},
+		{map[string]interface{}{"status": "lost"}, `status: "lost" is not one of the allowed values "available", "sold"`},
+		{map[string]interface{}{"level": float64(3)}, `level: 3 is not one of the allowed values 1, 2`},
+		{map[string]interface{}{"tags": []interface{}{"a", "c"}}, `tags: item 1: "c" is not one of the allowed values "a", "b"`},
+	}
+	for _, tt := range tests {
+		err := checkEnums(tt.values, properties)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%v: got error %v", tt.values, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("%v: got error %v; want %q", tt.values, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+	"strings"
+)
+
+// defaultMaxConcurrentRequests bounds the tool calls waiting on the upstream API at the same time
+const defaultMaxConcurrentRequests = 64
+
+// WithMaxConcurrentRequests limits how many tool calls send upstream requests at the same time.
+// Further calls wait for a slot until they are cancelled or time out. A call holds its slot
+// until its response is read, including retries and further pages.
+// The default is 64; a zero or negative limit disables the limit.
+func WithMaxConcurrentRequests(limit int) AdapterOption {
+	return func(c *adapterConfig) {
+		c.maxInFlight = limit
+	}
+}
+
+// WithHostConcurrencyLimit limits the tool calls sending requests to the given host,
+// e.g. "api.example.com", at the same time, in addition to the global limit
+func WithHostConcurrencyLimit(host string, limit int) AdapterOption {
+	return func(c *adapterConfig) {
+		if limit < 1 {
+			c.setError(fmt.Errorf("concurrency limit of host %s must be positive, got %d", host, limit))
+			return
+		}
+		if c.hostInFlight == nil {
+			c.hostInFlight = map[string]int{}
+		}
+		c.hostInFlight[strings.ToLower(host)] = limit
+	}
+}
+
+// semaphore holds a token for each call in flight; its capacity is the limit
+type semaphore chan struct{}
+
+// inFlightLimits holds the semaphores shared by all handlers of an adapter
+type inFlightLimits struct {
+	global semaphore
+	hosts  map[string]semaphore
+}
+
+// newInFlightLimits creates the semaphores for the configured limits
+func (c *adapterConfig) newInFlightLimits() *inFlightLimits {
+	limits := &inFlightLimits{hosts: map[string]semaphore{}}
+	if c.maxInFlight > 0 {
+		limits.global = make(semaphore, c.maxInFlight)
+	}
+	for host, limit := range c.hostInFlight {
+		limits.hosts[host] = make(semaphore, limit)
+	}
+	return limits
+}
+
+// semaphoresFor returns the semaphores a call sending requests to rawURL must acquire
+func (c *adapterConfig) semaphoresFor(rawURL string) []semaphore {
+	var semaphores []semaphore
+	if c.inFlight.global != nil {
+		semaphores = append(semaphores, c.inFlight.global)
+	}
+	if u, err := neturl.Parse(rawURL); err == nil {
+		if host, ok := c.inFlight.hosts[strings.ToLower(u.Host)]; ok {
+			semaphores = append(semaphores, host)
+		}
+	}
+	return semaphores
+}
+
+// acquireAll takes a slot from each semaphore in turn, waiting until one is free or ctx is done.
+// On success the returned function releases the slots; on failure no slot is held.
+func acquireAll(ctx context.Context, semaphores []semaphore) (func(), error) {
+	release := func(held []semaphore) {
+		for _, s := range held {
+			<-s
+		}
+	}
+	for i, s := range semaphores {
+		select {
+		case s <- struct{}{}:
+		case <-ctx.Done():
+			release(semaphores[:i])
+			return nil, fmt.Errorf("waiting for a free request slot: %w", ctx.Err())
+		}
+	}
+	return func() { release(semaphores) }, nil
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_ConcurrencyLimit(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	defer close(unblock)
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, nil, WithMaxConcurrentRequests(1))
+	go handler(context.Background(), mcp.CallToolRequest{})
+
+	// The second call waits for the slot held by the first and gives up when cancelled
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err := handler(ctx, mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !result.IsError || !strings.Contains(text, "free request slot") {
+		t.Errorf("Got result %q; want an error for the cancelled wait", text)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Cancelled call returned after %s", elapsed)
+	}
+}
+
+func Test_SemaphoresFor(t *testing.T) {
+	cfg := newAdapterConfig(WithHostConcurrencyLimit("API.example.com", 2))
+	if cfg.err != nil {
+		t.Fatal(cfg.err)
+	}
+	if got := cfg.semaphoresFor("https://api.example.com/items"); len(got) != 2 || cap(got[1]) != 2 {
+		t.Errorf("Got %d semaphores; want the global and host semaphores", len(got))
+	}
+	if got := newAdapterConfig(WithMaxConcurrentRequests(0)).semaphoresFor("https://other.example.com"); len(got) != 0 {
+		t.Errorf("Got %d semaphores; want none when the limit is disabled", len(got))
+	}
+	if err := newAdapterConfig(WithHostConcurrencyLimit("api.example.com", 0)).err; err == nil {
+		t.Errorf("Got no error for a zero host limit")
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+)
+
+// defaultCSRFHeader is the request header carrying the CSRF token unless configured otherwise
+const defaultCSRFHeader = "X-CSRF-Token"
+
+// maxCSRFResponseBytes bounds the body read from the token endpoint
+const maxCSRFResponseBytes = 64 << 10
+
+// CSRF configures how a CSRF token is obtained for session-based APIs that require one on
+// requests changing state. The token is sent in Header with every request other than GET, HEAD,
+// OPTIONS and TRACE. It is cached, and fetched again once if the upstream answers 403 Forbidden.
+type CSRF struct {
+	// URL is fetched with GET to obtain the token, sending Header with the value "Fetch". The token
+	// is read from the Header response header, else from the Cookie cookie, else from the top-level
+	// JSON field Field, else it is the whole response body.
+	URL string
+	// Cookie names the cookie holding the token, e.g. "XSRF-TOKEN", for APIs using double-submit
+	// cookies. Its current value is read from the cookie jar, see WithCookieJar, and URL is only
+	// fetched if the cookie is not set.
+	Cookie string
+	// Field names the JSON field of the URL response holding the token, e.g. "csrfToken"
+	Field string
+	// Header is the request header carrying the token; empty uses X-CSRF-Token
+	Header string
+}
+
+// WithCSRF fetches a CSRF token and sends it with the requests that change state.
+// The token header is redacted in logs.
+func WithCSRF(csrf CSRF) AdapterOption {
+	return func(c *adapterConfig) {
+		if csrf.URL == "" && csrf.Cookie == "" {
+			c.setError(fmt.Errorf("CSRF needs a token URL or cookie"))
+			return
+		}
+		if csrf.URL != "" {
+			if u, err := neturl.Parse(csrf.URL); err != nil || !u.IsAbs() {
+				c.setError(fmt.Errorf("invalid CSRF token URL %q", csrf.URL))
+				return
+			}
+		}
+		if csrf.Header == "" {
+			csrf.Header = defaultCSRFHeader
+		}
+		c.csrf = &csrfSource{CSRF: csrf}
+		c.redactHeaders = append(c.redactHeaders, csrf.Header)
+	}
+}
+
+// csrfSource caches the CSRF token shared by all handlers of an adapter
+type csrfSource struct {
+	CSRF
+
+	mu    sync.Mutex
+	token string
+}
+
+// needsCSRFToken reports whether requests with the given method must carry the token
+func needsCSRFToken(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+// get returns the token for a request to rawURL, fetching it if it is not known yet or refresh is
+// set. The fetch sends the given headers and credentials, like the request it is for.
+func (s *csrfSource) get(ctx context.Context, client *http.Client, rawURL string, refresh bool, headers map[string]string, auths []*Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !refresh {
+		if token := s.cookie(client, rawURL); token != "" {
+			return token, nil
+		}
+		if s.token != "" {
+			return s.token, nil
+		}
+	}
+	if s.URL == "" {
+		// The cookie may have been renewed by the response that asked for a fresh token
+		if token := s.cookie(client, rawURL); token != "" {
+			return token, nil
+		}
+		return "", fmt.Errorf("no %s cookie set", s.Cookie)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set(s.Header, "Fetch")
+	for _, auth := range auths {
+		if err := auth.apply(req); err != nil {
+			return "", err
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _, err := readLimited(resp.Body, maxCSRFResponseBytes)
+	if err != nil {
+		return "", err
+	}
+	if !isSuccessStatus(resp.StatusCode) {
+		return "", fmt.Errorf("token request failed with %s", resp.Status)
+	}
+
+	token := s.tokenFromResponse(resp, body)
+	if token == "" {
+		token = s.cookie(client, rawURL)
+	}
+	if token == "" {
+		return "", fmt.Errorf("no token in the response of %s", s.URL)
+	}
+	s.token = token
+	return token, nil
+}
+
+// tokenFromResponse reads the token from the response of the token URL
+func (s *csrfSource) tokenFromResponse(resp *http.Response, body []byte) string {
+	if token := resp.Header.Get(s.Header); token != "" {
+		return token
+	}
+	if s.Cookie != "" {
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == s.Cookie {
+				return cookie.Value
+			}
+		}
+		return ""
+	}
+	if s.Field != "" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return ""
+		}
+		if token, ok := fields[s.Field].(string); ok {
+			return token
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// cookie returns the value of the token cookie the client's jar holds for rawURL
+func (s *csrfSource) cookie(client *http.Client, rawURL string) string {
+	if s.Cookie == "" || client.Jar == nil {
+		return ""
+	}
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	for _, cookie := range client.Jar.Cookies(u) {
+		if cookie.Name == s.Cookie {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// drainResponse discards the rest of a response body and closes it, so the connection can be reused
+func drainResponse(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_CSRFTokenRefreshedOnForbidden(t *testing.T) {
+	fetches := 0
+	current := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/csrf" {
+			fetches++
+			current = fmt.Sprintf("token-%d", fetches)
+			w.Header().Set("X-CSRF-Token", current)
+			return
+		}
+		if r.Method == http.MethodGet && r.Header.Get("X-CSRF-Token") != "" {
+			t.Errorf("GET request sent a CSRF token")
+		}
+		if r.Method == http.MethodPost && r.Header.Get("X-CSRF-Token") != current {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	cfg := newAdapterConfig(WithCSRF(CSRF{URL: ts.URL + "/csrf"}))
+	create := newToolHandler(toolEndpoint{method: http.MethodPost, url: ts.URL + "/items"}, cfg)
+	for i := 0; i < 2; i++ {
+		result, err := create(context.Background(), mcp.CallToolRequest{})
+		if err != nil || result.IsError {
+			t.Fatalf("Call %d failed: %v %+v", i+1, err, result)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Token fetched %d times; want it cached", fetches)
+	}
+
+	// The upstream rotated its token, so the cached one is rejected and fetched again
+	current = "rotated"
+	result, err := create(context.Background(), mcp.CallToolRequest{})
+	if err != nil || result.IsError || fetches != 2 {
+		t.Errorf("Got %+v (%v) after %d fetches; want success after a refresh", result, err, fetches)
+	}
+
+	list := newToolHandler(toolEndpoint{method: http.MethodGet, url: ts.URL + "/items"}, cfg)
+	if _, err := list(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CSRFCookie(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "XSRF-TOKEN", Value: "abc", Path: "/"})
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-XSRF-Token")))
+	}))
+	defer ts.Close()
+
+	cfg := newAdapterConfig(WithCookieJar(nil), WithCSRF(CSRF{Cookie: "XSRF-TOKEN", Header: "X-XSRF-Token"}))
+	create := newToolHandler(toolEndpoint{method: http.MethodPost, url: ts.URL + "/items"}, cfg)
+	if result, _ := create(context.Background(), mcp.CallToolRequest{}); !result.IsError {
+		t.Errorf("Got %+v; want an error before the cookie is set", result)
+	}
+
+	login := newToolHandler(toolEndpoint{method: http.MethodGet, url: ts.URL + "/login"}, cfg)
+	login(context.Background(), mcp.CallToolRequest{})
+	result, err := create(context.Background(), mcp.CallToolRequest{})
+	if err != nil || resultText(t, result) != "abc" {
+		t.Errorf("Got %q (%v); want the cookie value as the token", resultText(t, result), err)
+	}
+	if err := newAdapterConfig(WithCSRF(CSRF{})).err; err == nil {
+		t.Errorf("Got no error for a CSRF config without a token source")
+	}
+}
+
+func Test_CSRFFailureKeepsCircuitUsable(t *testing.T) {
+	upstreamDown, tokenDown := true, true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/csrf" && tokenDown, r.URL.Path != "/csrf" && upstreamDown:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/csrf":
+			w.Header().Set("X-CSRF-Token", "token")
+		}
+	}))
+	defer ts.Close()
+
+	cfg := newAdapterConfig(
+		WithCSRF(CSRF{URL: ts.URL + "/csrf"}),
+		WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1, Cooldown: time.Millisecond}),
+	)
+	list := newToolHandler(toolEndpoint{method: http.MethodGet, url: ts.URL + "/items", circuit: cfg.circuits.forURL(ts.URL)}, cfg)
+	create := newToolHandler(toolEndpoint{method: http.MethodPost, url: ts.URL + "/items", circuit: cfg.circuits.forURL(ts.URL)}, cfg)
+
+	// The failing GET opens the circuit, then the half-open probe fails to obtain a token
+	if _, err := list(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if result, err := create(context.Background(), mcp.CallToolRequest{}); err != nil || !result.IsError {
+		t.Fatalf("Expected a CSRF token error, got %+v (%v)", result, err)
+	}
+
+	upstreamDown, tokenDown = false, false
+	if result, err := create(context.Background(), mcp.CallToolRequest{}); err != nil || result.IsError {
+		t.Fatalf("Expected the request to be sent once the token is available, got %+v (%v)", result, err)
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WithCurlCommand adds the equivalent curl command to every tool result that sent or would have
+// sent a request, as a second text item, to reproduce calls outside the server.
+// Sensitive headers, query parameters and credentials are redacted as in logs.
+func WithCurlCommand(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.showCurl = enabled
+	}
+}
+
+// curlCommand returns a curl command line sending the same request, quoted for POSIX shells
+func (c *adapterConfig) curlCommand(req *http.Request, reqBody *requestBody, secrets []string) string {
+	args := []string{"curl"}
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodHead:
+		args = append(args, "--head")
+	default:
+		args = append(args, "-X", req.Method)
+	}
+	args = append(args, shellQuote(c.redactor.redactURL(req.URL.String(), secrets)))
+
+	headers := c.redactor.redactHeaders(req.Header)
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "-H", shellQuote(name+": "+c.redactor.redactText(headers[name], secrets)))
+	}
+
+	switch {
+	case reqBody == nil:
+	case reqBody.data != nil:
+		args = append(args, "--data-binary", shellQuote(c.redactor.redactText(string(reqBody.data), secrets)))
+	default:
+		args = append(args, "--data-binary", "@-", fmt.Sprintf("# %s body streamed from files", reqBody.contentType))
+	}
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes a string as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Downloads saves successful response bodies to files and returns their path instead of their
+// content, for download endpoints whose bodies do not belong in the model's context
+type Downloads struct {
+	// Dir is the directory files are saved to; empty uses a new temporary directory
+	Dir string
+	// ContentTypes lists media type prefixes always saved to a file, e.g. "application/pdf" or "video/"
+	ContentTypes []string
+	// MinBytes saves bodies longer than this many bytes whatever their type; zero saves only
+	// bodies matching ContentTypes
+	MinBytes int64
+	// MaxBytes aborts downloads longer than this many bytes, removing the partial file; zero allows any size
+	MaxBytes int64
+	// MaxAge removes the files saved by the server once they are older, checked whenever a file is
+	// saved; zero keeps them
+	MaxAge time.Duration
+}
+
+// WithDownloads saves response bodies matching the configuration to files. The tool result is a JSON
+// object with the path, size and content type of the file, so a model can hand it to other tools.
+// Bodies are streamed to the file without being held in memory or limited by WithMaxResponseBytes.
+func WithDownloads(downloads Downloads) AdapterOption {
+	return func(c *adapterConfig) {
+		if len(downloads.ContentTypes) == 0 && downloads.MinBytes <= 0 {
+			c.setError(fmt.Errorf("downloads need content types or a minimum size"))
+			return
+		}
+		if downloads.Dir != "" {
+			if info, err := os.Stat(downloads.Dir); err != nil || !info.IsDir() {
+				c.setError(fmt.Errorf("invalid download directory %s", downloads.Dir))
+				return
+			}
+		}
+		c.downloads = &downloader{Downloads: downloads}
+	}
+}
+
+// downloader saves response bodies and tracks the files it created, so only those are cleaned up
+type downloader struct {
+	Downloads
+
+	mu    sync.Mutex
+	dir   string
+	saved []savedFile
+}
+
+// savedFile is the tool result of a response saved to a file
+type savedFile struct {
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType,omitempty"`
+	Status      int       `json:"status"`
+	created     time.Time // When the file was saved, for cleanup
+}
+
+// save writes the body to a file if the response matches the configuration. Otherwise it returns
+// a nil file and a reader returning the whole body, including any part read to check its size.
+func (d *downloader) save(tool string, resp *http.Response, body io.Reader) (*savedFile, io.Reader, error) {
+	contentType := resp.Header.Get("Content-Type")
+	if !isBinaryContentType(contentType, d.ContentTypes) {
+		if d.MinBytes <= 0 {
+			return nil, body, nil
+		}
+		head, err := io.ReadAll(io.LimitReader(body, d.MinBytes+1))
+		if err != nil || int64(len(head)) <= d.MinBytes {
+			return nil, bytes.NewReader(head), err
+		}
+		body = io.MultiReader(bytes.NewReader(head), body)
+	}
+
+	dir, err := d.directory()
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.CreateTemp(dir, downloadPattern(tool, resp))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create download file: %w", err)
+	}
+	reader := body
+	if d.MaxBytes > 0 {
+		reader = io.LimitReader(body, d.MaxBytes+1)
+	}
+	size, err := io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && d.MaxBytes > 0 && size > d.MaxBytes {
+		err = fmt.Errorf("download exceeds the size limit of %d bytes%s", d.MaxBytes, totalSizeNote(resp))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, nil, err
+	}
+
+	saved := savedFile{Path: file.Name(), Size: size, ContentType: contentType, Status: resp.StatusCode, created: time.Now()}
+	d.track(saved)
+	return &saved, nil, nil
+}
+
+// directory returns the directory files are saved to, creating a temporary one on first use
+func (d *downloader) directory() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dir == "" {
+		d.dir = d.Dir
+	}
+	if d.dir == "" {
+		dir, err := os.MkdirTemp("", "mcp-downloads-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create download directory: %w", err)
+		}
+		d.dir = dir
+	}
+	return d.dir, nil
+}
+
+// track records a saved file and removes the files older than MaxAge
+func (d *downloader) track(saved savedFile) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.MaxAge > 0 {
+		kept := d.saved[:0]
+		for _, file := range d.saved {
+			if time.Since(file.created) > d.MaxAge {
+				os.Remove(file.Path)
+				continue
+			}
+			kept = append(kept, file)
+		}
+		d.saved = kept
+	}
+	d.saved = append(d.saved, saved)
+}
+
+// downloadPattern returns the os.CreateTemp pattern of a download, keeping the extension of the file
+// name suggested by Content-Disposition or else one matching the content type
+func downloadPattern(tool string, resp *http.Response) string {
+	name := sanitizeToolName(tool)
+	ext := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		filename := filepath.Base(filepath.Clean("/" + params["filename"]))
+		ext = filepath.Ext(filename)
+		name = strings.TrimSuffix(filename, ext)
+	} else if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	name = strings.NewReplacer("*", "", string(filepath.Separator), "").Replace(name)
+	ext = strings.NewReplacer("*", "", string(filepath.Separator), "").Replace(ext)
+	if name == "" || name == "." {
+		name = "download"
+	}
+	return name + "-*" + ext
+}
+
+// newDownloadResult returns the description of a saved file as the tool result
+func newDownloadResult(saved *savedFile) *mcp.CallToolResult {
+	encoded, err := json.Marshal(saved)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Error marshaling response: %v", err))
+	}
+	return mcp.NewToolResultText(string(encoded))
+}
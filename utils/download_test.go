@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_Downloads(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", `attachment; filename="../q3 report.pdf"`)
+			w.Write([]byte("%PDF-1.7"))
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			w.Write([]byte("small"))
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	opts := []AdapterOption{WithDownloads(Downloads{Dir: dir, ContentTypes: []string{"application/pdf"}, MinBytes: 10})}
+	call := func(path string) (*mcp.CallToolResult, savedFile) {
+		result, err := NewToolHandler(http.MethodGet, ts.URL+path, nil, opts...)(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var saved savedFile
+		json.Unmarshal([]byte(resultText(t, result)), &saved)
+		return result, saved
+	}
+
+	_, saved := call("/report")
+	if filepath.Dir(saved.Path) != dir || !strings.HasPrefix(filepath.Base(saved.Path), "q3 report-") || filepath.Ext(saved.Path) != ".pdf" {
+		t.Errorf("Got path %q; want a .pdf file named after the suggested file name in %s", saved.Path, dir)
+	}
+	if data, err := os.ReadFile(saved.Path); err != nil || string(data) != "%PDF-1.7" || saved.Size != 8 {
+		t.Errorf("Got file %q (%v) of size %d", data, err, saved.Size)
+	}
+
+	if _, saved = call("/large"); saved.Size != 100 {
+		t.Errorf("Got size %d; want the body over the threshold saved in full", saved.Size)
+	}
+	if result, _ := call("/small"); resultText(t, result) != "small" {
+		t.Errorf("Got %q; want the small body returned as is", resultText(t, result))
+	}
+
+	opts = append(opts, WithDownloads(Downloads{Dir: dir, MinBytes: 10, MaxBytes: 50}))
+	if result, _ := call("/large"); !result.IsError {
+		t.Errorf("Got %q; want an error for a download over the size limit", resultText(t, result))
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithDryRun makes tools return the request they would send instead of sending it, to check how
+// tool arguments are assembled into the URL, headers and body and how credentials are applied.
+// Sensitive headers, query parameters and credentials are redacted as in logs.
+func WithDryRun(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.dryRun = enabled
+	}
+}
+
+// dryRunRequest is the tool result returned in dry-run mode
+type dryRunRequest struct {
+	DryRun  bool              `json:"dryRun"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// newDryRunResult describes the request that would have been sent
+func (c *adapterConfig) newDryRunResult(req *http.Request, reqBody *requestBody, secrets []string) *mcp.CallToolResult {
+	description := dryRunRequest{
+		DryRun:  true,
+		Method:  req.Method,
+		URL:     c.redactor.redactURL(req.URL.String(), secrets),
+		Headers: c.redactor.redactHeaders(req.Header),
+	}
+	switch {
+	case reqBody == nil:
+	case reqBody.data != nil:
+		description.Body = c.redactor.redactText(string(reqBody.data), secrets)
+	default:
+		description.Body = fmt.Sprintf("(%s body streamed from files, %d bytes)", reqBody.contentType, reqBody.length)
+	}
+
+	encoded, err := json.MarshalIndent(description, "", "  ")
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("Error marshaling request: %v", err))
+	}
+	return mcp.NewToolResultText(string(encoded))
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// expandEnv replaces references to environment variables in a configuration value: $VAR, ${VAR}
+// and ${VAR:-default}, which uses default if VAR is unset or empty. "$$" stands for a literal "$",
+// as does a "$" not followed by a variable name. Referencing an unset variable without a default
+// is an error, so a missing secret is not sent as an empty value.
+func expandEnv(value string) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		switch next := value[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(value[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", value)
+			}
+			name, fallback, hasFallback := strings.Cut(value[i+2:i+2+end], ":-")
+			if !isEnvName(name) {
+				return "", fmt.Errorf("invalid variable name %q", name)
+			}
+			resolved, ok := os.LookupEnv(name)
+			switch {
+			case hasFallback && resolved == "":
+				resolved = fallback
+			case !ok:
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			b.WriteString(resolved)
+			i += 2 + end
+		case isEnvNameByte(next, true):
+			end := i + 2
+			for end < len(value) && isEnvNameByte(value[end], false) {
+				end++
+			}
+			name := value[i+1 : end]
+			resolved, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			b.WriteString(resolved)
+			i = end - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// WithHeaderEnv resolves references to environment variables in the values of the configured
+// headers when the tools are built, e.g. "Authorization: Bearer ${API_TOKEN}", so secrets can stay
+// out of configuration files. References are written $VAR, ${VAR} or ${VAR:-default}, which uses
+// default if VAR is unset or empty; other unset variables are an error. "$$" is a literal "$".
+// It is disabled by default, since headers received from clients, such as those of SSEServer,
+// must never be expanded: they could read any variable of the server's environment.
+func WithHeaderEnv(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.envHeaders = enabled
+	}
+}
+
+// expandHeaderEnv expands environment variable references in the values of headers if enabled,
+// returning a new map if any value changed
+func (c *adapterConfig) expandHeaderEnv(headers map[string]string) (map[string]string, error) {
+	if !c.envHeaders {
+		return headers, nil
+	}
+	var expanded map[string]string
+	for name, value := range headers {
+		resolved, err := expandEnv(value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		if resolved == value {
+			continue
+		}
+		if expanded == nil {
+			expanded = make(map[string]string, len(headers))
+			for key, original := range headers {
+				expanded[key] = original
+			}
+		}
+		expanded[name] = resolved
+	}
+	if expanded == nil {
+		return headers, nil
+	}
+	return expanded, nil
+}
+
+// isEnvName reports whether name is a valid environment variable name
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isEnvNameByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEnvNameByte reports whether c may appear in a variable name; digits may not start one
+func isEnvNameByte(c byte, first bool) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (!first && '0' <= c && c <= '9')
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func Test_ExpandEnv(t *testing.T) {
+	t.Setenv("API_TOKEN", "s3cret")
+	t.Setenv("EMPTY", "")
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Bearer ${API_TOKEN}", "Bearer s3cret"},
+		{"Bearer $API_TOKEN", "Bearer s3cret"},
+		{"${MISSING_VAR:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${EMPTY}", ""},
+		{"price: $$5, $ 1, end$", "price: $5, $ 1, end$"},
+	}
+	for _, tt := range tests {
+		if got, err := expandEnv(tt.value); err != nil || got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, %v; want %q", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"${MISSING_VAR}", "$MISSING_VAR", "${API_TOKEN", "${1X}"} {
+		if _, err := expandEnv(value); err == nil {
+			t.Errorf("expandEnv(%q) succeeded; want an error", value)
+		}
+	}
+}
+
+func Test_HeaderEnvDisabledByDefault(t *testing.T) {
+	t.Setenv("API_TOKEN", "s3cret")
+	headers := map[string]string{"Authorization": "Bearer ${API_TOKEN}"}
+
+	if got, _ := newAdapterConfig().expandHeaderEnv(headers); got["Authorization"] != "Bearer ${API_TOKEN}" {
+		t.Errorf("Got %q; want the value unchanged", got["Authorization"])
+	}
+	got, err := newAdapterConfig(WithHeaderEnv(true)).expandHeaderEnv(headers)
+	if err != nil || got["Authorization"] != "Bearer s3cret" || headers["Authorization"] != "Bearer ${API_TOKEN}" {
+		t.Errorf("Got %q (%v); want the variable resolved in a copy", got["Authorization"], err)
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Vendor extensions recognized on operations
+const (
+	// extensionIgnore excludes an operation from tool generation when set to true
+	extensionIgnore = "x-mcp-ignore"
+	// extensionName sets the tool name of an operation, taking precedence over the configured ToolNamer
+	extensionName = "x-mcp-name"
+	// extensionTimeout sets the request timeout of an operation, as a duration such as "120s" or in seconds
+	extensionTimeout = "x-mcp-timeout"
+	// extensionHeaders sets headers sent with the requests of an operation, as an object of strings
+	extensionHeaders = "x-mcp-headers"
+	// extensionGraphQLQuery holds the GraphQL document sent by operations created by GraphQLParser
+	extensionGraphQLQuery = "x-mcp-graphql-query"
+	// extensionGRPCMethod holds the RPC called by operations created by GRPCParser
+	extensionGRPCMethod = "x-mcp-grpc-method"
+)
+
+// parseExtensions collects the vendor extensions of a spec object, or returns nil if it has none
+func parseExtensions(obj map[string]interface{}) map[string]interface{} {
+	var extensions map[string]interface{}
+	for key, value := range obj {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if extensions == nil {
+			extensions = map[string]interface{}{}
+		}
+		extensions[key] = value
+	}
+	return extensions
+}
+
+// Extension returns the value of a vendor extension of the operation, such as "x-mcp-ignore"
+func (e APIEndpoint) Extension(name string) (interface{}, bool) {
+	value, ok := e.Extensions[name]
+	return value, ok
+}
+
+// extensionBool reports whether a vendor extension is set to true, accepting the string "true" as well
+func (e APIEndpoint) extensionBool(name string) bool {
+	switch value := e.Extensions[name].(type) {
+	case bool:
+		return value
+	case string:
+		return strings.EqualFold(value, "true")
+	default:
+		return false
+	}
+}
+
+// extensionDuration returns the duration set by a vendor extension, given as a Go duration string
+// such as "1m30s" or as a number of seconds. It returns zero if the extension is absent.
+func (e APIEndpoint) extensionDuration(name string) (time.Duration, error) {
+	value, ok := e.Extensions[name]
+	if !ok {
+		return 0, nil
+	}
+
+	var duration time.Duration
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", name, v, err)
+		}
+		duration = parsed
+	case float64:
+		duration = time.Duration(v * float64(time.Second))
+	case int:
+		duration = time.Duration(v) * time.Second
+	default:
+		return 0, fmt.Errorf("invalid %s %v: expected a duration", name, value)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid %s %v: must be positive", name, value)
+	}
+	return duration, nil
+}
+
+// extensionStringMap returns the value of a vendor extension given as an object of strings,
+// or nil if the extension is absent
+func (e APIEndpoint) extensionStringMap(name string) (map[string]string, error) {
+	value, ok := e.Extensions[name]
+	if !ok {
+		return nil, nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %v: expected an object", name, value)
+	}
+	result := make(map[string]string, len(object))
+	for key, item := range object {
+		text, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s: value of %s must be a string", name, key)
+		}
+		result[key] = text
+	}
+	return result, nil
+}
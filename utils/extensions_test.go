@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_TimeoutFromExtension(t *testing.T) {
+	cfg := newAdapterConfig(WithRequestTimeout(10 * time.Second))
+	tests := []struct {
+		opCfg     OperationConfig
+		extension interface{}
+		want      time.Duration
+		wantErr   bool
+	}{
+		{extension: nil, want: 10 * time.Second},
+		{extension: "120s", want: 120 * time.Second},
+		{extension: float64(5), want: 5 * time.Second},
+		{opCfg: OperationConfig{Timeout: time.Second}, extension: "120s", want: time.Second},
+		{extension: "soon", wantErr: true},
+		{extension: "-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		api := APIEndpoint{}
+		if tt.extension != nil {
+			api.Extensions = map[string]interface{}{"x-mcp-timeout": tt.extension}
+		}
+		got, err := cfg.timeoutFor(tt.opCfg, api)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Got error %v for %v; want error %v", err, tt.extension, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Got timeout %s for %v; want %s", got, tt.extension, tt.want)
+		}
+	}
+}
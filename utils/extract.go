@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath expression. The supported subset covers child names ($.a.b or
+// $['a']), array indexes, negative ones counting from the end ($.items[0], $.items[-1]) and
+// wildcards ($.items[*].id, $.data.*).
+type jsonPath struct {
+	expression string
+	steps      []pathStep
+}
+
+// pathStep selects children of a JSON value
+type pathStep struct {
+	name     string // Object member to select, if not an index or wildcard
+	index    int
+	isIndex  bool
+	wildcard bool // Selects all members of an object or elements of an array
+}
+
+// compileJSONPath parses a JSONPath expression
+func compileJSONPath(expression string) (*jsonPath, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(expression), "$")
+	if !ok {
+		return nil, fmt.Errorf("JSONPath %q must start with $", expression)
+	}
+	path := &jsonPath{expression: expression}
+	for rest != "" {
+		var step pathStep
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, fmt.Errorf("JSONPath %q: recursive descent is not supported", expression)
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			name := rest[1:end]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q: empty member name", expression)
+			}
+			step = pathStep{name: name, wildcard: name == "*"}
+			rest = rest[end:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q: unclosed [", expression)
+			}
+			selector := strings.TrimSpace(rest[1:end])
+			switch {
+			case selector == "*":
+				step = pathStep{wildcard: true}
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				step = pathStep{name: selector[1 : len(selector)-1]}
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("JSONPath %q: unsupported selector [%s]", expression, selector)
+				}
+				step = pathStep{index: index, isIndex: true}
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q", expression, rest)
+		}
+		path.steps = append(path.steps, step)
+	}
+	return path, nil
+}
+
+// hasWildcard reports whether the path can match more than one value
+func (p *jsonPath) hasWildcard() bool {
+	for _, step := range p.steps {
+		if step.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the value matched in document. Paths with wildcards return an array of all matches.
+func (p *jsonPath) apply(document interface{}) (interface{}, error) {
+	nodes := []interface{}{document}
+	for _, step := range p.steps {
+		var matched []interface{}
+		for _, node := range nodes {
+			matched = append(matched, step.children(node)...)
+		}
+		nodes = matched
+	}
+	if p.hasWildcard() {
+		if nodes == nil {
+			nodes = []interface{}{}
+		}
+		return nodes, nil
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%s matches nothing", p.expression)
+	}
+	return nodes[0], nil
+}
+
+// children returns the values the step selects from node
+func (s pathStep) children(node interface{}) []interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			names := make([]string, 0, len(value))
+			for name := range value {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			children := make([]interface{}, 0, len(names))
+			for _, name := range names {
+				children = append(children, value[name])
+			}
+			return children
+		}
+		if child, ok := value[s.name]; ok && !s.isIndex {
+			return []interface{}{child}
+		}
+	case []interface{}:
+		if s.wildcard {
+			return value
+		}
+		if s.isIndex {
+			index := s.index
+			if index < 0 {
+				index += len(value)
+			}
+			if index >= 0 && index < len(value) {
+				return []interface{}{value[index]}
+			}
+		}
+	}
+	return nil
+}
+
+// extractFor returns the compiled extraction path of the given operation, or nil if it has none.
+// The path has been validated by WithOperationConfig.
+func (c *adapterConfig) extractFor(opCfg OperationConfig) *jsonPath {
+	if opCfg.Extract == "" {
+		return nil
+	}
+	path, _ := compileJSONPath(opCfg.Extract)
+	return path
+}
+
+// extractBody applies the extraction path of the endpoint to a JSON body. If the body cannot be
+// parsed or the path matches nothing, the full body is returned with a note saying so.
+func (c *adapterConfig) extractBody(endpoint toolEndpoint, body []byte) []byte {
+	extracted, err := extractJSON(endpoint.extract, body)
+	if err != nil {
+		c.logger.Warn("response extraction failed", "tool", endpoint.name, "path", endpoint.extract.expression, "error", err)
+		note := fmt.Sprintf("\n\n[Extracting %s failed: %v; returning the full response]", endpoint.extract.expression, err)
+		return append(body[:len(body):len(body)], note...)
+	}
+	return extracted
+}
+
+// extractJSON returns the part of a JSON document matched by the path, encoded as JSON
+func extractJSON(path *jsonPath, body []byte) ([]byte, error) {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("the response is not valid JSON")
+	}
+	value, err := path.apply(document)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
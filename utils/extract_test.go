@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func Test_JSONPath(t *testing.T) {
+	document := `{"data": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}], "meta": {"total": 2}}`
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"$", document, false},
+		{"$.meta.total", `2`, false},
+		{"$['meta']", `{"total":2}`, false},
+		{"$.data[0].name", `"a"`, false},
+		{"$.data[-1].id", `2`, false},
+		{"$.data[*].id", `[1,2]`, false},
+		{"$.meta.*", `[2]`, false},
+		{"$.missing", "", true},
+		{"$.data[5]", "", true},
+	}
+	for _, tt := range tests {
+		path, err := compileJSONPath(tt.path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		got, err := extractJSON(path, []byte(document))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v; want error %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && tt.path != "$" && string(got) != tt.want {
+			t.Errorf("%s: got %s; want %s", tt.path, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"data", "$..id", "$.data[x]", "$.data[0"} {
+		if _, err := compileJSONPath(invalid); err == nil {
+			t.Errorf("%s: want a compile error", invalid)
+		}
+	}
+}
+
+func Test_ExtractResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			w.Write([]byte("not json"))
+			return
+		}
+		w.Write([]byte(`{"data": {"items": [1, 2]}, "links": {}}`))
+	}))
+	defer ts.Close()
+
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {
+		"/items": {"get": {"operationId": "listItems"}},
+		"/text": {"get": {"operationId": "getText"}}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newAdapterConfig(
+		WithOperationConfig("listItems", OperationConfig{Extract: "$.data.items"}),
+		WithOperationConfig("getText", OperationConfig{Extract: "$.data"}),
+	)
+	tools, err := buildTools(cfg, server.NewMCPServer("t", "1"), "t", ts.URL, nil, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := map[string]server.ToolHandlerFunc{}
+	for _, tool := range tools {
+		handlers[tool.Tool.Name] = tool.Handler
+	}
+
+	tests := []struct {
+		tool string
+		want string
+	}{
+		{"listitems", `[1,2]`},
+		{"gettext", "not json\n\n[Extracting $.data failed"},
+	}
+	for _, tt := range tests {
+		result, err := handlers[tt.tool](context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.HasPrefix(text, tt.want) {
+			t.Errorf("%s: got %q; want prefix %q", tt.tool, text, tt.want)
+		}
+	}
+
+	if cfg := newAdapterConfig(WithOperationConfig("listItems", OperationConfig{Extract: "data"})); cfg.err == nil {
+		t.Error("Want an error for an invalid extraction path")
+	}
+}
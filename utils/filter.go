@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operations are filtered before their tools are registered. An operation is registered if it
+// passes every configured include filter (tags, paths and methods) and matches no exclude filter;
+// when an operation matches both an include and an exclude filter, exclusion wins.
+// Operations flagged with x-mcp-ignore, or deprecated ones when WithSkipDeprecated is set,
+// are never registered.
+
+// WithSkipDeprecated skips the operations marked deprecated in the spec
+func WithSkipDeprecated(skip bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.skipDeprecated = skip
+	}
+}
+
+// WithIncludeTags registers only the operations that have at least one of the given tags.
+// Tags are matched exactly; operations without tags are skipped when this option is used.
+func WithIncludeTags(tags ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.includeTags = append(c.includeTags, tags...)
+	}
+}
+
+// WithExcludeTags skips the operations that have any of the given tags.
+// Exclusion takes precedence over WithIncludeTags.
+func WithExcludeTags(tags ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.excludeTags = append(c.excludeTags, tags...)
+	}
+}
+
+// WithIncludePaths registers only the operations whose path matches at least one of the regular
+// expressions, e.g. "^/users(/|$)". Patterns are matched against the path template as written in
+// the specification, such as "/users/{id}".
+func WithIncludePaths(patterns ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.includePaths = append(c.includePaths, compilePathPatterns(c, patterns)...)
+	}
+}
+
+// WithExcludePaths skips the operations whose path matches any of the regular expressions
+func WithExcludePaths(patterns ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.excludePaths = append(c.excludePaths, compilePathPatterns(c, patterns)...)
+	}
+}
+
+// WithIncludeMethods registers only the operations using one of the given HTTP methods,
+// e.g. WithIncludeMethods("GET") for a read-only toolset. Methods are matched case-insensitively.
+func WithIncludeMethods(methods ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		for _, method := range methods {
+			c.includeMethods = append(c.includeMethods, strings.ToUpper(method))
+		}
+	}
+}
+
+// compilePathPatterns compiles path filter expressions, recording an error for invalid ones
+func compilePathPatterns(c *adapterConfig, patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			c.setError(fmt.Errorf("invalid path pattern %q: %w", pattern, err))
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled
+}
+
+// matchesAny reports whether path matches one of the expressions
+func matchesAny(patterns []*regexp.Regexp, path string) bool {
+	for _, re := range patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// includeOperation reports whether a tool should be registered for the operation
+func (c *adapterConfig) includeOperation(api APIEndpoint) bool {
+	if api.extensionBool(extensionIgnore) || (c.skipDeprecated && api.Deprecated) {
+		return false
+	}
+	if matchesAny(c.excludePaths, api.Path) {
+		return false
+	}
+	if len(c.includePaths) > 0 && !matchesAny(c.includePaths, api.Path) {
+		return false
+	}
+	if len(c.includeMethods) > 0 && !containsString(c.includeMethods, strings.ToUpper(api.Method)) {
+		return false
+	}
+
+	for _, tag := range api.Tags {
+		if containsString(c.excludeTags, tag) {
+			return false
+		}
+	}
+	if len(c.includeTags) > 0 {
+		included := false
+		for _, tag := range api.Tags {
+			if containsString(c.includeTags, tag) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"testing"
+)
+
+func Test_IncludeOperationByTag(t *testing.T) {
+	apis := []APIEndpoint{
+		{OperationID: "listPets", Tags: []string{"pets"}},
+		{OperationID: "deletePet", Tags: []string{"pets", "admin"}},
+		{OperationID: "listUsers", Tags: []string{"users"}},
+		{OperationID: "ping"},
+	}
+
+	tests := []struct {
+		opts []AdapterOption
+		want []string
+	}{
+		{nil, []string{"listPets", "deletePet", "listUsers", "ping"}},
+		{[]AdapterOption{WithIncludeTags("pets")}, []string{"listPets", "deletePet"}},
+		{[]AdapterOption{WithExcludeTags("admin")}, []string{"listPets", "listUsers", "ping"}},
+		{[]AdapterOption{WithIncludeTags("pets"), WithExcludeTags("admin")}, []string{"listPets"}},
+	}
+
+	for i, tt := range tests {
+		cfg := newAdapterConfig(tt.opts...)
+		var got []string
+		for _, api := range apis {
+			if cfg.includeOperation(api) {
+				got = append(got, api.OperationID)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("case %d: got %v; want %v", i, got, tt.want)
+			continue
+		}
+		for j := range got {
+			if got[j] != tt.want[j] {
+				t.Errorf("case %d: got %v; want %v", i, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func Test_IncludeOperationByPathAndMethod(t *testing.T) {
+	apis := []APIEndpoint{
+		{OperationID: "listUsers", Path: "/users", Method: "GET"},
+		{OperationID: "getUser", Path: "/users/{id}", Method: "GET"},
+		{OperationID: "deleteUser", Path: "/users/{id}", Method: "DELETE"},
+		{OperationID: "listAdmins", Path: "/admin/users", Method: "GET"},
+	}
+
+	cfg := newAdapterConfig(
+		WithIncludePaths("^/users"),
+		WithExcludePaths(`\{id\}$`),
+		WithIncludeMethods("get"),
+	)
+	var got []string
+	for _, api := range apis {
+		if cfg.includeOperation(api) {
+			got = append(got, api.OperationID)
+		}
+	}
+	if len(got) != 1 || got[0] != "listUsers" {
+		t.Errorf("Unexpected operations: %v", got)
+	}
+
+	if cfg := newAdapterConfig(WithIncludePaths("(")); cfg.err == nil {
+		t.Errorf("Expected an error for an invalid pattern")
+	}
+}
+
+func Test_SkipIgnoredAndDeprecatedOperations(t *testing.T) {
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {"/pets": {
+		"get": {"operationId": "listPets"},
+		"post": {"operationId": "createPet", "x-mcp-ignore": true},
+		"delete": {"operationId": "deletePets", "deprecated": true}
+	}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts []AdapterOption
+		want map[string]bool
+	}{
+		{nil, map[string]bool{"listPets": true, "deletePets": true}},
+		{[]AdapterOption{WithSkipDeprecated(true)}, map[string]bool{"listPets": true}},
+	}
+	for i, tt := range tests {
+		cfg := newAdapterConfig(tt.opts...)
+		got := map[string]bool{}
+		for _, api := range parser.APIs() {
+			if cfg.includeOperation(api) {
+				got[api.OperationID] = true
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("case %d: got %v; want %v", i, got, tt.want)
+			continue
+		}
+		for name := range tt.want {
+			if !got[name] {
+				t.Errorf("case %d: got %v; want %v", i, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxGraphQLDepth limits how deeply nested object fields are selected in generated queries,
+// and how deeply nested input objects are expanded into argument schemas
+const maxGraphQLDepth = 3
+
+// defaultGraphQLTitle is the API title of GraphQL schemas, which have no title of their own
+const defaultGraphQLTitle = "GraphQL API"
+
+// gqlSchema is a GraphQL schema, in the shape of the __schema field of an introspection result
+type gqlSchema struct {
+	Description  string    `json:"description"`
+	QueryType    *gqlNamed `json:"queryType"`
+	MutationType *gqlNamed `json:"mutationType"`
+	Types        []gqlType `json:"types"`
+}
+
+type gqlNamed struct {
+	Name string `json:"name"`
+}
+
+type gqlType struct {
+	Kind          string          `json:"kind"` // OBJECT, INTERFACE, UNION, ENUM, INPUT_OBJECT or SCALAR
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Fields        []gqlField      `json:"fields"`
+	InputFields   []gqlInputValue `json:"inputFields"`
+	EnumValues    []gqlNamed      `json:"enumValues"`
+	PossibleTypes []gqlNamed      `json:"possibleTypes"`
+}
+
+type gqlField struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Args         []gqlInputValue `json:"args"`
+	Type         gqlTypeRef      `json:"type"`
+	IsDeprecated bool            `json:"isDeprecated"`
+}
+
+type gqlInputValue struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Type         gqlTypeRef `json:"type"`
+	DefaultValue *string    `json:"defaultValue"` // GraphQL literal, e.g. "10" or "\"abc\""
+}
+
+// gqlTypeRef references a named type, possibly wrapped as a list or non-null type
+type gqlTypeRef struct {
+	Kind   string      `json:"kind"` // NON_NULL or LIST for wrapping types
+	Name   string      `json:"name"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+// String formats the reference in GraphQL syntax, e.g. [String!]!
+func (r gqlTypeRef) String() string {
+	switch {
+	case r.Kind == "NON_NULL" && r.OfType != nil:
+		return r.OfType.String() + "!"
+	case r.Kind == "LIST" && r.OfType != nil:
+		return "[" + r.OfType.String() + "]"
+	}
+	return r.Name
+}
+
+// named returns the name of the referenced type without its wrappers
+func (r gqlTypeRef) named() string {
+	if r.OfType != nil && (r.Kind == "NON_NULL" || r.Kind == "LIST") {
+		return r.OfType.named()
+	}
+	return r.Name
+}
+
+// GraphQLParser exposes the queries and mutations of a GraphQL API as operations, so that they can
+// be served as tools like OpenAPI operations. Each operation is a POST to the GraphQL endpoint whose
+// request body properties are the field's arguments; the handler sends them as the variables of a
+// query generated from the schema, selecting scalar fields up to maxGraphQLDepth levels deep.
+type GraphQLParser struct {
+	schema   *gqlSchema
+	types    map[string]*gqlType
+	endpoint string
+	source   []byte
+}
+
+// ParseGraphQL parses a GraphQL schema, given in the schema definition language or as the JSON
+// result of an introspection query, for the API served at endpoint
+func ParseGraphQL(data []byte, endpoint string) (*GraphQLParser, error) {
+	var schema *gqlSchema
+	if isJSONDocument(data) {
+		var result struct {
+			Data *struct {
+				Schema *gqlSchema `json:"__schema"`
+			} `json:"data"`
+			Schema *gqlSchema `json:"__schema"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("invalid introspection result: %w", err)
+		}
+		schema = result.Schema
+		if result.Data != nil && result.Data.Schema != nil {
+			schema = result.Data.Schema
+		}
+		if schema == nil {
+			return nil, fmt.Errorf("invalid introspection result: no __schema field")
+		}
+	} else {
+		var err error
+		if schema, err = parseSDL(data); err != nil {
+			return nil, fmt.Errorf("invalid GraphQL schema: %w", err)
+		}
+	}
+	if schema.QueryType == nil && schema.MutationType == nil {
+		return nil, fmt.Errorf("GraphQL schema has no query or mutation type")
+	}
+
+	p := &GraphQLParser{schema: schema, types: map[string]*gqlType{}, endpoint: endpoint, source: data}
+	for i := range schema.Types {
+		p.types[schema.Types[i].Name] = &schema.Types[i]
+	}
+	return p, nil
+}
+
+// Source returns the schema the parser was created from
+func (p *GraphQLParser) Source() ([]byte, string) {
+	if isJSONDocument(p.source) {
+		return p.source, "application/json"
+	}
+	return p.source, "application/graphql"
+}
+
+// Servers returns the GraphQL endpoint
+func (p *GraphQLParser) Servers() []Server {
+	if p.endpoint == "" {
+		return nil
+	}
+	return []Server{{URL: p.endpoint}}
+}
+
+// Info returns the description of the schema
+func (p *GraphQLParser) Info() APIInfo {
+	return APIInfo{Title: defaultGraphQLTitle, Description: p.schema.Description}
+}
+
+// APIs returns one operation per field of the query and mutation types
+func (p *GraphQLParser) APIs() []APIEndpoint {
+	var apis []APIEndpoint
+	for _, root := range []struct {
+		operation string
+		typeRef   *gqlNamed
+	}{{"query", p.schema.QueryType}, {"mutation", p.schema.MutationType}} {
+		if root.typeRef == nil || p.types[root.typeRef.Name] == nil {
+			continue
+		}
+		for _, field := range p.types[root.typeRef.Name].Fields {
+			apis = append(apis, p.operation(root.operation, field))
+		}
+	}
+	return apis
+}
+
+// operation describes a query or mutation field as an API endpoint
+func (p *GraphQLParser) operation(operation string, field gqlField) APIEndpoint {
+	body := Schema{Type: "object", Properties: map[string]Schema{}}
+	for _, arg := range field.Args {
+		body.Properties[arg.Name] = p.inputSchema(arg, 0)
+		body.PropertyOrder = append(body.PropertyOrder, arg.Name)
+		if arg.Type.Kind == "NON_NULL" && arg.DefaultValue == nil {
+			body.Required = append(body.Required, arg.Name)
+		}
+	}
+
+	query, output := p.query(operation, field)
+	return APIEndpoint{
+		Method:      http.MethodPost,
+		OperationID: field.Name,
+		Description: field.Description,
+		Tags:        []string{operation},
+		RequestBody: &RequestBody{
+			Required: len(body.Required) > 0,
+			Content:  map[string]MediaType{"application/json": {Schema: &body}},
+		},
+		Responses: map[string]Response{"200": {
+			Description: "GraphQL response",
+			Content: map[string]MediaType{"application/json": {Schema: &Schema{
+				Type: "object",
+				Properties: map[string]Schema{
+					"data": {Type: "object", Properties: map[string]Schema{field.Name: output}},
+				},
+			}}},
+		}},
+		Deprecated: field.IsDeprecated,
+		Extensions: map[string]interface{}{extensionGraphQLQuery: query},
+	}
+}
+
+// query generates the document of an operation, e.g.
+// "query user($id: ID!) { user(id: $id) { id name } }", and the schema of the selected data
+func (p *GraphQLParser) query(operation string, field gqlField) (string, Schema) {
+	var b strings.Builder
+	b.WriteString(operation + " " + field.Name)
+	var variables, arguments []string
+	for _, arg := range field.Args {
+		variables = append(variables, "$"+arg.Name+": "+arg.Type.String())
+		arguments = append(arguments, arg.Name+": $"+arg.Name)
+	}
+	if len(variables) > 0 {
+		b.WriteString("(" + strings.Join(variables, ", ") + ")")
+	}
+	b.WriteString(" { " + field.Name)
+	if len(arguments) > 0 {
+		b.WriteString("(" + strings.Join(arguments, ", ") + ")")
+	}
+	selection, output := p.selection(field.Type, 0)
+	b.WriteString(selection + " }")
+	return b.String(), output
+}
+
+// selection returns the selection set of a field of the given type, " { a b { c } }" for objects
+// and "" for scalars, along with the schema of the selected value
+func (p *GraphQLParser) selection(ref gqlTypeRef, depth int) (string, Schema) {
+	switch ref.Kind {
+	case "NON_NULL":
+		return p.selection(*ref.OfType, depth)
+	case "LIST":
+		selection, items := p.selection(*ref.OfType, depth)
+		return selection, Schema{Type: "array", Items: &items}
+	}
+
+	t := p.types[ref.Name]
+	if t == nil || (t.Kind != "OBJECT" && t.Kind != "INTERFACE" && t.Kind != "UNION") {
+		return "", p.scalarSchema(ref.Name)
+	}
+	schema := Schema{Type: "object", Description: t.Description, Properties: map[string]Schema{}}
+	var fields []string
+	for _, field := range t.Fields {
+		// Fields with required arguments cannot be selected without values for them
+		if hasRequiredArgs(field) {
+			continue
+		}
+		target := p.types[field.Type.named()]
+		isObject := target != nil && (target.Kind == "OBJECT" || target.Kind == "INTERFACE" || target.Kind == "UNION")
+		if isObject && depth+1 >= maxGraphQLDepth {
+			continue
+		}
+		selection, fieldSchema := p.selection(field.Type, depth+1)
+		if fieldSchema.Description == "" {
+			fieldSchema.Description = field.Description
+		}
+		fields = append(fields, field.Name+selection)
+		schema.Properties[field.Name] = fieldSchema
+		schema.PropertyOrder = append(schema.PropertyOrder, field.Name)
+	}
+	if len(fields) == 0 {
+		// Unions and objects without selectable fields still tell which type was returned
+		fields = []string{"__typename"}
+		schema.Properties["__typename"] = Schema{Type: "string"}
+	}
+	return " { " + strings.Join(fields, " ") + " }", schema
+}
+
+// hasRequiredArgs reports whether a field has a non-null argument without a default value
+func hasRequiredArgs(field gqlField) bool {
+	for _, arg := range field.Args {
+		if arg.Type.Kind == "NON_NULL" && arg.DefaultValue == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// inputSchema converts an argument or input field to a schema
+func (p *GraphQLParser) inputSchema(value gqlInputValue, depth int) Schema {
+	schema := p.inputTypeSchema(value.Type, depth)
+	if value.Description != "" {
+		schema.Description = value.Description
+	}
+	if value.DefaultValue != nil {
+		if defaultValue, err := parseGraphQLLiteral(*value.DefaultValue); err == nil {
+			schema.Default = defaultValue
+		}
+	}
+	return schema
+}
+
+func (p *GraphQLParser) inputTypeSchema(ref gqlTypeRef, depth int) Schema {
+	switch ref.Kind {
+	case "NON_NULL":
+		return p.inputTypeSchema(*ref.OfType, depth)
+	case "LIST":
+		items := p.inputTypeSchema(*ref.OfType, depth)
+		return Schema{Type: "array", Items: &items}
+	}
+
+	t := p.types[ref.Name]
+	if t == nil || t.Kind != "INPUT_OBJECT" {
+		return p.scalarSchema(ref.Name)
+	}
+	schema := Schema{Type: "object", Description: t.Description, Properties: map[string]Schema{}}
+	if depth+1 >= maxGraphQLDepth {
+		// Input objects may be recursive, so deeper levels are left open
+		return schema
+	}
+	for _, field := range t.InputFields {
+		schema.Properties[field.Name] = p.inputSchema(field, depth+1)
+		schema.PropertyOrder = append(schema.PropertyOrder, field.Name)
+		if field.Type.Kind == "NON_NULL" && field.DefaultValue == nil {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+	return schema
+}
+
+// scalarSchema returns the schema of a scalar or enum type. Custom scalars accept any value.
+func (p *GraphQLParser) scalarSchema(name string) Schema {
+	switch name {
+	case "Int":
+		return Schema{Type: "integer"}
+	case "Float":
+		return Schema{Type: "number"}
+	case "String", "ID":
+		return Schema{Type: "string"}
+	case "Boolean":
+		return Schema{Type: "boolean"}
+	}
+	schema := Schema{}
+	if t := p.types[name]; t != nil {
+		schema.Description = t.Description
+		if t.Kind == "ENUM" {
+			schema.Type = "string"
+			for _, value := range t.EnumValues {
+				schema.Enum = append(schema.Enum, value.Name)
+			}
+		}
+	}
+	return schema
+}
+
+// graphQLQueryFor returns the GraphQL document of an operation created by GraphQLParser, or "" for other operations
+func graphQLQueryFor(api APIEndpoint) string {
+	query, _ := api.Extensions[extensionGraphQLQuery].(string)
+	return query
+}
+
+// encodeGraphQLBody encodes a GraphQL request with the body arguments as its variables
+func (c *adapterConfig) encodeGraphQLBody(query string, variables map[string]interface{}) (*requestBody, error) {
+	if variables == nil {
+		variables = map[string]interface{}{}
+	}
+	body, err := c.marshalBody(map[string]interface{}{"query": query, "variables": variables}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newBytesBody(body, "application/json"), nil
+}
+
+// graphQLErrors returns the error messages of a GraphQL response that has no data, or "" if it has data.
+// Responses with both data and errors are partial results and returned as they are.
+func graphQLErrors(body []byte) string {
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Errors) == 0 {
+		return ""
+	}
+	if len(response.Data) > 0 && !bytes.Equal(response.Data, []byte("null")) {
+		return ""
+	}
+	messages := make([]string, len(response.Errors))
+	for i, e := range response.Errors {
+		messages[i] = e.Message
+	}
+	return strings.Join(messages, "; ")
+}
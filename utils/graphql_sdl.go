@@ -0,0 +1,595 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gqlToken is a lexical token of a GraphQL document
+type gqlToken struct {
+	kind  byte   // 'n' name, 's' string, '0' number, 'p' punctuator, 0 end of document
+	value string // Name, punctuator, number or unquoted string
+	start int    // Offset of the token in the document
+	end   int
+}
+
+// gqlLexer splits a GraphQL document into tokens, skipping whitespace, commas and comments
+type gqlLexer struct {
+	src []byte
+	pos int
+}
+
+func (l *gqlLexer) next() (gqlToken, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return l.token()
+		}
+	}
+	return gqlToken{start: l.pos, end: l.pos}, nil
+}
+
+func (l *gqlLexer) token() (gqlToken, error) {
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case c == '_' || isASCIILetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isASCIILetter(l.src[l.pos]) || isASCIIDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return gqlToken{kind: 'n', value: string(l.src[start:l.pos]), start: start, end: l.pos}, nil
+	case c == '-' || isASCIIDigit(c):
+		l.pos++
+		for l.pos < len(l.src) && (isASCIIDigit(l.src[l.pos]) || strings.IndexByte(".eE+-", l.src[l.pos]) >= 0) {
+			l.pos++
+		}
+		return gqlToken{kind: '0', value: string(l.src[start:l.pos]), start: start, end: l.pos}, nil
+	case c == '"':
+		return l.string()
+	case c == '.':
+		if !strings.HasPrefix(string(l.src[l.pos:]), "...") {
+			return gqlToken{}, fmt.Errorf("unexpected . at offset %d", l.pos)
+		}
+		l.pos += 3
+		return gqlToken{kind: 'p', value: "...", start: start, end: l.pos}, nil
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.pos++
+		return gqlToken{kind: 'p', value: string(c), start: start, end: l.pos}, nil
+	}
+	return gqlToken{}, fmt.Errorf("unexpected character %q at offset %d", c, l.pos)
+}
+
+// string reads a quoted or block string
+func (l *gqlLexer) string() (gqlToken, error) {
+	start := l.pos
+	if strings.HasPrefix(string(l.src[l.pos:]), `"""`) {
+		// The block string ends at the first """ that is not escaped as \"""
+		end := l.pos + 3
+		for {
+			i := strings.Index(string(l.src[end:]), `"""`)
+			if i < 0 {
+				return gqlToken{}, fmt.Errorf("unterminated block string at offset %d", start)
+			}
+			end += i
+			if l.src[end-1] != '\\' {
+				break
+			}
+			end += 3
+		}
+		raw := string(l.src[l.pos+3 : end])
+		l.pos = end + 3
+		return gqlToken{kind: 's', value: blockStringValue(strings.ReplaceAll(raw, `\"""`, `"""`)), start: start, end: l.pos}, nil
+	}
+
+	l.pos++
+	for l.pos < len(l.src) && l.src[l.pos] != '"' && l.src[l.pos] != '\n' {
+		if l.src[l.pos] == '\\' {
+			l.pos++
+		}
+		l.pos++
+	}
+	if l.pos >= len(l.src) || l.src[l.pos] != '"' {
+		return gqlToken{}, fmt.Errorf("unterminated string at offset %d", start)
+	}
+	l.pos++
+	value, err := strconv.Unquote(string(l.src[start:l.pos]))
+	if err != nil {
+		return gqlToken{}, fmt.Errorf("invalid string at offset %d: %w", start, err)
+	}
+	return gqlToken{kind: 's', value: value, start: start, end: l.pos}, nil
+}
+
+// blockStringValue removes the common indentation and the blank first and last lines of a block string
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = ""
+		}
+	}
+	return strings.Trim(strings.Join(lines, "\n"), " \t\n")
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// sdlParser builds a gqlSchema from a schema definition language document
+type sdlParser struct {
+	lexer  gqlLexer
+	token  gqlToken
+	schema *gqlSchema
+	types  map[string]*gqlType
+	err    error // Lexical error, reported by the next syntax error it causes
+}
+
+// parseSDL parses the type system definitions of a GraphQL SDL document. Directive definitions
+// are skipped, and extensions are merged into the types they extend.
+func parseSDL(data []byte) (*gqlSchema, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	p := &sdlParser{lexer: gqlLexer{src: data}, schema: &gqlSchema{}, types: map[string]*gqlType{}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var order []string
+	for p.token.kind != 0 {
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+		extend := p.accept("extend")
+		keyword, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if keyword == "schema" {
+			if err := p.schemaDefinition(description); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if keyword == "directive" {
+			if err := p.skipDirectiveDefinition(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		kind, ok := map[string]string{
+			"scalar": "SCALAR", "type": "OBJECT", "interface": "INTERFACE",
+			"union": "UNION", "enum": "ENUM", "input": "INPUT_OBJECT",
+		}[keyword]
+		if !ok {
+			return nil, fmt.Errorf("unexpected %q at offset %d: only type system definitions are supported", keyword, p.token.start)
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t := p.types[name]
+		if t == nil {
+			t = &gqlType{Kind: kind, Name: name}
+			p.types[name] = t
+			order = append(order, name)
+		}
+		if !extend && description != "" {
+			t.Description = description
+		}
+		if err := p.typeBody(t); err != nil {
+			return nil, fmt.Errorf("type %s: %w", name, err)
+		}
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	for _, name := range order {
+		p.schema.Types = append(p.schema.Types, *p.types[name])
+	}
+	// Without a schema definition the root types are found by their conventional names
+	if p.schema.QueryType == nil && p.types["Query"] != nil {
+		p.schema.QueryType = &gqlNamed{Name: "Query"}
+	}
+	if p.schema.MutationType == nil && p.types["Mutation"] != nil {
+		p.schema.MutationType = &gqlNamed{Name: "Mutation"}
+	}
+	return p.schema, nil
+}
+
+func (p *sdlParser) advance() error {
+	token, err := p.lexer.next()
+	p.token = token
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	return err
+}
+
+// accept consumes the token if it is the given name or punctuator
+func (p *sdlParser) accept(value string) bool {
+	if (p.token.kind == 'n' || p.token.kind == 'p') && p.token.value == value {
+		return p.advance() == nil
+	}
+	return false
+}
+
+// expect consumes the given punctuator or fails
+func (p *sdlParser) expect(value string) error {
+	if p.token.kind != 'p' || p.token.value != value {
+		return p.unexpected("expected " + value)
+	}
+	return p.advance()
+}
+
+func (p *sdlParser) name() (string, error) {
+	if p.token.kind != 'n' {
+		return "", p.unexpected("expected a name")
+	}
+	name := p.token.value
+	return name, p.advance()
+}
+
+func (p *sdlParser) unexpected(message string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.token.kind == 0 {
+		return fmt.Errorf("%s, got the end of the document", message)
+	}
+	return fmt.Errorf("%s at offset %d, got %q", message, p.token.start, p.token.value)
+}
+
+// description consumes an optional description string
+func (p *sdlParser) description() (string, error) {
+	if p.token.kind != 's' {
+		return "", nil
+	}
+	description := p.token.value
+	return description, p.advance()
+}
+
+func (p *sdlParser) schemaDefinition(description string) error {
+	if description != "" {
+		p.schema.Description = description
+	}
+	if _, err := p.directives(); err != nil {
+		return err
+	}
+	if !p.accept("{") {
+		return nil
+	}
+	for !p.accept("}") {
+		operation, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		typeName, err := p.name()
+		if err != nil {
+			return err
+		}
+		switch operation {
+		case "query":
+			p.schema.QueryType = &gqlNamed{Name: typeName}
+		case "mutation":
+			p.schema.MutationType = &gqlNamed{Name: typeName}
+		}
+	}
+	return nil
+}
+
+// skipDirectiveDefinition consumes "@name(args) repeatable on LOCATION | LOCATION"
+func (p *sdlParser) skipDirectiveDefinition() error {
+	if err := p.expect("@"); err != nil {
+		return err
+	}
+	if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.token.kind == 'p' && p.token.value == "(" {
+		if _, err := p.inputValues("(", ")"); err != nil {
+			return err
+		}
+	}
+	p.accept("repeatable")
+	if !p.accept("on") {
+		return p.unexpected("expected on")
+	}
+	p.accept("|")
+	for {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if !p.accept("|") {
+			return nil
+		}
+	}
+}
+
+// typeBody parses what follows the name of a type definition
+func (p *sdlParser) typeBody(t *gqlType) error {
+	if p.accept("implements") {
+		p.accept("&")
+		for p.token.kind == 'n' {
+			p.advance()
+			if !p.accept("&") {
+				break
+			}
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return err
+	}
+
+	switch t.Kind {
+	case "OBJECT", "INTERFACE":
+		if !p.accept("{") {
+			return nil
+		}
+		for !p.accept("}") {
+			field, err := p.field()
+			if err != nil {
+				return err
+			}
+			t.Fields = append(t.Fields, field)
+		}
+	case "INPUT_OBJECT":
+		if p.token.kind != 'p' || p.token.value != "{" {
+			return nil
+		}
+		values, err := p.inputValues("{", "}")
+		if err != nil {
+			return err
+		}
+		t.InputFields = append(t.InputFields, values...)
+	case "ENUM":
+		if !p.accept("{") {
+			return nil
+		}
+		for !p.accept("}") {
+			if _, err := p.description(); err != nil {
+				return err
+			}
+			value, err := p.name()
+			if err != nil {
+				return err
+			}
+			if _, err := p.directives(); err != nil {
+				return err
+			}
+			t.EnumValues = append(t.EnumValues, gqlNamed{Name: value})
+		}
+	case "UNION":
+		if !p.accept("=") {
+			return nil
+		}
+		p.accept("|")
+		for {
+			member, err := p.name()
+			if err != nil {
+				return err
+			}
+			t.PossibleTypes = append(t.PossibleTypes, gqlNamed{Name: member})
+			if !p.accept("|") {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (p *sdlParser) field() (gqlField, error) {
+	description, err := p.description()
+	if err != nil {
+		return gqlField{}, err
+	}
+	field := gqlField{Description: description}
+	if field.Name, err = p.name(); err != nil {
+		return field, err
+	}
+	if p.token.kind == 'p' && p.token.value == "(" {
+		if field.Args, err = p.inputValues("(", ")"); err != nil {
+			return field, err
+		}
+	}
+	if err := p.expect(":"); err != nil {
+		return field, err
+	}
+	if field.Type, err = p.typeRef(); err != nil {
+		return field, err
+	}
+	directives, err := p.directives()
+	field.IsDeprecated = containsString(directives, "deprecated")
+	return field, err
+}
+
+// inputValues parses argument or input field definitions enclosed by open and close
+func (p *sdlParser) inputValues(open, close string) ([]gqlInputValue, error) {
+	if err := p.expect(open); err != nil {
+		return nil, err
+	}
+	var values []gqlInputValue
+	for !p.accept(close) {
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+		value := gqlInputValue{Description: description}
+		if value.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if value.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if p.accept("=") {
+			start := p.token.start
+			if err := p.skipValue(); err != nil {
+				return nil, err
+			}
+			literal := strings.TrimSpace(string(p.lexer.src[start:p.token.start]))
+			value.DefaultValue = &literal
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// typeRef parses a type reference such as [String!]!
+func (p *sdlParser) typeRef() (gqlTypeRef, error) {
+	var ref gqlTypeRef
+	if p.accept("[") {
+		item, err := p.typeRef()
+		if err != nil {
+			return ref, err
+		}
+		if err := p.expect("]"); err != nil {
+			return ref, err
+		}
+		ref = gqlTypeRef{Kind: "LIST", OfType: &item}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return ref, err
+		}
+		ref = gqlTypeRef{Name: name}
+	}
+	if p.accept("!") {
+		inner := ref
+		ref = gqlTypeRef{Kind: "NON_NULL", OfType: &inner}
+	}
+	return ref, nil
+}
+
+// directives consumes directive applications, returning their names
+func (p *sdlParser) directives() ([]string, error) {
+	var names []string
+	for p.accept("@") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if p.accept("(") {
+			for !p.accept(")") {
+				if _, err := p.name(); err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if err := p.skipValue(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// skipValue consumes a value literal
+func (p *sdlParser) skipValue() error {
+	_, err := p.value()
+	return err
+}
+
+// value parses a constant value literal. Enum values are returned as strings.
+func (p *sdlParser) value() (interface{}, error) {
+	token := p.token
+	switch {
+	case token.kind == 's':
+		return token.value, p.advance()
+	case token.kind == '0':
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", token.value, token.start)
+		}
+		return number, p.advance()
+	case token.kind == 'n':
+		var value interface{} = token.value
+		switch token.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		}
+		return value, p.advance()
+	case p.accept("$"):
+		_, err := p.name()
+		return nil, err
+	case p.accept("["):
+		list := []interface{}{}
+		for !p.accept("]") {
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case p.accept("{"):
+		object := map[string]interface{}{}
+		for !p.accept("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	}
+	return nil, p.unexpected("expected a value")
+}
+
+// parseGraphQLLiteral parses a constant value literal such as a default value
+func parseGraphQLLiteral(literal string) (interface{}, error) {
+	p := &sdlParser{lexer: gqlLexer{src: []byte(literal)}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != 0 {
+		return nil, p.unexpected("expected the end of the value")
+	}
+	return value, nil
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const testGraphQLSchema = `
+"""The pet store"""
+schema { query: Root mutation: Mutation }
+
+type Root {
+  "Find a pet by ID"
+  pet(id: ID!): Pet
+  pets(status: Status = AVAILABLE, first: Int = 10): [Pet!]! @deprecated(reason: "use search")
+}
+
+type Mutation {
+  addPet(input: PetInput!): Pet
+}
+
+# Pets have owners, who have pets in turn
+type Pet implements Node & Named {
+  id: ID!
+  name: String
+  owner: Owner
+  photo(size: Int!): String
+}
+
+type Owner { name: String pets: [Pet] }
+
+input PetInput { name: String! tags: [String!] status: Status }
+
+enum Status { AVAILABLE SOLD }
+`
+
+func Test_GraphQLParser(t *testing.T) {
+	parser, err := ParseGraphQL([]byte(testGraphQLSchema), "https://api.example.com/graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := parser.Info(); info.Description != "The pet store" {
+		t.Errorf("Got info %+v", info)
+	}
+
+	apis := parser.APIs()
+	if len(apis) != 3 {
+		t.Fatalf("Got %d operations; want 3", len(apis))
+	}
+	pet, pets, addPet := apis[0], apis[1], apis[2]
+	want := "query pet($id: ID!) { pet(id: $id) { id name owner { name pets { id name } } } }"
+	if got := graphQLQueryFor(pet); got != want {
+		t.Errorf("Got query %q; want %q", got, want)
+	}
+	if got := graphQLQueryFor(addPet); got != "mutation addPet($input: PetInput!) { addPet(input: $input) { id name owner { name pets { id name } } } }" {
+		t.Errorf("Got mutation %q", got)
+	}
+	if !pets.Deprecated || pets.RequestBody.Required {
+		t.Errorf("Got pets operation %+v", pets)
+	}
+	status := pets.RequestBody.Content["application/json"].Schema.Properties["status"]
+	if status.Default != "AVAILABLE" || len(status.Enum) != 2 {
+		t.Errorf("Got status argument %+v", status)
+	}
+	input := addPet.RequestBody.Content["application/json"].Schema.Properties["input"]
+	if input.Type != "object" || len(input.Required) != 1 || input.Properties["tags"].Items.Type != "string" {
+		t.Errorf("Got input argument %+v", input)
+	}
+}
+
+func Test_GraphQLIntrospection(t *testing.T) {
+	introspection := `{"data": {"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [{"name": "hello", "args": [
+			{"name": "name", "type": {"kind": "SCALAR", "name": "String"}, "defaultValue": "\"world\""}],
+			"type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}]}]}}}`
+	parser, err := ParseGraphQL([]byte(introspection), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apis := parser.APIs()
+	if len(apis) != 1 || graphQLQueryFor(apis[0]) != "query hello($name: String) { hello(name: $name) }" {
+		t.Fatalf("Got operations %+v", apis)
+	}
+	if got := apis[0].RequestBody.Content["application/json"].Schema.Properties["name"].Default; got != "world" {
+		t.Errorf("Got default %v", got)
+	}
+}
+
+func Test_GraphQLToolCall(t *testing.T) {
+	var received struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		if received.Variables["id"] == "missing" {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "pet not found"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"pet": {"id": "7", "name": "Rex"}}}`))
+	}))
+	defer ts.Close()
+
+	parser, err := ParseGraphQL([]byte(testGraphQLSchema), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := buildTools(newAdapterConfig(), server.NewMCPServer("t", "1"), "t", "", nil, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(id string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"requestBody": map[string]interface{}{"id": id}}
+		result, err := tools[0].Handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := call("7")
+	if result.IsError || resultText(t, result) != `{"data": {"pet": {"id": "7", "name": "Rex"}}}` {
+		t.Errorf("Got result %+v", result)
+	}
+	if received.Query != graphQLQueryFor(parser.APIs()[0]) || received.Variables["id"] != "7" {
+		t.Errorf("Got request %+v", received)
+	}
+	if result := call("missing"); !result.IsError {
+		t.Errorf("Got result %s; want an error", resultText(t, result))
+	}
+}
@@ -0,0 +1,429 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// defaultGRPCTitle is the API title of gRPC services, which have no title of their own
+const defaultGRPCTitle = "gRPC API"
+
+// grpcContentType is the media type of gRPC requests and responses
+const grpcContentType = "application/grpc"
+
+// grpcStatusNames names the gRPC status codes
+var grpcStatusNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND", "ALREADY_EXISTS",
+	"PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE",
+	"UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+// grpcUnimplemented is the status of calls to methods the server does not implement
+const grpcUnimplemented = 12
+
+// GRPCParser exposes the methods of gRPC services as operations, so that they can be served as tools
+// like OpenAPI operations. Each method is a POST to /package.Service/Method whose request body
+// properties are the fields of the request message. The handler encodes them as protobuf and converts
+// the response message back to JSON, following the protobuf JSON mapping. Streaming methods are
+// listed too, but calling them fails, since only unary calls are supported.
+type GRPCParser struct {
+	services []protoreflect.ServiceDescriptor
+	endpoint string
+}
+
+// ParseGRPCDescriptors creates a parser for the services declared by serialized
+// google.protobuf.FileDescriptorProto messages, such as those returned by server reflection,
+// for the server at endpoint. The files imported by the given files must be included.
+func ParseGRPCDescriptors(files [][]byte, endpoint string) (*GRPCParser, error) {
+	decoded := make([]*descriptorpb.FileDescriptorProto, 0, len(files))
+	for _, data := range files {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, file); err != nil {
+			return nil, fmt.Errorf("invalid file descriptor: %w", err)
+		}
+		decoded = append(decoded, file)
+	}
+	return newGRPCParser(decoded, endpoint, nil)
+}
+
+// newGRPCParser creates a parser for the services of the decoded files. If served is not nil,
+// only the services it lists are included.
+func newGRPCParser(files []*descriptorpb.FileDescriptorProto, endpoint string, served map[string]bool) (*GRPCParser, error) {
+	registry, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: files})
+	if err != nil {
+		return nil, fmt.Errorf("invalid file descriptors: %w", err)
+	}
+	p := &GRPCParser{endpoint: endpoint}
+	registry.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			if served == nil || served[string(services.Get(i).FullName())] {
+				p.services = append(p.services, services.Get(i))
+			}
+		}
+		return true
+	})
+	sort.Slice(p.services, func(i, j int) bool { return p.services[i].FullName() < p.services[j].FullName() })
+	return p, nil
+}
+
+// Servers returns the gRPC endpoint
+func (p *GRPCParser) Servers() []Server {
+	if p.endpoint == "" {
+		return nil
+	}
+	return []Server{{URL: p.endpoint}}
+}
+
+// Info lists the services in the description
+func (p *GRPCParser) Info() APIInfo {
+	names := make([]string, len(p.services))
+	for i, service := range p.services {
+		names[i] = string(service.FullName())
+	}
+	return APIInfo{Title: defaultGRPCTitle, Description: "Services: " + strings.Join(names, ", ")}
+}
+
+// APIs returns one operation per method of the services
+func (p *GRPCParser) APIs() []APIEndpoint {
+	var apis []APIEndpoint
+	for _, service := range p.services {
+		methods := service.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			apis = append(apis, p.operation(service, methods.Get(i)))
+		}
+	}
+	return apis
+}
+
+// operation describes a method as an API endpoint
+func (p *GRPCParser) operation(service protoreflect.ServiceDescriptor, method protoreflect.MethodDescriptor) APIEndpoint {
+	rpc := newGRPCMethod(method)
+	description := "Calls the " + rpc.name + " RPC"
+	if kind := rpc.streamingKind(); kind != "" {
+		description = "Calls the " + rpc.name + " " + kind + " RPC, which is not supported yet"
+	}
+
+	body := messageSchema(method.Input(), map[protoreflect.FullName]bool{})
+	output := messageSchema(method.Output(), map[protoreflect.FullName]bool{})
+	return APIEndpoint{
+		Method:      http.MethodPost,
+		Path:        "/" + rpc.name,
+		OperationID: string(method.Name()),
+		Description: description,
+		Tags:        []string{string(service.FullName())},
+		RequestBody: &RequestBody{
+			Required: len(body.Required) > 0,
+			Content:  map[string]MediaType{"application/json": {Schema: &body}},
+		},
+		Responses: map[string]Response{"200": {
+			Description: "gRPC response",
+			Content:     map[string]MediaType{"application/json": {Schema: &output}},
+		}},
+		Extensions: map[string]interface{}{extensionGRPCMethod: rpc},
+	}
+}
+
+// messageSchema converts a message type to a schema matching its JSON mapping. Messages already
+// being converted further up, that is recursive ones, are left open.
+func messageSchema(message protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) Schema {
+	name := message.FullName()
+	if name.Parent() == "google.protobuf" {
+		switch name.Name() {
+		case "Timestamp":
+			return Schema{Type: "string", Format: "date-time"}
+		case "Duration":
+			return Schema{Type: "string", Description: `Duration in seconds, e.g. "1.5s"`}
+		case "FieldMask":
+			return Schema{Type: "string", Description: "Comma-separated field paths"}
+		case "Struct":
+			return Schema{Type: "object"}
+		case "ListValue":
+			return Schema{Type: "array"}
+		case "Value":
+			return Schema{}
+		case "DoubleValue", "FloatValue", "Int64Value", "UInt64Value", "Int32Value", "UInt32Value",
+			"BoolValue", "StringValue", "BytesValue":
+			schema := fieldSchema(message.Fields().ByName("value"), visiting)
+			schema.Nullable = true
+			return schema
+		}
+	}
+
+	schema := Schema{Type: "object", Properties: map[string]Schema{}}
+	if visiting[name] {
+		return schema
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		jsonName := field.JSONName()
+		schema.Properties[jsonName] = fieldSchema(field, visiting)
+		schema.PropertyOrder = append(schema.PropertyOrder, jsonName)
+		if field.Cardinality() == protoreflect.Required {
+			schema.Required = append(schema.Required, jsonName)
+		}
+	}
+	return schema
+}
+
+// fieldSchema converts a field to a schema. 64-bit integers are strings, as in the JSON mapping.
+func fieldSchema(field protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool) Schema {
+	if field.IsMap() {
+		description := fmt.Sprintf("Map of %s keys to %s values", protoTypeName(field.MapKey()), protoTypeName(field.MapValue()))
+		return Schema{Type: "object", Description: description}
+	}
+	if field.IsList() {
+		items := singularFieldSchema(field, visiting)
+		return Schema{Type: "array", Items: &items}
+	}
+	return singularFieldSchema(field, visiting)
+}
+
+// singularFieldSchema converts a single value of a field to a schema
+func singularFieldSchema(field protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool) Schema {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSchema(field.Message(), visiting)
+	case protoreflect.EnumKind:
+		schema := Schema{Type: "string"}
+		values := field.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+		return schema
+	case protoreflect.StringKind:
+		return Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return Schema{Type: "string", Format: "byte"}
+	case protoreflect.BoolKind:
+		return Schema{Type: "boolean"}
+	case protoreflect.DoubleKind:
+		return Schema{Type: "number", Format: "double"}
+	case protoreflect.FloatKind:
+		return Schema{Type: "number", Format: "float"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		minimum := float64(0)
+		return Schema{Type: "integer", Minimum: &minimum}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return Schema{Type: "string", Format: "uint64"}
+	}
+	return Schema{}
+}
+
+// protoTypeName returns the type of a field as written in a .proto file, e.g. "int64" or "pets.v1.Pet"
+func protoTypeName(field protoreflect.FieldDescriptor) string {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(field.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(field.Enum().FullName())
+	}
+	return field.Kind().String()
+}
+
+// grpcMethod is the RPC called by an operation created by GRPCParser
+type grpcMethod struct {
+	name            string // Full method name, e.g. "pets.v1.PetService/GetPet"
+	input           protoreflect.MessageDescriptor
+	output          protoreflect.MessageDescriptor
+	clientStreaming bool
+	serverStreaming bool
+}
+
+// newGRPCMethod describes the RPC of a method descriptor
+func newGRPCMethod(method protoreflect.MethodDescriptor) *grpcMethod {
+	return &grpcMethod{
+		name:            string(method.Parent().FullName()) + "/" + string(method.Name()),
+		input:           method.Input(),
+		output:          method.Output(),
+		clientStreaming: method.IsStreamingClient(),
+		serverStreaming: method.IsStreamingServer(),
+	}
+}
+
+// grpcMethodFor returns the RPC of an operation created by GRPCParser, or nil for other operations
+func grpcMethodFor(api APIEndpoint) *grpcMethod {
+	method, _ := api.Extensions[extensionGRPCMethod].(*grpcMethod)
+	return method
+}
+
+// streamingKind describes how the method streams, or returns "" for unary methods
+func (m *grpcMethod) streamingKind() string {
+	switch {
+	case m.clientStreaming && m.serverStreaming:
+		return "bidirectional streaming"
+	case m.clientStreaming:
+		return "client streaming"
+	case m.serverStreaming:
+		return "server streaming"
+	}
+	return ""
+}
+
+// checkUnary returns an error for streaming methods, which cannot be called yet
+func (m *grpcMethod) checkUnary() error {
+	if kind := m.streamingKind(); kind != "" {
+		return fmt.Errorf("%s is a %s RPC; only unary RPCs are supported", m.name, kind)
+	}
+	return nil
+}
+
+// encodeRequest encodes the body arguments as the request message, framed for gRPC
+func (m *grpcMethod) encodeRequest(args map[string]interface{}) (*requestBody, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	message, err := protoFromJSON(m.input, data)
+	if err != nil {
+		return nil, err
+	}
+	return newBytesBody(frameGRPCMessage(message), grpcContentType), nil
+}
+
+// decodeResponse checks the status of a gRPC response and converts its message to JSON
+func (m *grpcMethod) decodeResponse(resp *http.Response, body []byte) ([]byte, error) {
+	if err := grpcStatusError(resp); err != nil {
+		return nil, err
+	}
+	message, err := readGRPCMessage(body)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := protoToJSON(m.output, message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response message: %w", err)
+	}
+	return converted, nil
+}
+
+// protoFromJSON encodes a message of the given type from its JSON mapping
+func protoFromJSON(descriptor protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+	message := dynamicpb.NewMessage(descriptor)
+	if err := protojson.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
+
+// protoToJSON converts an encoded message of the given type to its JSON mapping
+func protoToJSON(descriptor protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+	message := dynamicpb.NewMessage(descriptor)
+	if err := proto.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	converted, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	// protojson randomly varies its whitespace so that its output is not relied upon
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, converted); err != nil {
+		return nil, err
+	}
+	return compact.Bytes(), nil
+}
+
+// isGRPCResponse reports whether the response was sent by a gRPC server
+func isGRPCResponse(resp *http.Response) bool {
+	contentType := resp.Header.Get("Content-Type")
+	return contentType == grpcContentType || strings.HasPrefix(contentType, grpcContentType+"+") ||
+		strings.HasPrefix(contentType, grpcContentType+";")
+}
+
+// withJSONContentType returns a copy of a gRPC response whose body has been converted to JSON
+func withJSONContentType(resp *http.Response) *http.Response {
+	converted := *resp
+	converted.Header = resp.Header.Clone()
+	converted.Header.Set("Content-Type", "application/json")
+	return &converted
+}
+
+// frameGRPCMessage prefixes a message with the gRPC frame header: an uncompressed flag and its length
+func frameGRPCMessage(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// readGRPCMessage returns the first message of a gRPC response body
+func readGRPCMessage(body []byte) ([]byte, error) {
+	if len(body) < 5 {
+		return nil, errors.New("gRPC response has no message")
+	}
+	if body[0] != 0 {
+		return nil, errors.New("compressed gRPC messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(body[1:5])
+	if uint64(len(body)-5) < uint64(length) {
+		return nil, errors.New("gRPC response message is truncated")
+	}
+	return body[5 : 5+length], nil
+}
+
+// grpcError is a call that failed with a gRPC status other than OK
+type grpcError struct {
+	code    int
+	message string
+}
+
+func (e *grpcError) Error() string {
+	name := "code " + strconv.Itoa(e.code)
+	if e.code >= 0 && e.code < len(grpcStatusNames) {
+		name = grpcStatusNames[e.code]
+	}
+	if e.message == "" {
+		return "gRPC status " + name
+	}
+	return "gRPC status " + name + ": " + e.message
+}
+
+// grpcStatusError returns the status of a response whose body has been read, as an error unless it is OK.
+// Servers send it in the trailers, or in the headers of responses without a message.
+func grpcStatusError(resp *http.Response) error {
+	status, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if status == "" {
+		return errors.New("gRPC response has no status")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return fmt.Errorf("invalid gRPC status %q", status)
+	}
+	if code == 0 {
+		return nil
+	}
+	// The message is percent-encoded
+	if unescaped, err := neturl.PathUnescape(message); err == nil {
+		message = unescaped
+	}
+	return &grpcError{code: code, message: message}
+}
@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// grpcReflectionMethods are the methods of the server reflection service, in order of preference
+var grpcReflectionMethods = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+// NewMCPFromGRPCReflection creates an MCP server exposing one tool per method of the gRPC services
+// served at endpoint, such as "https://grpc.example.com", which are discovered with server reflection.
+// Calls are converted from JSON to protobuf and back, see GRPCParser.
+//
+// gRPC runs over HTTP/2, which the default HTTP client only speaks over TLS. For servers accepting
+// plaintext connections, pass a client whose transport speaks HTTP/2 without TLS with WithHTTPClient.
+// Reflection requests send extraHeaders and the credentials set with WithSpecAuth.
+func NewMCPFromGRPCReflection(ctx context.Context, endpoint string, extraHeaders map[string]string, opts ...AdapterOption) (*server.MCPServer, error) {
+	cfg := newAdapterConfig(opts...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	endpoint, headers := cfg.upstream(endpoint, extraHeaders)
+	headers, err := cfg.expandHeaderEnv(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+	client := &grpcReflectionClient{cfg: cfg, endpoint: strings.TrimSuffix(endpoint, "/"), headers: headers}
+	parser, err := client.parser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gRPC server reflection failed: %w", err)
+	}
+	return newMCPFromParser(cfg, endpoint, extraHeaders, parser)
+}
+
+// grpcReflectionClient lists the services of a gRPC server and fetches their file descriptors.
+// Every request is sent on a stream of its own.
+type grpcReflectionClient struct {
+	cfg      *adapterConfig
+	endpoint string
+	headers  map[string]string
+	method   string // Reflection method the server implements, found by the first request
+}
+
+// parser creates a parser for the services of the server, except the reflection service itself
+func (c *grpcReflectionClient) parser(ctx context.Context) (*GRPCParser, error) {
+	response, err := c.request(ctx, reflectionRequest{ListServices: "*"})
+	if err != nil {
+		return nil, err
+	}
+	if response.ListServicesResponse == nil {
+		return nil, errors.New("server did not list its services")
+	}
+
+	files := map[string]bool{}
+	var ordered []*descriptorpb.FileDescriptorProto
+	add := func(response *reflectionResponse) error {
+		if response.FileDescriptorResponse == nil {
+			return errors.New("server did not return file descriptors")
+		}
+		for _, data := range response.FileDescriptorResponse.FileDescriptorProto {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(data, file); err != nil {
+				return fmt.Errorf("invalid file descriptor: %w", err)
+			}
+			if !files[file.GetName()] {
+				files[file.GetName()] = true
+				ordered = append(ordered, file)
+			}
+		}
+		return nil
+	}
+
+	listed := map[string]bool{}
+	for _, service := range response.ListServicesResponse.Service {
+		if strings.HasPrefix(service.Name, "grpc.reflection.") {
+			continue
+		}
+		listed[service.Name] = true
+		response, err := c.request(ctx, reflectionRequest{FileContainingSymbol: service.Name})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", service.Name, err)
+		}
+		if err := add(response); err != nil {
+			return nil, fmt.Errorf("%s: %w", service.Name, err)
+		}
+	}
+
+	// Servers may return only the file declaring the symbol, leaving its imports to be requested
+	for i := 0; i < len(ordered); i++ {
+		for _, dependency := range ordered[i].GetDependency() {
+			if files[dependency] {
+				continue
+			}
+			response, err := c.request(ctx, reflectionRequest{FileByFilename: dependency})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", dependency, err)
+			}
+			if err := add(response); err != nil {
+				return nil, fmt.Errorf("%s: %w", dependency, err)
+			}
+		}
+	}
+
+	// Imported files may declare services the server does not serve
+	return newGRPCParser(ordered, c.endpoint, listed)
+}
+
+// request sends a ServerReflectionRequest and returns the ServerReflectionResponse. The methods of
+// the reflection service are tried in order until one is implemented.
+func (c *grpcReflectionClient) request(ctx context.Context, request reflectionRequest) (*reflectionResponse, error) {
+	if c.method != "" {
+		return c.call(ctx, c.method, request)
+	}
+	var err error
+	for _, method := range grpcReflectionMethods {
+		var response *reflectionResponse
+		response, err = c.call(ctx, method, request)
+		var status *grpcError
+		if errors.As(err, &status) && status.code == grpcUnimplemented {
+			continue
+		}
+		if err == nil {
+			c.method = method
+		}
+		return response, err
+	}
+	return nil, err
+}
+
+// call sends a request to a reflection method and returns the first message of the response
+func (c *grpcReflectionClient) call(ctx context.Context, method string, request reflectionRequest) (*reflectionResponse, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	message, err := protoFromJSON(reflectionFile.Messages().ByName("ServerReflectionRequest"), data)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+method, bytes.NewReader(frameGRPCMessage(message)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", grpcContentType)
+	req.Header.Set("TE", "trailers")
+	if err := c.cfg.specAuth.apply(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate reflection request: %w", err)
+	}
+
+	resp, err := c.cfg.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSuccessStatus(resp.StatusCode) || !isGRPCResponse(resp) {
+		if resp.ProtoMajor != 2 {
+			return nil, fmt.Errorf("unexpected %s response %s; gRPC requires HTTP/2", resp.Proto, resp.Status)
+		}
+		return nil, fmt.Errorf("unexpected response HTTP %s", resp.Status)
+	}
+	if err := grpcStatusError(resp); err != nil {
+		return nil, err
+	}
+	message, err = readGRPCMessage(body)
+	if err != nil {
+		return nil, err
+	}
+	data, err = protoToJSON(reflectionFile.Messages().ByName("ServerReflectionResponse"), message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reflection response: %w", err)
+	}
+	var response reflectionResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid reflection response: %w", err)
+	}
+	if response.ErrorResponse != nil {
+		return nil, &grpcError{code: response.ErrorResponse.ErrorCode, message: response.ErrorResponse.ErrorMessage}
+	}
+	return &response, nil
+}
+
+// reflectionRequest is the JSON mapping of the ServerReflectionRequest fields sent by the client
+type reflectionRequest struct {
+	FileByFilename       string `json:"fileByFilename,omitempty"`
+	FileContainingSymbol string `json:"fileContainingSymbol,omitempty"`
+	ListServices         string `json:"listServices,omitempty"`
+}
+
+// reflectionResponse is the JSON mapping of a ServerReflectionResponse
+type reflectionResponse struct {
+	FileDescriptorResponse *struct {
+		FileDescriptorProto [][]byte `json:"fileDescriptorProto"`
+	} `json:"fileDescriptorResponse"`
+	ListServicesResponse *struct {
+		Service []struct {
+			Name string `json:"name"`
+		} `json:"service"`
+	} `json:"listServicesResponse"`
+	ErrorResponse *struct {
+		ErrorCode    int    `json:"errorCode"`
+		ErrorMessage string `json:"errorMessage"`
+	} `json:"errorResponse"`
+}
+
+// reflectionFile declares the messages of the server reflection protocol used by the client, from
+// grpc/reflection/v1/reflection.proto. The v1alpha messages only differ by package.
+var reflectionFile = newReflectionFile()
+
+// newReflectionFile describes the subset of the reflection protocol sent and read by the client
+func newReflectionFile() protoreflect.FileDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(".grpc.reflection.v1." + typeName)
+		}
+		return f
+	}
+	repeated := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return f
+	}
+	inOneof := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.OneofIndex = proto.Int32(0)
+		return f
+	}
+	message := func(name string, oneof string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+		m := &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+		if oneof != "" {
+			m.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String(oneof)}}
+		}
+		return m
+	}
+	const (
+		stringType  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		bytesType   = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+		int32Type   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		messageType = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("grpc/reflection/v1/reflection.proto"),
+		Package: proto.String("grpc.reflection.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			message("ServerReflectionRequest", "message_request",
+				field("host", 1, stringType, ""),
+				inOneof(field("file_by_filename", 3, stringType, "")),
+				inOneof(field("file_containing_symbol", 4, stringType, "")),
+				inOneof(field("list_services", 7, stringType, ""))),
+			message("ServerReflectionResponse", "message_response",
+				field("valid_host", 1, stringType, ""),
+				inOneof(field("file_descriptor_response", 4, messageType, "FileDescriptorResponse")),
+				inOneof(field("list_services_response", 6, messageType, "ListServiceResponse")),
+				inOneof(field("error_response", 7, messageType, "ErrorResponse"))),
+			message("FileDescriptorResponse", "", repeated(field("file_descriptor_proto", 1, bytesType, ""))),
+			message("ListServiceResponse", "", repeated(field("service", 1, messageType, "ServiceResponse"))),
+			message("ServiceResponse", "", field("name", 1, stringType, "")),
+			message("ErrorResponse", "",
+				field("error_code", 1, int32Type, ""),
+				field("error_message", 2, stringType, "")),
+		},
+	}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return file
+}
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func testProtoField(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	field := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Label: label.Enum(), Type: typ.Enum()}
+	if typeName != "" {
+		field.TypeName = proto.String(typeName)
+	}
+	return field
+}
+
+// testPetsDescriptors returns the serialized file descriptors of
+//
+//	package pets.v1;
+//	import "google/protobuf/timestamp.proto";
+//	enum Status { STATUS_UNSPECIFIED = 0; AVAILABLE = 1; SOLD = 2; }
+//	message Pet {
+//	  int64 id = 1; string name = 2; Status status = 3; repeated string tags = 4;
+//	  map<string, int32> scores = 5; google.protobuf.Timestamp born = 6; Pet parent = 7;
+//	  bytes photo = 8; sint32 offset = 9; double weight = 10; repeated int32 ranks = 11;
+//	}
+//	message GetPetRequest { int64 pet_id = 1; }
+//	service PetService { rpc GetPet(GetPetRequest) returns (Pet); rpc WatchPets(GetPetRequest) returns (stream Pet); }
+//
+// and of google/protobuf/timestamp.proto
+func testPetsDescriptors(t *testing.T) (pets, timestamp []byte) {
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("pets/v1/pets.proto"),
+		Package:    proto.String("pets.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Pet"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				testProtoField("id", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				testProtoField("name", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				testProtoField("status", 3, optional, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".pets.v1.Status"),
+				testProtoField("tags", 4, repeated, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				testProtoField("scores", 5, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".pets.v1.Pet.ScoresEntry"),
+				testProtoField("born", 6, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+				testProtoField("parent", 7, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".pets.v1.Pet"),
+				testProtoField("photo", 8, optional, descriptorpb.FieldDescriptorProto_TYPE_BYTES, ""),
+				testProtoField("offset", 9, optional, descriptorpb.FieldDescriptorProto_TYPE_SINT32, ""),
+				testProtoField("weight", 10, optional, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""),
+				testProtoField("ranks", 11, repeated, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("ScoresEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					testProtoField("key", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					testProtoField("value", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}, {
+			Name:  proto.String("GetPetRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{testProtoField("pet_id", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_INT64, "")},
+		}},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("AVAILABLE"), Number: proto.Int32(1)},
+				{Name: proto.String("SOLD"), Number: proto.Int32(2)},
+			},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("PetService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetPet"), InputType: proto.String(".pets.v1.GetPetRequest"), OutputType: proto.String(".pets.v1.Pet")},
+				{Name: proto.String("WatchPets"), InputType: proto.String(".pets.v1.GetPetRequest"), OutputType: proto.String(".pets.v1.Pet"), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}
+
+	pets, err := proto.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp, err = proto.Marshal(protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pets, timestamp
+}
+
+// testGetPet returns the GetPet method of the test descriptors
+func testGetPet(t *testing.T) *grpcMethod {
+	pets, timestamp := testPetsDescriptors(t)
+	parser, err := ParseGRPCDescriptors([][]byte{pets, timestamp}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newGRPCMethod(parser.services[0].Methods().ByName("GetPet"))
+}
+
+// newGRPCTestServer serves pets.v1.PetService over HTTP/2 with v1alpha server reflection only.
+// GetPet answers NOT_FOUND for pet 0.
+func newGRPCTestServer(t *testing.T) *httptest.Server {
+	petsFile, timestampFile := testPetsDescriptors(t)
+	getPet := testGetPet(t)
+	reflectionRequest := reflectionFile.Messages().ByName("ServerReflectionRequest")
+	reflectionResponse := reflectionFile.Messages().ByName("ServerReflectionResponse")
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request, err := readGRPCMessage(body)
+		if err != nil || r.Header.Get("Content-Type") != grpcContentType {
+			http.Error(w, "not a gRPC request", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", grpcContentType)
+		status, message := "0", ""
+		var response []byte
+		switch r.URL.Path {
+		case "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo":
+			decoded, err := protoToJSON(reflectionRequest, request)
+			if err != nil {
+				t.Error(err)
+			}
+			reply := `{"errorResponse": {"errorCode": 5, "errorMessage": "not found"}}`
+			switch string(decoded) {
+			case `{"listServices":"*"}`:
+				reply = `{"listServicesResponse": {"service": [{"name": "pets.v1.PetService"}, {"name": "grpc.reflection.v1alpha.ServerReflection"}]}}`
+			case `{"fileContainingSymbol":"pets.v1.PetService"}`:
+				files, _ := json.Marshal([][]byte{petsFile})
+				reply = `{"fileDescriptorResponse": {"fileDescriptorProto": ` + string(files) + `}}`
+			case `{"fileByFilename":"google/protobuf/timestamp.proto"}`:
+				files, _ := json.Marshal([][]byte{timestampFile})
+				reply = `{"fileDescriptorResponse": {"fileDescriptorProto": ` + string(files) + `}}`
+			}
+			if response, err = protoFromJSON(reflectionResponse, []byte(reply)); err != nil {
+				t.Error(err)
+			}
+		case "/pets.v1.PetService/GetPet":
+			decoded, err := protoToJSON(getPet.input, request)
+			if err != nil {
+				t.Error(err)
+			}
+			if string(decoded) == "{}" {
+				status, message = "5", "pet 0 not found%21"
+				break
+			}
+			response, err = protoFromJSON(getPet.output, []byte(`{"id": "7", "name": "Rex", "status": "AVAILABLE", "tags": ["good"],
+				"scores": {"speed": 9}, "born": "2020-01-02T03:04:05Z", "parent": {"name": "Max"}, "offset": -3, "weight": 12.5}`))
+			if err != nil {
+				t.Error(err)
+			}
+		default:
+			status = "12"
+		}
+		if response != nil {
+			w.Write(frameGRPCMessage(response))
+		}
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", status)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", message)
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	return ts
+}
+
+func Test_GRPCReflection(t *testing.T) {
+	ts := newGRPCTestServer(t)
+	defer ts.Close()
+
+	s, err := NewMCPFromGRPCReflection(context.Background(), ts.URL, nil, WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	callTool := func(name, arguments string) string {
+		response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "`+name+`", "arguments": `+arguments+`}}`))
+		encoded, _ := json.Marshal(response)
+		return string(encoded)
+	}
+
+	response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`))
+	tools, _ := json.Marshal(response)
+	if !strings.Contains(string(tools), `"name":"getpet"`) || !strings.Contains(string(tools), `"name":"watchpets"`) ||
+		!strings.Contains(string(tools), `"petId":{"format":"int64","type":"string"}`) {
+		t.Errorf("Got tools %s; want getpet and watchpets taking the petId field", tools)
+	}
+
+	got := callTool("getpet", `{"requestBody": {"petId": 7}}`)
+	want := `{\"id\":\"7\",\"name\":\"Rex\",\"status\":\"AVAILABLE\",\"tags\":[\"good\"],\"scores\":{\"speed\":9},` +
+		`\"born\":\"2020-01-02T03:04:05Z\",\"parent\":{\"name\":\"Max\"},\"offset\":-3,\"weight\":12.5}`
+	if !strings.Contains(got, want) {
+		t.Errorf("Got result %s; want the pet as JSON", got)
+	}
+
+	if got := callTool("getpet", `{"requestBody": {"petId": "0"}}`); !strings.Contains(got, `"isError":true`) ||
+		!strings.Contains(got, "gRPC status NOT_FOUND: pet 0 not found!") {
+		t.Errorf("Got result %s; want the NOT_FOUND status", got)
+	}
+	if got := callTool("getpet", `{"requestBody": {"petName": "Rex"}}`); !strings.Contains(got, `unknown field \"petName\"`) {
+		t.Errorf("Got result %s; want an error for the unknown field", got)
+	}
+	if got := callTool("watchpets", `{}`); !strings.Contains(got, "pets.v1.PetService/WatchPets is a server streaming RPC") {
+		t.Errorf("Got result %s; want streaming RPCs to be refused", got)
+	}
+}
+
+func Test_GRPCTranscoding(t *testing.T) {
+	getPet := testGetPet(t)
+	schema := messageSchema(getPet.output, map[protoreflect.FullName]bool{})
+	encoded, _ := json.Marshal(schema.Properties)
+	for _, want := range []string{
+		`"born":{"type":"string","format":"date-time"}`,
+		`"id":{"type":"string","format":"int64"}`,
+		`"parent":{"type":"object"}`,
+		`"photo":{"type":"string","format":"byte"}`,
+		`"scores":{"type":"object","description":"Map of string keys to int32 values"}`,
+		`"status":{"type":"string","enum":["STATUS_UNSPECIFIED","AVAILABLE","SOLD"]}`,
+		`"ranks":{"type":"array","items":{"type":"integer","format":"int32"}}`,
+	} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("Got properties %s; want %s", encoded, want)
+		}
+	}
+	if order := strings.Join(schema.PropertyOrder, ","); order != "id,name,status,tags,scores,born,parent,photo,offset,weight,ranks" {
+		t.Errorf("Got property order %s; want the field order", order)
+	}
+
+	// 64-bit integers are strings, enums are names and bytes are base64, as in the JSON mapping
+	body, err := getPet.encodeRequest(map[string]interface{}{"petId": "-9007199254740993"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, _ := readGRPCMessage(body.data)
+	if decoded, err := protoToJSON(getPet.input, message); err != nil || string(decoded) != `{"petId":"-9007199254740993"}` {
+		t.Errorf("Got %s, %v; want the 64-bit ID to round trip", decoded, err)
+	}
+	pet, err := protoFromJSON(getPet.output, []byte(`{"status": "SOLD", "photo": "AQID", "weight": "NaN", "ranks": [1, "2"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := protoToJSON(getPet.output, pet); err != nil || string(decoded) != `{"status":"SOLD","photo":"AQID","weight":"NaN","ranks":[1,2]}` {
+		t.Errorf("Got %s, %v; want the pet to round trip", decoded, err)
+	}
+	if _, err := protoFromJSON(getPet.output, []byte(`{"status": "LOST"}`)); err == nil {
+		t.Error("Got no error; want an error for an unknown enum value")
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// HeaderProvider returns headers to send with an upstream request, derived from the context of
+// the tool call, e.g. the token of the user behind the calling MCP session
+type HeaderProvider func(ctx context.Context) (map[string]string, error)
+
+// WithHeaderProvider calls the provider once per tool call and sends the headers it returns,
+// overriding static headers with the same name. If the provider fails, the call is aborted.
+func WithHeaderProvider(provider HeaderProvider) AdapterOption {
+	return func(c *adapterConfig) {
+		if provider == nil {
+			c.setError(fmt.Errorf("header provider must not be nil"))
+			return
+		}
+		c.headerProvider = provider
+	}
+}
+
+// contextHeadersKey is the context key of the headers stored by ContextWithHeaders
+type contextHeadersKey struct{}
+
+// ContextWithHeaders returns a context carrying headers for ContextHeaderProvider, e.g. set by
+// the server's context function from the credentials of the incoming MCP connection
+func ContextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return context.WithValue(ctx, contextHeadersKey{}, headers)
+}
+
+// ContextHeaderProvider is a HeaderProvider returning the headers stored by ContextWithHeaders
+func ContextHeaderProvider(ctx context.Context) (map[string]string, error) {
+	headers, _ := ctx.Value(contextHeadersKey{}).(map[string]string)
+	return headers, nil
+}
+
+// requestHeaders merges the headers of the provider, if any, over the static headers
+func (c *adapterConfig) requestHeaders(ctx context.Context, static map[string]string) (map[string]string, error) {
+	if c.headerProvider == nil {
+		return static, nil
+	}
+	dynamic, err := c.headerProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(static)+len(dynamic))
+	for key, value := range static {
+		headers[key] = value
+	}
+	for key, value := range dynamic {
+		headers[key] = value
+	}
+	return headers, nil
+}
+
+// headersFor returns the static headers of an operation: the global extra headers, overridden by
+// the operation's x-mcp-headers extension, overridden by its OperationConfig. Names are case-insensitive.
+// Environment variables are expanded in the configured headers, never in those of the specification.
+func (c *adapterConfig) headersFor(opCfg OperationConfig, api APIEndpoint, global map[string]string) (map[string]string, error) {
+	fromSpec, err := api.extensionStringMap(extensionHeaders)
+	if err != nil || (len(fromSpec) == 0 && len(opCfg.Headers) == 0) {
+		return global, err
+	}
+	configured, err := c.expandHeaderEnv(opCfg.Headers)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(global)+len(fromSpec)+len(configured))
+	for _, layer := range []map[string]string{global, fromSpec, configured} {
+		for name, value := range layer {
+			for existing := range headers {
+				if strings.EqualFold(existing, name) {
+					delete(headers, existing)
+				}
+			}
+			headers[name] = value
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_HeaderProvider(t *testing.T) {
+	var gotTenant, gotSource string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTenant = r.Header.Get("X-Tenant")
+		gotSource = r.Header.Get("X-Source")
+	}))
+	defer ts.Close()
+
+	static := map[string]string{"X-Tenant": "default", "X-Source": "mcp"}
+	handler := NewToolHandler(http.MethodGet, ts.URL, static, WithHeaderProvider(ContextHeaderProvider))
+	ctx := ContextWithHeaders(context.Background(), map[string]string{"X-Tenant": "acme"})
+	if _, err := handler(ctx, mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if gotTenant != "acme" || gotSource != "mcp" {
+		t.Errorf("Got X-Tenant %q and X-Source %q; want acme and mcp", gotTenant, gotSource)
+	}
+
+	failing := func(ctx context.Context) (map[string]string, error) {
+		return nil, errors.New("no session token")
+	}
+	result, err := NewToolHandler(http.MethodGet, ts.URL, nil, WithHeaderProvider(failing))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "no session token") {
+		t.Errorf("Got result %q; want the provider error", text)
+	}
+}
+
+func Test_OperationHeaders(t *testing.T) {
+	api := APIEndpoint{OperationID: "listItems", Extensions: map[string]interface{}{
+		extensionHeaders: map[string]interface{}{"X-Scope": "items:read", "x-env": "staging"},
+	}}
+	cfg := newAdapterConfig()
+	global := map[string]string{"X-Env": "prod", "X-Tenant": "acme"}
+
+	headers, err := cfg.headersFor(OperationConfig{Headers: map[string]string{"X-Scope": "items:write"}}, api, global)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"X-Tenant": "acme", "x-env": "staging", "X-Scope": "items:write"}
+	if !reflect.DeepEqual(headers, want) {
+		t.Errorf("Got headers %v; want %v", headers, want)
+	}
+	if global["X-Env"] != "prod" {
+		t.Errorf("The global headers were modified")
+	}
+
+	api.Extensions[extensionHeaders] = map[string]interface{}{"X-Retries": float64(3)}
+	if _, err := cfg.headersFor(OperationConfig{}, api, global); err == nil {
+		t.Errorf("Got no error for a non-string header value")
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_HMACSign(t *testing.T) {
+	auth := HMACAuth{
+		Secret:          "secret",
+		Header:          "X-Signature",
+		Prefix:          "sha256=",
+		Template:        "{{.Method}}|{{.Path}}|{{.Query}}|{{.Timestamp}}|{{.Body}}",
+		TimestampHeader: "X-Timestamp",
+		NonceHeader:     "X-Nonce",
+	}
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/payments?id=7", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Body = http.NoBody
+	if err := auth.sign(req, nil, time.Unix(1700000000, 0)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST|/v1/payments|id=7|1700000000|"))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.Header.Get("X-Signature") != want {
+		t.Errorf("Expected signature %s, got %s", want, req.Header.Get("X-Signature"))
+	}
+	if got := req.Header.Get("X-Timestamp"); got != "1700000000" {
+		t.Errorf("Expected timestamp 1700000000, got %q", got)
+	}
+	if got := req.Header.Get("X-Nonce"); len(got) != 32 {
+		t.Errorf("Expected a 32 character nonce, got %q", got)
+	}
+}
+
+func Test_HMACHandler(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = readRequestBody(r)
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodPost, ts.URL+"/orders", nil, WithAuth(Auth{HMAC: &HMACAuth{
+		Secret:          "secret",
+		Header:          "X-Signature",
+		TimestampHeader: "X-Timestamp",
+		Template:        "{{.Timestamp}}.{{.BodySHA256}}",
+	}}))
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"body": map[string]interface{}{"amount": 10}}
+	if _, err := handler(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got == nil || len(body) == 0 {
+		t.Fatal("Expected a request with a body")
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(got.Header.Get("X-Timestamp") + "." + sha256Hex(body)))
+	if want := hex.EncodeToString(mac.Sum(nil)); got.Header.Get("X-Signature") != want {
+		t.Errorf("Expected signature %s, got %s", want, got.Header.Get("X-Signature"))
+	}
+}
+
+func Test_HMACValidate(t *testing.T) {
+	// The template is parsed once, when the option is applied
+	if cfg := newAdapterConfig(WithAuth(Auth{HMAC: &HMACAuth{Secret: "secret", Header: "X-Signature"}})); cfg.auth.hmacTemplate == nil {
+		t.Error("Expected the HMAC template to be parsed by WithAuth")
+	}
+
+	tests := []HMACAuth{
+		{Header: "X-Signature"},
+		{Secret: "secret", Header: "X-Signature", Algorithm: "md5"},
+		{Secret: "secret", Header: "X-Signature", Encoding: "base32"},
+		{Secret: "secret", Header: "X-Signature", Template: "{{.Method"},
+	}
+	for _, tt := range tests {
+		auth := Auth{HMAC: &tt}
+		if err := auth.validate(); err == nil {
+			t.Errorf("Expected an error for %+v", tt)
+		}
+	}
+}
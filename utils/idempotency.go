@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// defaultIdempotencyHeader is the header carrying idempotency keys unless another name is configured
+const defaultIdempotencyHeader = "Idempotency-Key"
+
+// WithIdempotencyKeys attaches a random key to every POST and PATCH request, so that an upstream
+// supporting idempotency keys applies a request at most once. The key is generated per tool call
+// and reused when the call is retried; combine it with RetryPolicy.RetryNonIdempotent to retry
+// such requests safely. The header defaults to Idempotency-Key if header is empty, as some APIs
+// expect another name such as X-Idempotency-Key. A key passed as a header parameter is kept.
+func WithIdempotencyKeys(header string) AdapterOption {
+	return func(c *adapterConfig) {
+		if header == "" {
+			header = defaultIdempotencyHeader
+		}
+		c.idempotencyKeys = header
+	}
+}
+
+// idempotencyKey returns a new key for a call with the given method, or "" if it needs none
+func (c *adapterConfig) idempotencyKey(method string) string {
+	if c.idempotencyKeys == "" {
+		return ""
+	}
+	switch strings.ToUpper(method) {
+	case http.MethodPost, http.MethodPatch:
+		return uuid.NewString()
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithSpecAuth sets the credentials sent when NewMCPFromURL downloads the specification.
+// They are independent from the credentials used for the API itself.
+func WithSpecAuth(auth Auth) AdapterOption {
+	return func(c *adapterConfig) {
+		if err := auth.validate(); err != nil {
+			c.setError(fmt.Errorf("invalid spec auth: %w", err))
+			return
+		}
+		c.specAuth = &auth
+	}
+}
+
+// ParseOpenAPI parses an OpenAPI 3.x or Swagger 2.0 document, detecting whether it is JSON or YAML
+func ParseOpenAPI(data []byte) (OpenAPIParser, error) {
+	if isJSONDocument(data) {
+		return ParseOpenAPIFromJSON(data)
+	}
+	return ParseOpenAPIFromYAML(data)
+}
+
+// parseSpec parses a document using its file name or Content-Type to choose between JSON and YAML,
+// falling back to detecting the format from the content
+func parseSpec(data []byte, name, contentType string) (OpenAPIParser, error) {
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case ext == ".yaml" || ext == ".yml" || strings.Contains(contentType, "yaml"):
+		return ParseOpenAPIFromYAML(data)
+	case ext == ".json" || strings.Contains(contentType, "json"):
+		return ParseOpenAPIFromJSON(data)
+	}
+	return ParseOpenAPI(data)
+}
+
+// isJSONDocument reports whether a document is JSON rather than YAML, based on its first character
+func isJSONDocument(data []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// NewMCPFromReader reads an OpenAPI or Swagger document and creates an MCP server for it.
+// See NewMCPFromCustomParser for the meaning of the other arguments.
+func NewMCPFromReader(r io.Reader, baseURL string, extraHeaders map[string]string, opts ...AdapterOption) (*server.MCPServer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read specification: %w", err)
+	}
+	parser, err := ParseOpenAPI(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewMCPFromCustomParser(baseURL, extraHeaders, parser, opts...)
+}
+
+// NewMCPFromFile loads an OpenAPI or Swagger document from a local file and creates an MCP server for it.
+// Files ending in .yaml or .yml are parsed as YAML, .json files as JSON; otherwise the format is detected.
+// WatchSpecFile keeps the server in sync with later changes to the file.
+func NewMCPFromFile(filename string, baseURL string, extraHeaders map[string]string, opts ...AdapterOption) (*server.MCPServer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read specification: %w", err)
+	}
+	parser, err := parseSpec(data, filename, "")
+	if err != nil {
+		return nil, err
+	}
+	cfg := newAdapterConfig(opts...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	s, err := newMCPFromParser(cfg, baseURL, extraHeaders, parser)
+	if err != nil {
+		return nil, err
+	}
+	watchableServers.Store(s, &specWatcher{cfg: cfg, server: s, filename: filename, baseURL: baseURL, extraHeaders: extraHeaders})
+	return s, nil
+}
+
+// NewMCPFromURL downloads an OpenAPI or Swagger document and creates an MCP server for it.
+// The download uses the configured HTTP client and request timeout, and the credentials set with WithSpecAuth.
+func NewMCPFromURL(ctx context.Context, specURL string, baseURL string, extraHeaders map[string]string, opts ...AdapterOption) (*server.MCPServer, error) {
+	cfg := newAdapterConfig(opts...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	cfg.specURL = specURL
+
+	data, contentType, err := fetchSpec(ctx, cfg, specURL)
+	if err != nil {
+		return nil, err
+	}
+	parser, err := parseSpec(data, specURLPath(specURL), contentType)
+	if err != nil {
+		return nil, err
+	}
+	return newMCPFromParser(cfg, baseURL, extraHeaders, parser)
+}
+
+// specURLPath returns the path of a specification URL, used to recognize its file extension
+func specURLPath(specURL string) string {
+	u, err := neturl.Parse(specURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// fetchSpec downloads the document at specURL, returning it with its Content-Type
+func fetchSpec(ctx context.Context, cfg *adapterConfig, specURL string) ([]byte, string, error) {
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid specification URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.8")
+	if err := cfg.specAuth.apply(req); err != nil {
+		return nil, "", fmt.Errorf("failed to authenticate specification request: %w", err)
+	}
+
+	resp, err := cfg.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch specification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if !isSuccessStatus(resp.StatusCode) {
+		return nil, "", fmt.Errorf("failed to fetch specification: HTTP %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read specification: %w", err)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const loaderSpec = `{
+	"openapi": "3.0.0",
+	"info": {"title": "Loader", "version": "1.0"},
+	"paths": {"/ping": {"get": {"operationId": "ping"}}}
+}`
+
+func Test_NewMCPFromURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer spec-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(loaderSpec))
+	}))
+	defer ts.Close()
+
+	if _, err := NewMCPFromURL(context.Background(), ts.URL, "http://api", nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Expected the unauthenticated download to fail, got %v", err)
+	}
+	if _, err := NewMCPFromURL(context.Background(), ts.URL, "http://api", nil, WithSpecAuth(Auth{BearerToken: "spec-token"})); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func Test_NewMCPFromReader(t *testing.T) {
+	yamlSpec := "openapi: 3.0.0\ninfo:\n  title: Loader\n  version: \"1.0\"\npaths:\n  /ping:\n    get:\n      operationId: ping\n"
+	for _, spec := range []string{loaderSpec, yamlSpec} {
+		if _, err := NewMCPFromReader(strings.NewReader(spec), "http://api", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func Test_ParseYAMLWithNumericKeys(t *testing.T) {
+	spec := "openapi: 3.0.0\ninfo:\n  title: Loader\n  version: 1.0\npaths:\n  /ping:\n    get:\n      operationId: ping\n      responses:\n        200:\n          description: ok\n"
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := parser.APIs()[0].Responses["200"]; !ok {
+		t.Fatalf("Expected the numeric response key to be kept, got %+v", parser.APIs()[0].Responses)
+	}
+	if version := parser.Info().Version; version != "1.0" {
+		t.Fatalf("Unexpected version: %q", version)
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxLoggedBodyBytes limits how much of a request or response body is included in debug logs
+const maxLoggedBodyBytes = 4096
+
+// WithLogger logs every upstream call made by the tool handlers. Each call is logged at info level
+// with its method, URL, status, duration and byte counts; the debug level adds headers and bodies.
+// Credentials are redacted, see WithRedactedHeaders. By default nothing is logged.
+func WithLogger(logger *slog.Logger) AdapterOption {
+	return func(c *adapterConfig) {
+		if logger == nil {
+			logger = slog.New(discardHandler{})
+		}
+		c.logger = logger
+	}
+}
+
+// discardHandler is a slog.Handler that drops all records
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// logResponse logs a completed upstream call; req is the last attempt sent
+func (c *adapterConfig) logResponse(ctx context.Context, req *http.Request, reqBody *requestBody, resp *http.Response, body []byte, duration time.Duration, attempts int, secrets []string) {
+	url := c.redactor.redactURL(req.URL.String(), secrets)
+	c.logger.LogAttrs(ctx, slog.LevelInfo, "upstream request",
+		slog.String("method", req.Method),
+		slog.String("url", url),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("duration", duration),
+		slog.Int64("request_bytes", max(req.ContentLength, 0)),
+		slog.Int("response_bytes", len(body)),
+		slog.Int("attempts", attempts),
+	)
+
+	if !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", url),
+		slog.Any("request_headers", c.redactor.redactHeaders(req.Header)),
+		slog.Any("response_headers", c.redactor.redactHeaders(resp.Header)),
+		slog.String("response_body", c.redactor.redactText(logBody(body), secrets)),
+	}
+	if reqBody != nil && reqBody.data != nil {
+		attrs = append(attrs, slog.String("request_body", c.redactor.redactText(logBody(reqBody.data), secrets)))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "upstream exchange", attrs...)
+}
+
+// logFailure logs an upstream call that did not produce a response
+func (c *adapterConfig) logFailure(ctx context.Context, method, url string, err error, duration time.Duration, attempts int, secrets []string) {
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "upstream request failed",
+		slog.String("method", method),
+		slog.String("url", c.redactor.redactURL(url, secrets)),
+		slog.Duration("duration", duration),
+		slog.Int("attempts", attempts),
+		slog.String("error", c.redactor.redactText(err.Error(), secrets)),
+	)
+}
+
+// logBody returns a body for a debug log, cut off at maxLoggedBodyBytes
+func logBody(body []byte) string {
+	if len(body) > maxLoggedBodyBytes {
+		return string(trimPartialRune(body[:maxLoggedBodyBytes])) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_LogRequestsWithRedactedHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	headers := map[string]string{"Authorization": "Bearer secret-token", "X-Request-Source": "test"}
+
+	_, err := NewToolHandler(http.MethodGet, ts.URL, headers, WithLogger(logger))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logged := buf.String()
+	for _, want := range []string{"status=200", "response_bytes=11", "X-Request-Source:test", `{\"ok\":true}`} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("Log %q does not contain %q", logged, want)
+		}
+	}
+	if strings.Contains(logged, "secret-token") {
+		t.Errorf("Log %q leaks the Authorization header", logged)
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"time"
+)
+
+// CallMetrics describes one upstream call made by a tool handler
+type CallMetrics struct {
+	Tool          string // Name of the tool; empty for handlers created with NewToolHandler
+	Method        string
+	StatusCode    int // HTTP status of the response, or zero if no response was received
+	Duration      time.Duration
+	RequestBytes  int64 // Size of the request body, or zero if it has none or its size is unknown
+	ResponseBytes int64 // Bytes of the response body read by the handler
+	Err           error // Transport error, timeout or error reading the response
+}
+
+// Metrics receives a measurement for every upstream call, e.g. to feed Prometheus counters
+// and histograms labeled by tool name and status code. Implementations must be safe for
+// concurrent use, since tools are called concurrently.
+type Metrics interface {
+	ObserveCall(call CallMetrics)
+}
+
+// MetricsFunc adapts an ordinary function to the Metrics interface
+type MetricsFunc func(call CallMetrics)
+
+// ObserveCall calls f(call)
+func (f MetricsFunc) ObserveCall(call CallMetrics) {
+	f(call)
+}
+
+// WithMetrics reports every upstream call made by the tool handlers to the given collector
+func WithMetrics(metrics Metrics) AdapterOption {
+	return func(c *adapterConfig) {
+		c.metrics = metrics
+	}
+}
+
+// observeCall reports a call to the configured metrics collector, if any
+func (c *adapterConfig) observeCall(call CallMetrics) {
+	if c.metrics != nil {
+		c.metrics.ObserveCall(call)
+	}
+}
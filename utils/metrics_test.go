@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_ObserveCallMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+	defer ts.Close()
+
+	var calls []CallMetrics
+	metrics := MetricsFunc(func(call CallMetrics) { calls = append(calls, call) })
+	_, err := NewToolHandler(http.MethodGet, ts.URL, nil, WithMetrics(metrics))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 1 {
+		t.Fatalf("Got %d observed calls; want 1", len(calls))
+	}
+	call := calls[0]
+	if call.Method != http.MethodGet || call.StatusCode != http.StatusNotFound || call.ResponseBytes != 9 || call.Err != nil {
+		t.Fatalf("Got metrics %+v; want GET with status 404 and 9 response bytes", call)
+	}
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxExampleDepth limits how deeply nested schemas are expanded when generating an example
+const maxExampleDepth = 5
+
+// WithMockResponses makes tools return the examples declared by the specification instead of
+// calling the upstream API, e.g. to develop against an API that is not available yet.
+// The example of the lowest 2xx response is used, preferring application/json; if the operation
+// declares none, an example is generated from the response schema. Tools created with
+// NewToolHandler have no specification and are not affected.
+func WithMockResponses(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.mockResponses = enabled
+	}
+}
+
+// mockResponse is the canned response returned by a tool in mock mode
+type mockResponse struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+// response returns the response metadata, without a body
+func (m *mockResponse) response() *http.Response {
+	header := http.Header{}
+	if m.contentType != "" {
+		header.Set("Content-Type", m.contentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", m.status, http.StatusText(m.status)),
+		StatusCode:    m.status,
+		Header:        header,
+		ContentLength: int64(len(m.body)),
+	}
+}
+
+// newMockResponse builds the mock response of an operation from its declared responses
+func newMockResponse(api APIEndpoint) (*mockResponse, error) {
+	status, response, ok := mockStatus(api.Responses)
+	if !ok {
+		return &mockResponse{status: http.StatusOK}, nil
+	}
+	mock := &mockResponse{status: status}
+
+	names := make([]string, 0, len(response.Content))
+	for name := range response.Content {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return mock, nil
+	}
+	mock.contentType = names[0]
+	if _, ok := response.Content["application/json"]; ok {
+		mock.contentType = "application/json"
+	}
+
+	example := mediaTypeExample(response.Content[mock.contentType])
+	if text, ok := example.(string); ok && !strings.Contains(mock.contentType, "json") {
+		mock.body = []byte(text)
+		return mock, nil
+	}
+	body, err := json.Marshal(example)
+	if err != nil {
+		return nil, fmt.Errorf("invalid example: %w", err)
+	}
+	mock.body = body
+	return mock, nil
+}
+
+// mockStatus picks the response to mock: the lowest 2xx status, or else the default response
+func mockStatus(responses map[string]Response) (int, Response, bool) {
+	statuses := make([]string, 0, len(responses))
+	for status := range responses {
+		if len(status) == 3 && status[0] == '2' {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Strings(statuses)
+	if len(statuses) > 0 {
+		// A range such as 2XX is answered with its first code
+		code, err := strconv.Atoi(strings.NewReplacer("X", "0", "x", "0").Replace(statuses[0]))
+		if err != nil {
+			code = http.StatusOK
+		}
+		return code, responses[statuses[0]], true
+	}
+	if response, ok := responses["default"]; ok {
+		return http.StatusOK, response, true
+	}
+	return 0, Response{}, false
+}
+
+// mediaTypeExample returns the declared example of a media type, the first named example in
+// lexical order, or an example generated from its schema
+func mediaTypeExample(mediaType MediaType) interface{} {
+	if mediaType.Example != nil {
+		return mediaType.Example
+	}
+	if len(mediaType.Examples) > 0 {
+		names := make([]string, 0, len(mediaType.Examples))
+		for name := range mediaType.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return mediaType.Examples[names[0]]
+	}
+	return schemaExample(mediaType.Schema, 0)
+}
+
+// schemaExample generates a value matching the schema, using its examples, defaults and enums where declared
+func schemaExample(schema *Schema, depth int) interface{} {
+	switch {
+	case schema == nil:
+		return nil
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date":
+			return "2024-01-01"
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	case "integer", "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 0
+	case "boolean":
+		return true
+	case "array":
+		if depth >= maxExampleDepth {
+			return []interface{}{}
+		}
+		return []interface{}{schemaExample(schema.Items, depth+1)}
+	}
+
+	if len(schema.Properties) == 0 || depth >= maxExampleDepth {
+		if schema.Type == "object" {
+			return map[string]interface{}{}
+		}
+		return nil
+	}
+	object := make(map[string]interface{}, len(schema.Properties))
+	for name, prop := range schema.Properties {
+		object[name] = schemaExample(&prop, depth+1)
+	}
+	return object
+}
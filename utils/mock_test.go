@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func Test_MockResponses(t *testing.T) {
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {
+		"/pets": {"get": {"operationId": "listPets", "responses": {"200": {"content": {"application/json": {
+			"examples": {"two": {"value": [{"id": 1}, {"id": 2}]}}}}}}}},
+		"/pets/{id}": {"get": {"operationId": "getPet", "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+			"responses": {"201": {"content": {"application/json": {"schema": {"type": "object", "properties": {
+				"id": {"type": "integer", "minimum": 1}, "born": {"type": "string", "format": "date"}, "kind": {"type": "string", "enum": ["cat", "dog"]}}}}}}}}},
+		"/health": {"get": {"operationId": "health", "responses": {"200": {"content": {"text/plain": {"example": "ok"}}}}}}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The base URL is unreachable, so any real request would fail
+	tools, err := buildTools(newAdapterConfig(WithMockResponses(true)), server.NewMCPServer("t", "1"), "t", "http://127.0.0.1:1", nil, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"listpets": `[{"id":1},{"id":2}]`,
+		"getpet":   `{"born":"2024-01-01","id":1,"kind":"cat"}`,
+		"health":   "ok",
+	}
+	for _, tool := range tools {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pathNames": map[string]interface{}{"id": "1"}}
+		result, err := tool.Handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := resultText(t, result); text != want[tool.Tool.Name] {
+			t.Errorf("%s: got %q; want %q", tool.Tool.Name, text, want[tool.Tool.Name])
+		}
+	}
+}
+
+func Test_MockStatus(t *testing.T) {
+	status, _, ok := mockStatus(map[string]Response{"2XX": {}, "404": {}})
+	if !ok || status != http.StatusOK {
+		t.Errorf("Got status %d, %v; want 200 for a 2XX range", status, ok)
+	}
+	if _, _, ok := mockStatus(map[string]Response{"404": {}}); ok {
+		t.Error("Want no mock response without a success or default response")
+	}
+}
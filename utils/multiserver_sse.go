@@ -905,20 +905,29 @@ func (f *FilteredOpenAPIParser) Info() APIInfo {
 	return f.BaseParser.Info()
 }
 
+// SecuritySchemes delegates to the base parser
+func (f *FilteredOpenAPIParser) SecuritySchemes() map[string]SecurityScheme {
+	return securitySchemes(f.BaseParser)
+}
+
+// Source delegates to the base parser, returning no document if it does not implement SpecSource
+func (f *FilteredOpenAPIParser) Source() ([]byte, string) {
+	if source, ok := f.BaseParser.(SpecSource); ok {
+		return source.Source()
+	}
+	return nil, ""
+}
+
 // APIs returns filtered APIs from the base parser
 func (f *FilteredOpenAPIParser) APIs() []APIEndpoint {
 	allAPIs := f.BaseParser.APIs()
 	return ApplyFilters(allAPIs, f.Filters)
 }
 
-// isYAML checks if data looks like YAML
+// isYAML checks if data looks like YAML. JSON documents always start with an object or array,
+// so anything else is treated as YAML.
 func isYAML(data []byte) bool {
-	// Simple heuristic: check for common YAML indicators
-	s := string(data)
-	return strings.Contains(s, "---") ||
-		strings.Contains(s, ":") && strings.Contains(s, "\n") ||
-		strings.HasPrefix(strings.TrimSpace(s), "openapi:") ||
-		strings.HasPrefix(strings.TrimSpace(s), "swagger:")
+	return !isJSONDocument(data)
 }
 
 func createResponse(id interface{}, result interface{}) mcp.JSONRPCMessage {
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultMaxToolNameLength is the longest tool name generated by default, a limit accepted by common MCP clients
+const defaultMaxToolNameLength = 64
+
+// toolNameHashLength is the number of hex digits of the hash appended to a truncated tool name
+const toolNameHashLength = 8
+
+// ToolNamer derives the name of the tool generated for an operation
+type ToolNamer func(operationID, method, path string) string
+
+// defaultToolNamer names tools after their sanitized operationId
+func defaultToolNamer(operationID, method, path string) string {
+	return sanitizeToolName(operationID)
+}
+
+// validToolName matches the tool names accepted from the x-mcp-name extension
+var validToolName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// WithToolNamer replaces the default naming scheme, which sanitizes the operationId.
+// Names that collide with an already registered tool are disambiguated, see toolNames.resolve.
+func WithToolNamer(namer ToolNamer) AdapterOption {
+	return func(c *adapterConfig) {
+		if namer == nil {
+			c.setError(fmt.Errorf("tool namer must not be nil"))
+			return
+		}
+		c.toolNamer = namer
+	}
+}
+
+// toolName returns the name requested by the operation's x-mcp-name extension or, if it has none
+// or an invalid one, the name given by the configured ToolNamer. Collisions are resolved by the caller.
+func (c *adapterConfig) toolName(api APIEndpoint) string {
+	if value, ok := api.Extension(extensionName); ok {
+		name, _ := value.(string)
+		if validToolName.MatchString(name) {
+			return name
+		}
+		log.Printf("[TOOLS] Ignoring invalid %s %v of %s %s", extensionName, value, api.Method, api.Path)
+	}
+	return c.toolNamer(api.OperationID, api.Method, api.Path)
+}
+
+// WithMaxToolNameLength limits the length of generated tool names. Longer names are truncated
+// and end in a short hash of the full name, so distinct names stay distinct. Zero disables the limit.
+func WithMaxToolNameLength(length int) AdapterOption {
+	return func(c *adapterConfig) {
+		if length != 0 && length <= toolNameHashLength+1 {
+			c.setError(fmt.Errorf("max tool name length must be greater than %d, got %d", toolNameHashLength+1, length))
+			return
+		}
+		c.maxToolNameLength = length
+	}
+}
+
+// truncateToolName shortens a name to at most maxLength bytes, replacing its tail with a hash of the whole name
+func truncateToolName(name string, maxLength int) string {
+	if maxLength <= 0 || len(name) <= maxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:toolNameHashLength]
+	return strings.TrimSuffix(name[:maxLength-toolNameHashLength-1], "_") + "_" + hash
+}
+
+// toolNames hands out unique tool names no longer than maxLength
+type toolNames struct {
+	taken     map[string]bool
+	maxLength int
+}
+
+func newToolNames(maxLength int) *toolNames {
+	return &toolNames{taken: map[string]bool{}, maxLength: maxLength}
+}
+
+// unique returns name, or name with the lowest free numeric suffix if it is already taken
+func (n *toolNames) unique(name string) string {
+	candidate := truncateToolName(name, n.maxLength)
+	for i := 2; n.taken[candidate]; i++ {
+		candidate = truncateToolName(fmt.Sprintf("%s_%d", name, i), n.maxLength)
+	}
+	n.taken[candidate] = true
+	return candidate
+}
+
+// resolve returns a unique name for the operation's tool. A name already taken by another tool
+// first gets the operation's HTTP method appended, then the lowest free numeric suffix.
+// Operations are registered in sortOperations order, so the outcome is the same on every run.
+func (n *toolNames) resolve(name string, api APIEndpoint) string {
+	if candidate := truncateToolName(name, n.maxLength); !n.taken[candidate] {
+		n.taken[candidate] = true
+		return candidate
+	}
+
+	resolved := n.unique(name + "_" + strings.ToLower(api.Method))
+	log.Printf("[TOOLS] Tool name %q of %s %s is already taken, registering it as %q", name, api.Method, api.Path, resolved)
+	return resolved
+}
+
+// sortOperations orders operations by path and method, so that tools are registered
+// and name collisions are resolved in the same order regardless of how the spec was parsed
+func sortOperations(apis []APIEndpoint) {
+	sort.SliceStable(apis, func(i, j int) bool {
+		if apis[i].Path != apis[j].Path {
+			return apis[i].Path < apis[j].Path
+		}
+		return apis[i].Method < apis[j].Method
+	})
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func Test_ToolNamesAreUnique(t *testing.T) {
+	names := newToolNames(0)
+	got := []string{
+		names.unique("get_user"),
+		names.unique("get_user"),
+		names.unique("get_user_2"),
+		names.unique("get_user"),
+	}
+	want := []string{"get_user", "get_user_2", "get_user_2_2", "get_user_3"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Got names %v; want %v", got, want)
+		}
+	}
+}
+
+func Test_ResolveToolNameCollisions(t *testing.T) {
+	apis := []APIEndpoint{
+		{Path: "/users/{id}", Method: "PUT"},
+		{Path: "/users/{id}", Method: "GET"},
+		{Path: "/users", Method: "GET"},
+		{Path: "/users/{id}", Method: "GET"},
+	}
+	sortOperations(apis)
+
+	names := newToolNames(0)
+	var got []string
+	for _, api := range apis {
+		got = append(got, names.resolve("user", api))
+	}
+	want := []string{"user", "user_get", "user_get_2", "user_put"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Got names %v; want %v", got, want)
+		}
+	}
+}
+
+func Test_TruncateLongToolNames(t *testing.T) {
+	long := "get_all_invoices_for_customer_account_including_archived_and_draft_items"
+	names := newToolNames(defaultMaxToolNameLength)
+
+	first := names.unique(long)
+	second := names.unique(long)
+	if len(first) > defaultMaxToolNameLength || len(second) > defaultMaxToolNameLength {
+		t.Fatalf("Got names %q and %q; want at most %d characters", first, second, defaultMaxToolNameLength)
+	}
+	if first == second {
+		t.Fatalf("Got the same name %q twice; want distinct names", first)
+	}
+	if first != truncateToolName(long, defaultMaxToolNameLength) {
+		t.Fatalf("Got name %q; want a stable truncation", first)
+	}
+	if got := names.unique("short_name"); got != "short_name" {
+		t.Fatalf("Got name %q; want short names untouched", got)
+	}
+}
+
+func Test_ToolNameFromExtension(t *testing.T) {
+	cfg := newAdapterConfig()
+	tests := []struct {
+		extensions map[string]interface{}
+		want       string
+	}{
+		{nil, "listproducts"},
+		{map[string]interface{}{"x-mcp-name": "search_products"}, "search_products"},
+		{map[string]interface{}{"x-mcp-name": "search products!"}, "listproducts"},
+		{map[string]interface{}{"x-mcp-name": 42}, "listproducts"},
+	}
+	for _, tt := range tests {
+		api := APIEndpoint{OperationID: "listProducts", Method: "GET", Path: "/products", Extensions: tt.extensions}
+		if got := cfg.toolName(api); got != tt.want {
+			t.Errorf("Got name %q for extensions %v; want %q", got, tt.extensions, tt.want)
+		}
+	}
+}
+
+func Test_DeterministicOrdering(t *testing.T) {
+	spec := []byte(`{
+		"openapi": "3.0.0",
+		"paths": {
+			"/users": {
+				"post": {"operationId": "createUser", "requestBody": {"content": {
+					"application/json": {"schema": {"type": "object", "required": ["name", "email"], "properties": {
+						"name": {"type": "string", "description": "JSON name"}, "email": {"type": "string"}, "age": {"type": "integer"}
+					}}},
+					"application/x-www-form-urlencoded": {"schema": {"type": "object", "required": ["name"], "properties": {
+						"name": {"type": "string", "description": "Form name"}
+					}}}
+				}}},
+				"get": {"operationId": "listUsers"}
+			},
+			"/orders": {"get": {"operationId": "listOrders"}},
+			"/accounts": {"delete": {"operationId": "deleteAccounts"}, "get": {"operationId": "listAccounts"}}
+		}
+	}`)
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var operations []string
+	for _, api := range parser.APIs() {
+		operations = append(operations, api.OperationID)
+	}
+	want := []string{"deleteAccounts", "listAccounts", "listOrders", "listUsers", "createUser"}
+	if !reflect.DeepEqual(operations, want) {
+		t.Errorf("Unexpected operation order %v; want %v", operations, want)
+	}
+
+	first, err := buildTools(newAdapterConfig(), server.NewMCPServer("t", "1"), "t", "http://localhost", nil, parser)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	encoded, _ := json.Marshal(first)
+	for i := 0; i < 10; i++ {
+		tools, err := buildTools(newAdapterConfig(), server.NewMCPServer("t", "1"), "t", "http://localhost", nil, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if again, _ := json.Marshal(tools); string(again) != string(encoded) {
+			t.Fatalf("Tools differ between builds:\n%s\n%s", encoded, again)
+		}
+	}
+
+	body := first[len(first)-1].Tool.InputSchema.Properties["requestBody"].(map[string]interface{})
+	if required := body["required"]; !reflect.DeepEqual(required, []string{"name", "email"}) {
+		t.Errorf("Unexpected required body properties %v", required)
+	}
+	name := body["properties"].(map[string]interface{})["name"].(map[string]interface{})
+	if name["description"] != "[required] JSON name" {
+		t.Errorf("Expected the properties of the JSON body to win, got %v", name["description"])
+	}
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryDelta is how long before its expiry a cached token is refreshed
+const oauth2ExpiryDelta = 30 * time.Second
+
+// OAuth2ClientCredentials configures the OAuth2 client credentials grant.
+// The access token is fetched on first use, cached, and refreshed shortly before it expires.
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// CredentialsInBody sends the client ID and secret as form parameters instead of HTTP Basic auth
+	CredentialsInBody bool
+}
+
+// String describes the grant without revealing the client secret
+func (o OAuth2ClientCredentials) String() string {
+	return fmt.Sprintf("oauth2(%s at %s)", o.ClientID, o.TokenURL)
+}
+
+// oauth2TokenSource fetches and caches access tokens for one OAuth2 client
+type oauth2TokenSource struct {
+	config OAuth2ClientCredentials
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time // Zero if the token does not expire
+}
+
+// oauth2TokenResponse is the successful response of the token endpoint
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// newOAuth2TokenSource creates a token source fetching tokens with the given client
+func newOAuth2TokenSource(config OAuth2ClientCredentials, client *http.Client) *oauth2TokenSource {
+	return &oauth2TokenSource{
+		config: config,
+		client: client,
+	}
+}
+
+// Token returns a valid access token, fetching a new one if the cached token is missing or about to expire.
+// Concurrent callers wait for a single refresh instead of each requesting their own token.
+func (s *oauth2TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expires.IsZero() || time.Now().Add(oauth2ExpiryDelta).Before(s.expires)) {
+		return s.token, nil
+	}
+
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain OAuth2 token: %w", err)
+	}
+
+	s.token = token.AccessToken
+	s.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		s.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return s.token, nil
+}
+
+// fetch requests a new token from the token endpoint
+func (s *oauth2TokenSource) fetch(ctx context.Context) (*oauth2TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.CredentialsInBody {
+		form.Set("client_id", s.config.ClientID)
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !s.config.CredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !isSuccessStatus(resp.StatusCode) {
+		return nil, fmt.Errorf("token endpoint returned HTTP %s: %s", resp.Status, body)
+	}
+
+	var token oauth2TokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	return &token, nil
+}
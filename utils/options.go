@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// defaultRequestTimeout bounds each upstream request unless overridden
+const defaultRequestTimeout = 30 * time.Second
+
+// defaultHTTPClient is shared by every tool handler that is not given its own client,
+// so that upstream connections are pooled and kept alive across tool calls.
+// It strips credentials from requests redirected to a different host.
+var defaultHTTPClient = &http.Client{
+	CheckRedirect: RedirectPolicy{}.checkRedirect,
+}
+
+// AdapterOption defines a function type for configuring the tools generated from an OpenAPI parser
+type AdapterOption func(*adapterConfig)
+
+// adapterConfig holds the settings shared by all tool handlers of an MCP server
+type adapterConfig struct {
+	err error // First error raised while applying options
+
+	upstreamURL     string
+	extraHeaders    map[string]string
+	envHeaders      bool
+	httpClient      *http.Client
+	transportOpts   []func(*http.Transport)
+	roundTripper    http.RoundTripper
+	middlewares     []TransportMiddleware
+	redirect        *RedirectPolicy
+	cookieJar       http.CookieJar
+	downloads       *downloader
+	csrf            *csrfSource
+	cassette        *Cassette
+	timeout         time.Duration
+	maxResponse     int64
+	retry           RetryPolicy
+	rateLimit       *RateLimit
+	hostRateLimits  map[string]RateLimit
+	limiters        *rateLimiters
+	maxInFlight     int
+	hostInFlight    map[string]int
+	inFlight        *inFlightLimits
+	circuits        *circuits
+	cacheTTL        time.Duration
+	cacheEntries    int
+	cache           *responseCache
+	pagination      *Pagination
+	auth            *Auth
+	headerProvider  HeaderProvider
+	specAuth        *Auth
+	specURL         string // Location the specification was loaded from, used to resolve relative server URLs
+	serverVariables map[string]string
+	credentials     map[string]Credentials
+	operations      map[string]OperationConfig
+	uploadDirs      []string
+	rawPathParams   map[string]bool
+	strictEnums     bool
+	dryRun          bool
+	showCurl        bool
+	showURL         bool
+	showDuration    bool
+	mockResponses   bool
+	omitEmpty       *OmitEmpty
+	boolFormat      *[2]string // Values written for false and true; nil writes "false" and "true"
+	dateTimeFormat  string
+	rangeRequests   bool
+	orderedBody     bool
+	escapeHTML      bool
+	accept          string
+	idempotencyKeys string // Header carrying idempotency keys; empty sends none
+
+	// Tool generation
+	includeTags       []string
+	excludeTags       []string
+	includePaths      []*regexp.Regexp
+	excludePaths      []*regexp.Regexp
+	includeMethods    []string
+	skipDeprecated    bool
+	toolNamer         ToolNamer
+	getResources      bool
+	specResource      bool
+	reloadInterval    time.Duration
+	reloadErrors      func(err error)
+	resources         *resourceSet
+	maxToolNameLength int
+
+	errorPassThrough   bool
+	envelope           bool
+	envelopeHeaders    []string
+	emptyMessage       string
+	emptyHeaders       []string
+	jsonContent        bool
+	xmlToJSON          bool
+	binaryContentTypes []string
+	decoders           map[string]ContentDecoder
+
+	logger            *slog.Logger
+	metrics           Metrics
+	tracer            Tracer
+	redactHeaders     []string
+	redactQueryParams []string
+	unredacted        []string
+	redactor          *redactor
+}
+
+// OperationConfig overrides adapter settings for a single operation, identified by its operationId
+type OperationConfig struct {
+	// Timeout overrides the request timeout for this operation; zero uses the global timeout
+	Timeout time.Duration
+	// Auth overrides the credentials for this operation; nil uses the global credentials
+	Auth *Auth
+	// MaxResponseBytes overrides the response size limit for this operation; zero uses the global limit
+	MaxResponseBytes int64
+	// RateLimit replaces the global rate limit for this operation; nil uses the global limit
+	RateLimit *RateLimit
+	// CacheTTL overrides how long responses of this operation are cached; zero uses the global TTL
+	// and a negative duration disables caching for this operation
+	CacheTTL time.Duration
+	// Pagination replaces the global pagination setting for this operation; nil uses the global setting
+	Pagination *Pagination
+	// Extract is a JSONPath expression such as "$.data[*].id" selecting the part of a JSON response
+	// returned to the model; the full response is returned if it does not apply
+	Extract string
+	// Template is a text/template rendering the tool result from the response, which it receives
+	// parsed if it is JSON, e.g. "{{range .items}}{{.id}}: {{.name}}\n{{end}}". It is applied after
+	// Extract. Besides the builtins it can use json, join, upper, lower, trim, truncate and default.
+	Template string
+	// Accept overrides the Accept header sent for this operation; empty uses the global setting
+	Accept string
+	// Headers are sent with the requests of this operation, taking precedence over the operation's
+	// x-mcp-headers extension and the global extra headers
+	Headers map[string]string
+	// Range is the Range header sent for this operation unless the call gives its own range argument,
+	// e.g. "bytes=0-65535"; see WithRangeRequests
+	Range string
+}
+
+// newAdapterConfig applies the given options on top of the defaults
+func newAdapterConfig(opts ...AdapterOption) *adapterConfig {
+	cfg := &adapterConfig{
+		timeout:            defaultRequestTimeout,
+		maxInFlight:        defaultMaxConcurrentRequests,
+		operations:         map[string]OperationConfig{},
+		decoders:           map[string]ContentDecoder{},
+		credentials:        map[string]Credentials{},
+		rawPathParams:      map[string]bool{},
+		binaryContentTypes: defaultBinaryContentTypes,
+		serverVariables:    map[string]string{},
+		toolNamer:          defaultToolNamer,
+		maxToolNameLength:  defaultMaxToolNameLength,
+		logger:             slog.New(discardHandler{}),
+		reloadInterval:     defaultReloadInterval,
+		resources:          newResourceSet(),
+		emptyMessage:       defaultEmptyResponseMessage,
+	}
+	for encoding, decoder := range defaultContentDecoders {
+		cfg.decoders[encoding] = decoder
+	}
+
+	// Apply all options
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	cfg.redactor = cfg.newRedactor()
+	cfg.limiters = cfg.newRateLimiters()
+	cfg.inFlight = cfg.newInFlightLimits()
+	cfg.cache = cfg.newResponseCache()
+	cfg.httpClient = cfg.buildHTTPClient()
+	cfg.auth.prepare(cfg.httpClient)
+	cfg.specAuth.prepare(cfg.httpClient)
+	for _, opCfg := range cfg.operations {
+		opCfg.Auth.prepare(cfg.httpClient)
+	}
+
+	return cfg
+}
+
+// setError records an invalid option; only the first error is kept
+func (c *adapterConfig) setError(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// WithUpstreamURL sets the base URL of the upstream API, overriding the servers declared by the
+// specification. A base URL passed to NewMCPFromCustomParser or the loaders takes precedence.
+func WithUpstreamURL(baseURL string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.upstreamURL = baseURL
+	}
+}
+
+// WithExtraHeaders sets headers sent with every upstream request. Repeated options add to the
+// headers; headers passed to NewMCPFromCustomParser, the loaders or NewToolHandler take precedence.
+func WithExtraHeaders(headers map[string]string) AdapterOption {
+	return func(c *adapterConfig) {
+		if c.extraHeaders == nil {
+			c.extraHeaders = map[string]string{}
+		}
+		for name, value := range headers {
+			c.extraHeaders[name] = value
+		}
+	}
+}
+
+// upstream returns the base URL and extra headers to use, given those passed explicitly
+func (c *adapterConfig) upstream(baseURL string, extraHeaders map[string]string) (string, map[string]string) {
+	if baseURL == "" {
+		baseURL = c.upstreamURL
+	}
+	if len(c.extraHeaders) == 0 {
+		return baseURL, extraHeaders
+	}
+	merged := make(map[string]string, len(c.extraHeaders)+len(extraHeaders))
+	for name, value := range c.extraHeaders {
+		merged[name] = value
+	}
+	for name, value := range extraHeaders {
+		merged[name] = value
+	}
+	return baseURL, merged
+}
+
+// WithHTTPClient sets the HTTP client used for all upstream API requests.
+// The client is shared between concurrent tool calls, so its Transport must be safe for concurrent use.
+// Transport options such as WithProxyURL are applied to a copy of the client. A client without a
+// CheckRedirect function is given the default RedirectPolicy, which strips credentials from requests
+// redirected to a different host.
+func WithHTTPClient(client *http.Client) AdapterOption {
+	return func(c *adapterConfig) {
+		c.httpClient = client
+	}
+}
+
+// WithRequestTimeout sets the timeout applied to every upstream API request.
+// A zero or negative duration disables the timeout.
+func WithRequestTimeout(timeout time.Duration) AdapterOption {
+	return func(c *adapterConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxResponseBytes limits how much of a response body is read. Longer text responses are
+// truncated with a note saying so, longer binary responses produce an error result.
+// A zero or negative limit, the default, reads bodies of any size.
+func WithMaxResponseBytes(limit int64) AdapterOption {
+	return func(c *adapterConfig) {
+		c.maxResponse = limit
+	}
+}
+
+// WithErrorPassThrough controls how non-2xx upstream responses are reported.
+// By default they produce an error tool result; when enabled, the response body is returned
+// as a regular result so callers can inspect error payloads themselves.
+func WithErrorPassThrough(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.errorPassThrough = enabled
+	}
+}
+
+// WithUnescapedPathParams disables URL-escaping for the path parameters with the given names.
+// Use it for parameters whose values are intentionally inserted as raw path segments.
+func WithUnescapedPathParams(names ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		for _, name := range names {
+			c.rawPathParams[name] = true
+		}
+	}
+}
+
+// WithOperationConfig sets per-operation overrides for the operation with the given operationId
+func WithOperationConfig(operationID string, opCfg OperationConfig) AdapterOption {
+	return func(c *adapterConfig) {
+		if opCfg.Auth != nil {
+			// Copy the credentials so their token cache is owned by this configuration
+			auth := *opCfg.Auth
+			if err := auth.validate(); err != nil {
+				c.setError(fmt.Errorf("invalid auth for operation %s: %w", operationID, err))
+				return
+			}
+			opCfg.Auth = &auth
+		}
+		if opCfg.Pagination != nil {
+			if err := opCfg.Pagination.validate(); err != nil {
+				c.setError(fmt.Errorf("invalid pagination for operation %s: %w", operationID, err))
+				return
+			}
+		}
+		if opCfg.Extract != "" {
+			if _, err := compileJSONPath(opCfg.Extract); err != nil {
+				c.setError(fmt.Errorf("invalid extraction for operation %s: %w", operationID, err))
+				return
+			}
+		}
+		if opCfg.Range != "" {
+			if _, err := normalizeRange(opCfg.Range, 0); err != nil {
+				c.setError(fmt.Errorf("operation %s: %w", operationID, err))
+				return
+			}
+		}
+		if opCfg.Template != "" {
+			if _, err := compileResponseTemplate(operationID, opCfg.Template); err != nil {
+				c.setError(fmt.Errorf("invalid template for operation %s: %w", operationID, err))
+				return
+			}
+		}
+		c.operations[operationID] = opCfg
+	}
+}
+
+// operation returns the overrides for the given operationId, or the zero value if there are none
+func (c *adapterConfig) operation(operationID string) OperationConfig {
+	return c.operations[operationID]
+}
+
+// timeoutFor returns the request timeout to use for the given operation. An OperationConfig
+// override takes precedence over the operation's x-mcp-timeout extension, which in turn
+// takes precedence over the global timeout.
+func (c *adapterConfig) timeoutFor(opCfg OperationConfig, api APIEndpoint) (time.Duration, error) {
+	if opCfg.Timeout > 0 {
+		return opCfg.Timeout, nil
+	}
+	timeout, err := api.extensionDuration(extensionTimeout)
+	if err != nil || timeout > 0 {
+		return timeout, err
+	}
+	return c.timeout, nil
+}
+
+// maxResponseFor returns the response size limit to use for the given operation
+func (c *adapterConfig) maxResponseFor(opCfg OperationConfig) int64 {
+	if opCfg.MaxResponseBytes > 0 {
+		return opCfg.MaxResponseBytes
+	}
+	return c.maxResponse
+}
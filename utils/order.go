@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// propertyOrderKeyword is added next to the properties of each schema while the document is
+// decoded, listing the property names in the order the document declares them.
+// Decoded JSON objects are unordered maps, so the order would be lost otherwise.
+const propertyOrderKeyword = "x-mcp-property-order"
+
+// isDataKeyword reports whether the value of a key is data, such as a default or an example,
+// rather than part of a schema. Data is left exactly as the document declares it, even if it
+// holds an object with a properties field, e.g. a GeoJSON feature.
+func isDataKeyword(key string) bool {
+	switch key {
+	case "default", "example", "examples", "enum", "const", "value":
+		return true
+	}
+	return strings.HasPrefix(key, "x-")
+}
+
+// decodeOrdered decodes a JSON document like json.Unmarshal into an interface{},
+// recording the declared order of schema properties under propertyOrderKeyword
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	value, _, err := decodeOrderedValue(dec, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return value, nil
+}
+
+// decodeOrderedValue decodes the next value, returning the keys of objects in document order.
+// Nothing is recorded inside data, nor for a properties object, whose keys are property names.
+func decodeOrderedValue(dec *json.Decoder, data bool, names bool) (interface{}, []string, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := map[string]interface{}{}
+		var keys []string
+		var propertyKeys []string
+		for dec.More() {
+			keyToken, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			key := keyToken.(string)
+			valueData := data || (!names && isDataKeyword(key))
+			value, valueKeys, err := decodeOrderedValue(dec, valueData, !valueData && !names && key == "properties")
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, duplicate := object[key]; !duplicate {
+				keys = append(keys, key)
+			}
+			object[key] = value
+			if key == "properties" {
+				propertyKeys = valueKeys
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := object["properties"].(map[string]interface{}); ok && !data && !names && object[propertyOrderKeyword] == nil {
+			object[propertyOrderKeyword] = stringsToValues(propertyKeys)
+		}
+		return object, keys, nil
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, _, err := decodeOrderedValue(dec, data, false)
+			if err != nil {
+				return nil, nil, err
+			}
+			list = append(list, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		return list, nil, nil
+	}
+	return token, nil, nil
+}
+
+// addYAMLPropertyOrder records the declared order of schema properties in a YAML document under
+// propertyOrderKeyword, before the document is converted to JSON and its mappings lose their order
+func addYAMLPropertyOrder(node *yaml.Node) {
+	addYAMLPropertyOrderTo(node, false)
+}
+
+// addYAMLPropertyOrderTo records the property order of the schemas in node, skipping data like
+// decodeOrderedValue. names is set for a properties mapping, whose keys are property names.
+func addYAMLPropertyOrderTo(node *yaml.Node, names bool) {
+	if node == nil {
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		for _, child := range node.Content {
+			addYAMLPropertyOrderTo(child, false)
+		}
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if !names && isDataKeyword(key) {
+			continue
+		}
+		addYAMLPropertyOrderTo(node.Content[i+1], !names && key == "properties")
+	}
+	if names {
+		return
+	}
+
+	properties := yamlMappingValue(node, "properties")
+	if properties == nil || properties.Kind != yaml.MappingNode || yamlMappingValue(node, propertyOrderKeyword) != nil {
+		return
+	}
+	order := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for i := 0; i+1 < len(properties.Content); i += 2 {
+		order.Content = append(order.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: properties.Content[i].Value})
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: propertyOrderKeyword},
+		order,
+	)
+}
+
+// orderProperties returns the property names in the given order, without duplicates and names that
+// are not properties. Properties missing from the order follow in lexical order.
+func orderProperties(order []string, properties map[string]Schema) []string {
+	if len(properties) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(properties))
+	seen := map[string]bool{}
+	for _, name := range order {
+		if _, exists := properties[name]; exists && !seen[name] {
+			result = append(result, name)
+			seen[name] = true
+		}
+	}
+	var rest []string
+	for name := range properties {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(result, rest...)
+}
+
+// stringsToValues converts a list of strings to a decoded JSON array
+func stringsToValues(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+// WithOrderedBody encodes JSON request bodies with their fields in the order the schema declares
+// them, for upstreams or signature schemes that depend on the key order. Fields the schema does not
+// declare follow in lexical order. By default bodies are encoded with sorted keys.
+func WithOrderedBody(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.orderedBody = enabled
+	}
+}
+
+// marshalOrdered encodes a value as JSON, writing object fields in the order of the schema's properties
+func marshalOrdered(value interface{}, schema *Schema, escapeHTML bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeOrdered(&buf, value, schema, escapeHTML); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeOrdered(buf *bytes.Buffer, value interface{}, schema *Schema, escapeHTML bool) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var properties map[string]Schema
+		var declared []string
+		if schema != nil {
+			properties, declared = schema.Properties, schema.PropertyOrder
+		}
+		keys := make([]string, 0, len(v))
+		for _, name := range declared {
+			if _, ok := v[name]; ok {
+				keys = append(keys, name)
+			}
+		}
+		var rest []string
+		for name := range v {
+			if !containsString(declared, name) {
+				rest = append(rest, name)
+			}
+		}
+		sort.Strings(rest)
+		keys = append(keys, rest...)
+
+		buf.WriteByte('{')
+		for i, name := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodedName, _ := encodeJSON(name, escapeHTML)
+			buf.Write(encodedName)
+			buf.WriteByte(':')
+			var prop *Schema
+			if propSchema, ok := properties[name]; ok {
+				prop = &propSchema
+			}
+			if err := writeOrdered(buf, v[name], prop, escapeHTML); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		var items *Schema
+		if schema != nil {
+			items = schema.Items
+		}
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeOrdered(buf, item, items, escapeHTML); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	encoded, err := encodeJSON(value, escapeHTML)
+	if err != nil {
+		return err
+	}
+	buf.Write(encoded)
+	return nil
+}
@@ -0,0 +1,269 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxPages is how many pages are fetched when Pagination.MaxPages is not set
+const defaultMaxPages = 10
+
+// PaginationStrategy selects how the next page of a list endpoint is requested
+type PaginationStrategy int
+
+const (
+	// PaginateLinkHeader follows the URL of the Link: <...>; rel="next" response header
+	PaginateLinkHeader PaginationStrategy = iota
+	// PaginateCursor reads the next cursor from Pagination.CursorField of the response and
+	// sends it in the query parameter Pagination.Param
+	PaginateCursor
+	// PaginatePageNumber increments the page number in the query parameter Pagination.Param,
+	// starting from 1 if the first request did not set it
+	PaginatePageNumber
+	// PaginateOffset advances the offset in the query parameter Pagination.Param by the number
+	// of items received, starting from 0 if the first request did not set it
+	PaginateOffset
+)
+
+// Pagination makes a tool follow the pages of a list endpoint and return all items in one result.
+// Pages are fetched until there is no next page, a page is empty or MaxPages is reached, in which
+// case a note says that more results are available.
+type Pagination struct {
+	Strategy PaginationStrategy
+	// Param is the query parameter carrying the cursor, page number or offset
+	Param string
+	// CursorField is the dot-separated path of the next cursor in the response, e.g. "meta.next_cursor"
+	CursorField string
+	// ItemsField is the dot-separated path of the item array in the response, e.g. "data".
+	// If empty, the response itself must be an array.
+	ItemsField string
+	// MaxPages caps the number of pages fetched per call, the first one included; zero uses the default of 10
+	MaxPages int
+}
+
+// WithPagination follows the pages of every operation's responses.
+// An operation can replace this setting with OperationConfig.Pagination.
+func WithPagination(pagination Pagination) AdapterOption {
+	return func(c *adapterConfig) {
+		if err := pagination.validate(); err != nil {
+			c.setError(err)
+			return
+		}
+		c.pagination = &pagination
+	}
+}
+
+// validate checks that the strategy has the settings it needs
+func (p Pagination) validate() error {
+	switch {
+	case p.MaxPages < 0:
+		return fmt.Errorf("pagination page limit must not be negative, got %d", p.MaxPages)
+	case p.Strategy != PaginateLinkHeader && p.Param == "":
+		return fmt.Errorf("pagination needs the query parameter to set")
+	case p.Strategy == PaginateCursor && p.CursorField == "":
+		return fmt.Errorf("cursor pagination needs the response field holding the cursor")
+	}
+	return nil
+}
+
+// paginationFor returns how responses of the given operation are paginated, or nil if they are not
+func (c *adapterConfig) paginationFor(opCfg OperationConfig) *Pagination {
+	if opCfg.Pagination != nil {
+		return opCfg.Pagination
+	}
+	return c.pagination
+}
+
+// pageFetcher requests a further page and returns its response with the body read
+type pageFetcher func(pageURL string) (*http.Response, []byte, error)
+
+// follow fetches the pages after the first one and returns a single document holding the items of
+// all pages. A first page without the configured item array is returned unchanged.
+func (p Pagination) follow(firstURL string, resp *http.Response, body []byte, fetch pageFetcher) ([]byte, error) {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return body, nil
+	}
+	items, ok := p.items(document)
+	if !ok {
+		return body, nil
+	}
+
+	all := items
+	pageURL, pageDoc := firstURL, document
+	maxPages := p.MaxPages
+	if maxPages == 0 {
+		maxPages = defaultMaxPages
+	}
+	capped := false
+	for pages := 1; ; pages++ {
+		next, err := p.next(pageURL, resp, pageDoc, len(items))
+		if err != nil {
+			return nil, err
+		}
+		if next == "" || len(items) == 0 {
+			break
+		}
+		if pages >= maxPages {
+			capped = true
+			break
+		}
+
+		var pageBody []byte
+		if resp, pageBody, err = fetch(next); err != nil {
+			return nil, fmt.Errorf("page %d: %w", pages+1, err)
+		}
+		if !isSuccessStatus(resp.StatusCode) {
+			return nil, fmt.Errorf("page %d: %s", pages+1, statusErrorMessage(resp, pageBody, 1))
+		}
+		if err := json.Unmarshal(pageBody, &pageDoc); err != nil {
+			return nil, fmt.Errorf("page %d is not valid JSON: %w", pages+1, err)
+		}
+		if items, ok = p.items(pageDoc); !ok {
+			return nil, fmt.Errorf("page %d has no item array", pages+1)
+		}
+		all = append(all, items...)
+		pageURL = next
+	}
+
+	var combined interface{} = all
+	if p.ItemsField != "" {
+		setField(document, p.ItemsField, all)
+		combined = document
+	}
+	result, err := json.Marshal(combined)
+	if err != nil {
+		return nil, err
+	}
+	if capped {
+		result = append(result, fmt.Sprintf("\n\n[Stopped after %d pages of %d items; more results are available]", maxPages, len(all))...)
+	}
+	return result, nil
+}
+
+// items returns the item array of a page
+func (p Pagination) items(document interface{}) ([]interface{}, bool) {
+	value := document
+	if p.ItemsField != "" {
+		var ok bool
+		if value, ok = lookupField(document, p.ItemsField); !ok {
+			return nil, false
+		}
+	}
+	items, ok := value.([]interface{})
+	return items, ok
+}
+
+// next returns the URL of the page after the one fetched from pageURL, or "" if there is none
+func (p Pagination) next(pageURL string, resp *http.Response, document interface{}, count int) (string, error) {
+	switch p.Strategy {
+	case PaginateLinkHeader:
+		link := nextLink(resp.Header)
+		if link == "" {
+			return "", nil
+		}
+		base, err := neturl.Parse(pageURL)
+		if err != nil {
+			return "", err
+		}
+		ref, err := neturl.Parse(link)
+		if err != nil {
+			return "", fmt.Errorf("invalid next link %q: %w", link, err)
+		}
+		return base.ResolveReference(ref).String(), nil
+
+	case PaginateCursor:
+		cursor, _ := lookupField(document, p.CursorField)
+		if cursor == nil || formatScalar(cursor) == "" {
+			return "", nil
+		}
+		return withQueryParam(pageURL, p.Param, formatScalar(cursor))
+
+	default:
+		u, err := neturl.Parse(pageURL)
+		if err != nil {
+			return "", err
+		}
+		current := u.Query().Get(p.Param)
+		position := 0
+		if p.Strategy == PaginatePageNumber {
+			position = 1
+		}
+		if current != "" {
+			if position, err = strconv.Atoi(current); err != nil {
+				return "", fmt.Errorf("query parameter %s is not a number: %q", p.Param, current)
+			}
+		}
+		if p.Strategy == PaginatePageNumber {
+			position++
+		} else {
+			position += count
+		}
+		return withQueryParam(pageURL, p.Param, strconv.Itoa(position))
+	}
+}
+
+// withQueryParam returns rawURL with the query parameter set to value
+func withQueryParam(rawURL, name, value string) (string, error) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(name, value)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// nextLink returns the target of the rel="next" link of a Link header, or "" if there is none
+func nextLink(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(name, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// lookupField returns the value at a dot-separated path of nested JSON objects
+func lookupField(document interface{}, path string) (interface{}, bool) {
+	value := document
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setField replaces the value at a dot-separated path that lookupField has found
+func setField(document interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		document = document.(map[string]interface{})[key]
+	}
+	document.(map[string]interface{})[keys[len(keys)-1]] = value
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_Pagination(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/link":
+			page := r.URL.Query().Get("page")
+			if page == "" {
+				w.Header().Set("Link", `</link?page=2>; rel="next"`)
+				w.Write([]byte(`[1, 2]`))
+				return
+			}
+			w.Write([]byte(`[3]`))
+		case "/cursor":
+			switch r.URL.Query().Get("cursor") {
+			case "":
+				w.Write([]byte(`{"data": [1], "meta": {"next": "b"}}`))
+			case "b":
+				w.Write([]byte(`{"data": [2], "meta": {"next": "c"}}`))
+			default:
+				w.Write([]byte(`{"data": [3], "meta": {"next": null}}`))
+			}
+		case "/offset":
+			var offset int
+			fmt.Sscan(r.URL.Query().Get("offset"), &offset)
+			if offset >= 4 {
+				w.Write([]byte(`[]`))
+				return
+			}
+			fmt.Fprintf(w, `[%d, %d]`, offset, offset+1)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		path       string
+		pagination Pagination
+		want       string
+	}{
+		{"/link", Pagination{}, `[1,2,3]`},
+		{"/cursor", Pagination{Strategy: PaginateCursor, Param: "cursor", CursorField: "meta.next", ItemsField: "data"}, `{"data":[1,2,3],"meta":{"next":"b"}}`},
+		{"/offset", Pagination{Strategy: PaginateOffset, Param: "offset"}, `[0,1,2,3]`},
+		{"/offset", Pagination{Strategy: PaginateOffset, Param: "offset", MaxPages: 1}, "[0,1]\n\n[Stopped after 1 pages of 2 items; more results are available]"},
+	}
+	for _, tt := range tests {
+		handler := NewToolHandler(http.MethodGet, ts.URL+tt.path, nil, WithPagination(tt.pagination))
+		result, err := handler(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; text != tt.want {
+			t.Errorf("%s: got %q; want %q", tt.path, text, tt.want)
+		}
+	}
+}
+
+func Test_NextLink(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.example.com/items?page=1>; rel="prev first", <https://api.example.com/items?page=3>; rel="next"`)
+	if got := nextLink(header); got != "https://api.example.com/items?page=3" {
+		t.Errorf("Got next link %q", got)
+	}
+	if got := nextLink(http.Header{}); got != "" {
+		t.Errorf("Got next link %q for no Link header", got)
+	}
+}
+
+func Test_WithPaginationValidates(t *testing.T) {
+	cfg := newAdapterConfig(WithPagination(Pagination{Strategy: PaginateCursor, Param: "cursor"}))
+	if cfg.err == nil || !strings.Contains(cfg.err.Error(), "cursor") {
+		t.Errorf("Got error %v; want a missing cursor field error", cfg.err)
+	}
+}
@@ -3,9 +3,10 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/lestrrat-go/jsref"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,8 +21,43 @@ type OpenAPIParser interface {
 
 // Server represents a server in the OpenAPI specification
 type Server struct {
-	URL         string `json:"url,omitempty"`
-	Description string `json:"description,omitempty"`
+	URL         string                    `json:"url,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Variables   map[string]ServerVariable `json:"variables,omitempty"` // Values for the {name} placeholders of URL
+}
+
+// ServerVariable represents a variable of a server URL template
+type ServerVariable struct {
+	Default     string   `json:"default,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// expandURL returns the server URL with its variables substituted. Values given in values take
+// precedence over the declared defaults and must be one of the declared enum values, if any.
+// It fails if a variable of the URL has neither a value nor a default.
+func (s Server) expandURL(values map[string]string) (string, error) {
+	url := s.URL
+	for name, variable := range s.Variables {
+		value, ok := values[name]
+		if !ok {
+			value = variable.Default
+		} else if len(variable.Enum) > 0 && !containsString(variable.Enum, value) {
+			return "", fmt.Errorf("invalid value %q for server variable %s of %s, expected one of %s",
+				value, name, s.URL, strings.Join(variable.Enum, ", "))
+		}
+		url = strings.ReplaceAll(url, "{"+name+"}", value)
+	}
+	for name, value := range values {
+		url = strings.ReplaceAll(url, "{"+name+"}", value)
+	}
+
+	if start := strings.Index(url, "{"); start >= 0 {
+		if end := strings.Index(url[start:], "}"); end > 0 {
+			return "", fmt.Errorf("no value for server variable %s of %s", url[start+1:start+end], s.URL)
+		}
+	}
+	return strings.TrimSuffix(url, "/"), nil
 }
 
 // APIInfo contains basic information about the API
@@ -38,9 +74,72 @@ type APIEndpoint struct {
 	Summary     string              `json:"summary,omitempty"`
 	Description string              `json:"description,omitempty"`
 	OperationID string              `json:"operationId,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
 	Parameters  []Parameter         `json:"parameters,omitempty"`
 	RequestBody *RequestBody        `json:"requestBody,omitempty"`
 	Responses   map[string]Response `json:"responses,omitempty"`
+	// Servers overrides the document-level servers and the base URL given by the caller for this
+	// operation, from the operation or its path item; empty if the operation does not declare its own servers
+	Servers []Server `json:"servers,omitempty"`
+	// BaseURL is the absolute base URL of the operation, such as "https://billing.example.com/v1",
+	// for parsers whose operations live on different hosts. It takes precedence over the base URL
+	// given by the caller and over Servers.
+	BaseURL string `json:"baseUrl,omitempty"`
+	// Security lists alternative security requirements; the operation's own requirements
+	// take precedence over the document-level ones. An empty, non-nil slice means no auth.
+	Security []SecurityRequirement `json:"security,omitempty"`
+	// Deprecated reports whether the operation is marked deprecated
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Extensions holds the operation's vendor extensions, the fields prefixed with "x-"
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// SuccessResponseSchema returns the schema of the operation's success response, preferring the
+// lowest 2xx status code and the application/json media type. It returns nil if no 2xx
+// response declares a schema.
+func (e APIEndpoint) SuccessResponseSchema() *Schema {
+	statuses := make([]string, 0, len(e.Responses))
+	for status := range e.Responses {
+		// Matches explicit codes such as 200 as well as the 2XX range
+		if len(status) == 3 && status[0] == '2' {
+			statuses = append(statuses, status)
+		}
+	}
+	// Explicit codes sort before the 2XX range
+	sort.Strings(statuses)
+
+	for _, status := range statuses {
+		content := e.Responses[status].Content
+		if mediaType, ok := content["application/json"]; ok && mediaType.Schema != nil {
+			return mediaType.Schema
+		}
+		names := make([]string, 0, len(content))
+		for name := range content {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if strings.Contains(name, "json") && content[name].Schema != nil {
+				return content[name].Schema
+			}
+		}
+	}
+	return nil
+}
+
+// SecurityRequirement maps security scheme names to the scopes required from them.
+// All schemes of one requirement must be satisfied together.
+type SecurityRequirement map[string][]string
+
+// SecurityScheme represents a security scheme declared in components.securitySchemes
+type SecurityScheme struct {
+	Type         string `json:"type,omitempty"`         // apiKey, http, oauth2 or openIdConnect
+	Scheme       string `json:"scheme,omitempty"`       // HTTP auth scheme for type http, e.g. bearer or basic
+	BearerFormat string `json:"bearerFormat,omitempty"` // Hint about the bearer token format
+	Name         string `json:"name,omitempty"`         // Header, query or cookie name for type apiKey
+	In           string `json:"in,omitempty"`           // Location of the API key: header, query or cookie
+	TokenURL     string `json:"tokenUrl,omitempty"`     // Token URL of the oauth2 clientCredentials flow
+	Description  string `json:"description,omitempty"`
 }
 
 // Parameter represents an API parameter
@@ -50,6 +149,11 @@ type Parameter struct {
 	Required    bool    `json:"required,omitempty"`
 	Description string  `json:"description,omitempty"`
 	Schema      *Schema `json:"schema,omitempty"`
+	Style       string  `json:"style,omitempty"`   // Serialization style, e.g. form, spaceDelimited, pipeDelimited or deepObject
+	Explode     *bool   `json:"explode,omitempty"` // Whether arrays and objects generate separate parameters; nil uses the style default
+	// AllowReserved marks a path parameter whose value may span several segments, e.g. a file path.
+	// Its slashes are kept while each segment is still escaped.
+	AllowReserved bool `json:"allowReserved,omitempty"`
 }
 
 // RequestBody represents the request body of an API endpoint
@@ -60,7 +164,9 @@ type RequestBody struct {
 
 // MediaType represents a media type of a request or response
 type MediaType struct {
-	Schema *Schema `json:"schema,omitempty"`
+	Schema   *Schema                `json:"schema,omitempty"`
+	Example  interface{}            `json:"example,omitempty"`
+	Examples map[string]interface{} `json:"examples,omitempty"` // Values of the named examples
 }
 
 // Response represents an API response
@@ -79,67 +185,132 @@ type Schema struct {
 	Properties  map[string]Schema `json:"properties,omitempty"`
 	Items       *Schema           `json:"items,omitempty"`
 	Required    []string          `json:"required,omitempty"`
-	Ref         string
+	Minimum     *float64          `json:"minimum,omitempty"`
+	Maximum     *float64          `json:"maximum,omitempty"`
+	MultipleOf  *float64          `json:"multipleOf,omitempty"`
+	MinLength   *int              `json:"minLength,omitempty"`
+	MaxLength   *int              `json:"maxLength,omitempty"`
+	Pattern     string            `json:"pattern,omitempty"`
+	Example     interface{}       `json:"example,omitempty"`
+	// PropertyOrder lists the property names in the order the document declares them
+	PropertyOrder []string   `json:"-"`
+	Ref           string     `json:"-"` // Set when a $ref could not be expanded, e.g. because it is circular or external
+	Nullable      bool       `json:"-"` // Whether null is accepted, from OpenAPI 3.0 nullable or a 3.1 type array containing "null"
+	XML           *XMLObject `json:"-"` // How the value is represented in XML, from the xml keyword
+}
+
+// MarshalJSON encodes the schema as JSON Schema, expressing nullable types as a type array
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if !s.Nullable || s.Type == "" {
+		return json.Marshal(plain(s))
+	}
+	return json.Marshal(struct {
+		plain
+		Type []string `json:"type"`
+	}{plain(s), []string{s.Type, "null"}})
+}
+
+// jsonType returns the value of the JSON Schema type keyword for this schema
+func (s Schema) jsonType() interface{} {
+	if s.Nullable && s.Type != "" {
+		return []string{s.Type, "null"}
+	}
+	return s.Type
 }
 
 // SimpleOpenAPIParser is a simple parser for OpenAPI specifications
 type SimpleOpenAPIParser struct {
 	document map[string]interface{}
+
+	source     []byte // Document the parser was created from
+	sourceType string // Media type of source
 }
 
-// NewSimpleOpenAPIParser creates a new OpenAPI parser
+// NewSimpleOpenAPIParser creates a new OpenAPI parser.
+// Both OpenAPI 3.x and Swagger 2.0 documents are supported.
 func NewSimpleOpenAPIParser(data []byte) (*SimpleOpenAPIParser, error) {
 	jsonString := string(data)
 
-	// Parse JSON into interface{}
-	var v interface{}
-	if err := json.Unmarshal([]byte(jsonString), &v); err != nil {
+	// Parse JSON into interface{}, keeping the order of schema properties
+	v, err := decodeOrdered([]byte(jsonString))
+	if err != nil {
 		fmt.Println("Error unmarshaling JSON:", err)
 		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
 
-	// Create a new resolver
-	resolver := jsref.New()
-
-	resolved, err := resolver.Resolve(v, "#", jsref.WithRecursiveResolution(true))
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve references: %w", err)
-	}
+	// Expand local references; circular ones are kept as unresolved $ref objects
+	resolved := resolveRefs(v)
 
 	resolvedMap, ok := resolved.(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("failed to convert resolved to map[string]interface{}")
 	}
+
+	// Swagger 2.0 documents are mapped onto the OpenAPI 3 structure
+	if isSwagger2(resolvedMap) {
+		resolvedMap = convertSwagger2(resolvedMap)
+	}
 	parser := &SimpleOpenAPIParser{
-		document: resolvedMap,
+		document:   resolvedMap,
+		source:     data,
+		sourceType: "application/json",
 	}
 
 	return parser, nil
 }
 
+// Source returns the document the parser was created from, as JSON or YAML, and its media type
+func (p *SimpleOpenAPIParser) Source() ([]byte, string) {
+	return p.source, p.sourceType
+}
+
 // Servers returns the servers in the OpenAPI specification
 func (p *SimpleOpenAPIParser) Servers() []Server {
+	serversObj, _ := p.document["servers"].([]interface{})
+	return parseServers(serversObj)
+}
+
+// parseServers parses a list of server objects
+func parseServers(serversObj []interface{}) []Server {
 	servers := []Server{}
 
-	if serversObj, ok := p.document["servers"].([]interface{}); ok {
-		for _, server := range serversObj {
-			serverObj, ok := server.(map[string]interface{})
-			if !ok {
-				continue
-			}
+	for _, server := range serversObj {
+		serverObj, ok := server.(map[string]interface{})
+		if !ok {
+			continue
+		}
 
-			server := Server{}
+		server := Server{}
 
-			if url, ok := serverObj["url"].(string); ok {
-				server.URL = url
-			}
+		if url, ok := serverObj["url"].(string); ok {
+			server.URL = url
+		}
 
-			if description, ok := serverObj["description"].(string); ok {
-				server.Description = description
-			}
+		if description, ok := serverObj["description"].(string); ok {
+			server.Description = description
+		}
 
-			servers = append(servers, server)
+		if variables, ok := serverObj["variables"].(map[string]interface{}); ok {
+			server.Variables = make(map[string]ServerVariable)
+			for name, variable := range variables {
+				variableObj, ok := variable.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				serverVariable := ServerVariable{}
+				serverVariable.Default = formatScalar(variableObj["default"])
+				serverVariable.Description, _ = variableObj["description"].(string)
+				if enum, ok := variableObj["enum"].([]interface{}); ok {
+					for _, value := range enum {
+						serverVariable.Enum = append(serverVariable.Enum, formatScalar(value))
+					}
+				}
+				server.Variables[name] = serverVariable
+			}
 		}
+
+		servers = append(servers, server)
 	}
 
 	return servers
@@ -166,22 +337,95 @@ func (p *SimpleOpenAPIParser) Info() APIInfo {
 	return info
 }
 
-// APIs returns information about all API endpoints
+// SecuritySchemes returns the security schemes declared in components.securitySchemes
+func (p *SimpleOpenAPIParser) SecuritySchemes() map[string]SecurityScheme {
+	schemes := map[string]SecurityScheme{}
+
+	components, ok := p.document["components"].(map[string]interface{})
+	if !ok {
+		return schemes
+	}
+	schemesObj, ok := components["securitySchemes"].(map[string]interface{})
+	if !ok {
+		return schemes
+	}
+
+	for name, schemeObj := range schemesObj {
+		schemeMap, ok := schemeObj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		scheme := SecurityScheme{}
+		scheme.Type, _ = schemeMap["type"].(string)
+		scheme.Scheme, _ = schemeMap["scheme"].(string)
+		scheme.BearerFormat, _ = schemeMap["bearerFormat"].(string)
+		scheme.Name, _ = schemeMap["name"].(string)
+		scheme.In, _ = schemeMap["in"].(string)
+		scheme.Description, _ = schemeMap["description"].(string)
+
+		if flows, ok := schemeMap["flows"].(map[string]interface{}); ok {
+			if clientCredentials, ok := flows["clientCredentials"].(map[string]interface{}); ok {
+				scheme.TokenURL, _ = clientCredentials["tokenUrl"].(string)
+			}
+		}
+
+		schemes[name] = scheme
+	}
+
+	return schemes
+}
+
+// parseSecurity parses a list of security requirement objects
+func parseSecurity(securityObj []interface{}) []SecurityRequirement {
+	requirements := []SecurityRequirement{}
+	for _, requirementObj := range securityObj {
+		requirementMap, ok := requirementObj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		requirement := SecurityRequirement{}
+		for name, scopesObj := range requirementMap {
+			scopes := []string{}
+			if scopesList, ok := scopesObj.([]interface{}); ok {
+				for _, scope := range scopesList {
+					if scopeStr, ok := scope.(string); ok {
+						scopes = append(scopes, scopeStr)
+					}
+				}
+			}
+			requirement[name] = scopes
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements
+}
+
+// APIs returns information about all API endpoints, ordered by path and method
 func (p *SimpleOpenAPIParser) APIs() []APIEndpoint {
 	var endpoints []APIEndpoint
 
+	// Document-level security applies to every operation that does not declare its own
+	var rootSecurity []SecurityRequirement
+	if securityObj, ok := p.document["security"].([]interface{}); ok {
+		rootSecurity = parseSecurity(securityObj)
+	}
+
 	paths, ok := p.document["paths"].(map[string]interface{})
 	if !ok {
 		return endpoints
 	}
 
-	for path, pathItem := range paths {
-		pathItemObj, ok := pathItem.(map[string]interface{})
+	// Maps are iterated in sorted order, so the endpoints are the same from one run to the next
+	for _, path := range sortedKeys(paths) {
+		pathItemObj, ok := paths[path].(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		for method, operation := range pathItemObj {
+		for _, method := range sortedKeys(pathItemObj) {
+			operation := pathItemObj[method]
 			// Skip non-HTTP method fields
 			if !isHTTPMethod(method) {
 				continue
@@ -210,6 +454,32 @@ func (p *SimpleOpenAPIParser) APIs() []APIEndpoint {
 				endpoint.OperationID = operationId
 			}
 
+			if deprecated, ok := operationObj["deprecated"].(bool); ok {
+				endpoint.Deprecated = deprecated
+			}
+
+			endpoint.Extensions = parseExtensions(operationObj)
+
+			if tags, ok := operationObj["tags"].([]interface{}); ok {
+				for _, tag := range tags {
+					if tagStr, ok := tag.(string); ok {
+						endpoint.Tags = append(endpoint.Tags, tagStr)
+					}
+				}
+			}
+
+			// Operation servers take precedence over path item servers
+			if serversObj, ok := operationObj["servers"].([]interface{}); ok {
+				endpoint.Servers = parseServers(serversObj)
+			} else if serversObj, ok := pathItemObj["servers"].([]interface{}); ok {
+				endpoint.Servers = parseServers(serversObj)
+			}
+
+			endpoint.Security = rootSecurity
+			if securityObj, ok := operationObj["security"].([]interface{}); ok {
+				endpoint.Security = parseSecurity(securityObj)
+			}
+
 			// Parse parameters
 			if parameters, ok := operationObj["parameters"].([]interface{}); ok {
 				for _, param := range parameters {
@@ -227,22 +497,38 @@ func (p *SimpleOpenAPIParser) APIs() []APIEndpoint {
 					if in, ok := paramObj["in"].(string); ok {
 						parameter.In = in
 						if in == "path" {
-						    parameter.Required = true
-                        }
+							parameter.Required = true
+						}
 					}
 
 					if required, ok := paramObj["required"].(bool); ok {
-                        if parameter.In != "path" {
-                            parameter.Required = required
-                        }
+						if parameter.In != "path" {
+							parameter.Required = required
+						}
 					}
 
 					if description, ok := paramObj["description"].(string); ok {
 						parameter.Description = description
 					}
 
+					if style, ok := paramObj["style"].(string); ok {
+						parameter.Style = style
+					}
+
+					if explode, ok := paramObj["explode"].(bool); ok {
+						parameter.Explode = &explode
+					}
+
+					if allowReserved, ok := paramObj["allowReserved"].(bool); ok {
+						parameter.AllowReserved = allowReserved
+					}
+
 					if schemaObj, ok := paramObj["schema"].(map[string]interface{}); ok {
 						schema := p.parseSchema(schemaObj)
+						// An example given on the parameter takes precedence over the one of its schema
+						if example, ok := parameterExample(paramObj); ok {
+							schema.Example = example
+						}
 						parameter.Schema = &schema
 					}
 
@@ -263,14 +549,7 @@ func (p *SimpleOpenAPIParser) APIs() []APIEndpoint {
 				if contentObj, ok := requestBodyObj["content"].(map[string]interface{}); ok {
 					for mediaTypeName, mediaTypeObj := range contentObj {
 						if mediaTypeMap, ok := mediaTypeObj.(map[string]interface{}); ok {
-							mediaType := MediaType{}
-
-							if schemaObj, ok := mediaTypeMap["schema"].(map[string]interface{}); ok {
-								schema := p.parseSchema(schemaObj)
-								mediaType.Schema = &schema
-							}
-
-							requestBody.Content[mediaTypeName] = mediaType
+							requestBody.Content[mediaTypeName] = p.parseMediaType(mediaTypeMap)
 						}
 					}
 				}
@@ -293,14 +572,7 @@ func (p *SimpleOpenAPIParser) APIs() []APIEndpoint {
 						if contentObj, ok := responseMap["content"].(map[string]interface{}); ok {
 							for mediaTypeName, mediaTypeObj := range contentObj {
 								if mediaTypeMap, ok := mediaTypeObj.(map[string]interface{}); ok {
-									mediaType := MediaType{}
-
-									if schemaObj, ok := mediaTypeMap["schema"].(map[string]interface{}); ok {
-										schema := p.parseSchema(schemaObj)
-										mediaType.Schema = &schema
-									}
-
-									response.Content[mediaTypeName] = mediaType
+									response.Content[mediaTypeName] = p.parseMediaType(mediaTypeMap)
 								}
 							}
 						}
@@ -317,14 +589,89 @@ func (p *SimpleOpenAPIParser) APIs() []APIEndpoint {
 	return endpoints
 }
 
+// parameterExample returns the example of a parameter object, from its example field or else
+// the first of its named examples
+func parameterExample(paramObj map[string]interface{}) (interface{}, bool) {
+	if example, ok := paramObj["example"]; ok {
+		return example, true
+	}
+	examples, ok := paramObj["examples"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	for _, name := range sortedKeys(examples) {
+		// External examples only have a URL, which is not fetched
+		if exampleMap, ok := examples[name].(map[string]interface{}); ok {
+			if value, ok := exampleMap["value"]; ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseMediaType parses the schema and examples of a request or response media type
+func (p *SimpleOpenAPIParser) parseMediaType(mediaTypeObj map[string]interface{}) MediaType {
+	mediaType := MediaType{}
+	if schemaObj, ok := mediaTypeObj["schema"].(map[string]interface{}); ok {
+		schema := p.parseSchema(schemaObj)
+		mediaType.Schema = &schema
+	}
+	if example, ok := mediaTypeObj["example"]; ok {
+		mediaType.Example = example
+	}
+	if examples, ok := mediaTypeObj["examples"].(map[string]interface{}); ok {
+		mediaType.Examples = map[string]interface{}{}
+		for name, exampleObj := range examples {
+			// External examples only have a URL, which is not fetched
+			if exampleMap, ok := exampleObj.(map[string]interface{}); ok {
+				if value, ok := exampleMap["value"]; ok {
+					mediaType.Examples[name] = value
+				}
+			}
+		}
+	}
+	return mediaType
+}
+
 // parseSchema parses a JSON schema object
 func (p *SimpleOpenAPIParser) parseSchema(schemaObj map[string]interface{}) Schema {
 	schema := Schema{
 		Properties: make(map[string]Schema),
 	}
 
-	if t, ok := schemaObj["type"].(string); ok {
-		schema.Type = t
+	if ref, ok := schemaObj["$ref"].(string); ok {
+		schema.Ref = ref
+	}
+
+	switch t := schemaObj["type"].(type) {
+	case string:
+		if t == "null" {
+			schema.Nullable = true
+		} else {
+			schema.Type = t
+		}
+	case []interface{}:
+		// OpenAPI 3.1 lists alternative types, with "null" marking a nullable value.
+		// A single remaining type is kept; several types cannot be expressed and are left open.
+		var types []string
+		for _, item := range t {
+			if typeName, ok := item.(string); ok {
+				if typeName == "null" {
+					schema.Nullable = true
+				} else {
+					types = append(types, typeName)
+				}
+			}
+		}
+		if len(types) == 1 {
+			schema.Type = types[0]
+		}
+	}
+
+	// OpenAPI 3.0 marks nullable values with a separate keyword
+	if nullable, ok := schemaObj["nullable"].(bool); ok && nullable {
+		schema.Nullable = true
 	}
 
 	if format, ok := schemaObj["format"].(string); ok {
@@ -335,14 +682,38 @@ func (p *SimpleOpenAPIParser) parseSchema(schemaObj map[string]interface{}) Sche
 		schema.Description = description
 	}
 
+	// Validation keywords, passed on to clients as hints
+	schema.Minimum = numberKeyword(schemaObj, "minimum")
+	schema.Maximum = numberKeyword(schemaObj, "maximum")
+	schema.MultipleOf = numberKeyword(schemaObj, "multipleOf")
+	schema.MinLength = integerKeyword(schemaObj, "minLength")
+	schema.MaxLength = integerKeyword(schemaObj, "maxLength")
+	if pattern, ok := schemaObj["pattern"].(string); ok {
+		schema.Pattern = pattern
+	}
+
+	if example, ok := schemaObj["example"]; ok {
+		schema.Example = example
+	} else if examples, ok := schemaObj["examples"].([]interface{}); ok && len(examples) > 0 {
+		// JSON Schema, as used by OpenAPI 3.1, lists examples in an array
+		schema.Example = examples[0]
+	}
+
 	if defaultValue, ok := schemaObj["default"]; ok {
 		schema.Default = defaultValue
 	}
 
+	schema.XML = parseXMLObject(schemaObj)
+
 	if enum, ok := schemaObj["enum"].([]interface{}); ok {
 		schema.Enum = enum
 	}
 
+	// A const value is a single-valued enum
+	if constValue, ok := schemaObj["const"]; ok {
+		schema.Enum = []interface{}{constValue}
+	}
+
 	if required, ok := schemaObj["required"].([]interface{}); ok {
 		for _, req := range required {
 			if reqStr, ok := req.(string); ok {
@@ -360,6 +731,7 @@ func (p *SimpleOpenAPIParser) parseSchema(schemaObj map[string]interface{}) Sche
 			}
 		}
 	}
+	schema.PropertyOrder = stringList(schemaObj[propertyOrderKeyword])
 
 	// Handle items for array type
 	if items, ok := schemaObj["items"].(map[string]interface{}); ok {
@@ -367,9 +739,247 @@ func (p *SimpleOpenAPIParser) parseSchema(schemaObj map[string]interface{}) Sche
 		schema.Items = &itemsSchema
 	}
 
+	// Handle schema composition
+	if allOf, ok := schemaObj["allOf"].([]interface{}); ok {
+		subs := p.parseSubschemas(allOf)
+		nullable := len(subs) > 0
+		for _, sub := range subs {
+			mergeSchema(&schema, sub, true)
+			nullable = nullable && sub.Nullable
+		}
+		// null is only valid if every subschema accepts it
+		if nullable {
+			schema.Nullable = true
+		}
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if alternatives, ok := schemaObj[keyword].([]interface{}); ok {
+			mergeAlternatives(&schema, p.parseSubschemas(alternatives))
+		}
+	}
+	if discriminator, ok := schemaObj["discriminator"].(map[string]interface{}); ok {
+		applyDiscriminator(&schema, discriminator)
+	}
+	schema.PropertyOrder = orderProperties(schema.PropertyOrder, schema.Properties)
+
 	return schema
 }
 
+// numberKeyword returns the value of a numeric schema keyword, or nil if it is absent
+func numberKeyword(schemaObj map[string]interface{}, keyword string) *float64 {
+	var value float64
+	switch n := schemaObj[keyword].(type) {
+	case float64:
+		value = n
+	case int:
+		value = float64(n)
+	case int64:
+		value = float64(n)
+	default:
+		return nil
+	}
+	return &value
+}
+
+// integerKeyword returns the value of an integer schema keyword, or nil if it is absent
+func integerKeyword(schemaObj map[string]interface{}, keyword string) *int {
+	number := numberKeyword(schemaObj, keyword)
+	if number == nil {
+		return nil
+	}
+	value := int(*number)
+	return &value
+}
+
+// parseSubschemas parses the schemas listed by a composition keyword
+func (p *SimpleOpenAPIParser) parseSubschemas(list []interface{}) []Schema {
+	var schemas []Schema
+	for _, item := range list {
+		if itemObj, ok := item.(map[string]interface{}); ok {
+			schemas = append(schemas, p.parseSchema(itemObj))
+		}
+	}
+	return schemas
+}
+
+// mergeSchema merges the properties of sub into schema. Keywords already set on schema win.
+// When required is true, as for allOf, the required properties of sub are required by schema
+// as well, and the values allowed by both enums are kept if both schemas declare one.
+func mergeSchema(schema *Schema, sub Schema, required bool) {
+	if schema.Type == "" {
+		schema.Type = sub.Type
+	}
+	if schema.Format == "" {
+		schema.Format = sub.Format
+	}
+	if schema.Description == "" {
+		schema.Description = sub.Description
+	}
+	if schema.Items == nil {
+		schema.Items = sub.Items
+	}
+	if schema.Minimum == nil {
+		schema.Minimum = sub.Minimum
+	}
+	if schema.Maximum == nil {
+		schema.Maximum = sub.Maximum
+	}
+	if schema.MultipleOf == nil {
+		schema.MultipleOf = sub.MultipleOf
+	}
+	if schema.MinLength == nil {
+		schema.MinLength = sub.MinLength
+	}
+	if schema.MaxLength == nil {
+		schema.MaxLength = sub.MaxLength
+	}
+	if schema.Pattern == "" {
+		schema.Pattern = sub.Pattern
+	}
+	if schema.XML == nil {
+		schema.XML = sub.XML
+	}
+	if schema.Default == nil {
+		schema.Default = sub.Default
+	}
+	if schema.Example == nil {
+		schema.Example = sub.Example
+	}
+	if required && sub.Enum != nil {
+		if schema.Enum == nil {
+			schema.Enum = sub.Enum
+		} else {
+			allowed := []interface{}{}
+			for _, value := range schema.Enum {
+				if containsValue(sub.Enum, value) {
+					allowed = append(allowed, value)
+				}
+			}
+			schema.Enum = allowed
+		}
+	}
+	for name, prop := range sub.Properties {
+		if _, exists := schema.Properties[name]; !exists {
+			schema.Properties[name] = prop
+		}
+	}
+	schema.PropertyOrder = append(schema.PropertyOrder, sub.PropertyOrder...)
+	if required {
+		for _, name := range sub.Required {
+			if !isRequiredField(name, schema.Required) {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+}
+
+// mergeAlternatives flattens oneOf/anyOf alternatives into a union of their properties.
+// A property is only required if every alternative requires it, and the type is only
+// kept if all alternatives agree on it.
+func mergeAlternatives(schema *Schema, alternatives []Schema) {
+	// A {"type": "null"} alternative only makes the schema nullable
+	var nonNull []Schema
+	for _, alt := range alternatives {
+		if alt.Type == "" && alt.Nullable && len(alt.Properties) == 0 {
+			schema.Nullable = true
+			continue
+		}
+		nonNull = append(nonNull, alt)
+	}
+	alternatives = nonNull
+	if len(alternatives) == 0 {
+		return
+	}
+
+	sameType := true
+	for _, alt := range alternatives {
+		if alt.Type != alternatives[0].Type {
+			sameType = false
+		}
+	}
+	explicitType := schema.Type != ""
+
+	for _, alt := range alternatives {
+		if !sameType {
+			alt.Type = ""
+		}
+		mergeSchema(schema, alt, false)
+	}
+	if !sameType && !explicitType {
+		// Alternatives with different types cannot be described by a single type
+		schema.Type = ""
+	}
+
+	// Collect the enum values of properties shared by several alternatives,
+	// e.g. the constant type field of each variant of a polymorphic schema
+	for name, prop := range schema.Properties {
+		for _, alt := range alternatives {
+			for _, value := range alt.Properties[name].Enum {
+				if !containsValue(prop.Enum, value) {
+					prop.Enum = append(prop.Enum, value)
+				}
+			}
+		}
+		schema.Properties[name] = prop
+	}
+
+	for _, name := range alternatives[0].Required {
+		requiredByAll := true
+		for _, alt := range alternatives[1:] {
+			if !isRequiredField(name, alt.Required) {
+				requiredByAll = false
+				break
+			}
+		}
+		if requiredByAll && !isRequiredField(name, schema.Required) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// containsValue reports whether values contains value. Enum values may be arrays or objects,
+// which cannot be compared with ==.
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDiscriminator surfaces the discriminator property of a polymorphic schema as a required
+// string field, enumerating the known values from the mapping or from the alternatives' enums
+func applyDiscriminator(schema *Schema, discriminator map[string]interface{}) {
+	name, ok := discriminator["propertyName"].(string)
+	if !ok || name == "" {
+		return
+	}
+
+	prop := schema.Properties[name]
+	if prop.Type == "" {
+		prop.Type = "string"
+	}
+
+	var values []interface{}
+	if mapping, ok := discriminator["mapping"].(map[string]interface{}); ok {
+		for value := range mapping {
+			values = append(values, value)
+		}
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].(string) < values[j].(string)
+		})
+	}
+	if len(values) > 0 {
+		prop.Enum = values
+	}
+	schema.Properties[name] = prop
+
+	if !isRequiredField(name, schema.Required) {
+		schema.Required = append(schema.Required, name)
+	}
+}
+
 func isHTTPMethod(method string) bool {
 	method = strings.ToLower(method)
 	return method == "get" || method == "post" || method == "put" ||
@@ -384,7 +994,13 @@ func ParseOpenAPIFromYAML(data []byte) (OpenAPIParser, error) {
 	var yamlObj interface{}
 
 	// Unmarshal YAML to an interface
-	if err := yaml.Unmarshal(data, &yamlObj); err != nil {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	}
+	quoteVersionFields(&document)
+	addYAMLPropertyOrder(&document)
+	if err := document.Decode(&yamlObj); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
 	}
 
@@ -394,15 +1010,45 @@ func ParseOpenAPIFromYAML(data []byte) (OpenAPIParser, error) {
 		return nil, fmt.Errorf("failed to convert YAML to JSON: %w", err)
 	}
 
-	// Use the JSON data for parsing
-	data = jsonData
-	parser, err := NewSimpleOpenAPIParser(data)
+	// Use the JSON data for parsing, keeping the YAML document as the source
+	parser, err := NewSimpleOpenAPIParser(jsonData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse OpenAPI specification: %w", err)
 	}
+	parser.source, parser.sourceType = data, "application/yaml"
 	return parser, nil
 }
 
+// quoteVersionFields makes sure version numbers written without quotes, such as "swagger: 2.0"
+// or "version: 1.0", are decoded as strings rather than numbers that lose their formatting
+func quoteVersionFields(document *yaml.Node) {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return
+	}
+	root := document.Content[0]
+	for _, field := range []string{"openapi", "swagger"} {
+		if node := yamlMappingValue(root, field); node != nil && node.Kind == yaml.ScalarNode {
+			node.Tag = "!!str"
+		}
+	}
+	if node := yamlMappingValue(yamlMappingValue(root, "info"), "version"); node != nil && node.Kind == yaml.ScalarNode {
+		node.Tag = "!!str"
+	}
+}
+
+// yamlMappingValue returns the value node stored under key in a YAML mapping, or nil
+func yamlMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // ParseOpenAPIFromJSON parses an OpenAPI specification from JSON
 func ParseOpenAPIFromJSON(data []byte) (OpenAPIParser, error) {
 	return NewSimpleOpenAPIParser(data)
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -66,3 +67,45 @@ func Test_ParseYamlToJson(t *testing.T) {
 
 	fmt.Println(string(prettyJSON))
 }
+
+func Test_ParameterExamples(t *testing.T) {
+	spec := []byte(`{
+		"openapi": "3.0.0",
+		"paths": {
+			"/users/{id}": {
+				"post": {
+					"operationId": "updateUser",
+					"parameters": [
+						{"name": "id", "in": "path", "schema": {"type": "string", "example": "u_1"}, "example": "u_42"},
+						{"name": "since", "in": "query", "schema": {"type": "string"}, "examples": {"recent": {"value": "2024-01-01"}}},
+						{"name": "limit", "in": "query", "schema": {"type": "integer", "example": 10}}
+					],
+					"requestBody": {"content": {"application/json": {"schema": {
+						"type": "object",
+						"properties": {"bio": {"type": "string", "description": "About the user", "example": "` + strings.Repeat("a", 150) + `"}}
+					}}}}
+				}
+			}
+		}
+	}`)
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{"id": "u_42", "since": "2024-01-01", "limit": float64(10)}
+	for _, param := range parser.APIs()[0].Parameters {
+		if param.Schema.Example != want[param.Name] {
+			t.Errorf("%s: got example %v; want %v", param.Name, param.Schema.Example, want[param.Name])
+		}
+	}
+
+	bio := parser.APIs()[0].RequestBody.Content["application/json"].Schema.Properties["bio"]
+	description := schemaProperty(bio, bio.Description, 0)["description"].(string)
+	if !strings.HasPrefix(description, `About the user Example: "aaa`) || !strings.HasSuffix(description, "…") {
+		t.Errorf("Unexpected description: %q", description)
+	}
+	if got := schemaProperty(Schema{Type: "integer", Example: 10.0}, "", 0)["description"]; got != "Example: 10" {
+		t.Errorf("Unexpected description: %v", got)
+	}
+}
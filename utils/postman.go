@@ -0,0 +1,579 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// postmanVariablePattern matches {{name}} placeholders of Postman variables
+var postmanVariablePattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// unquotedVariablePattern matches JSON strings, which are kept, and placeholders outside of them
+var unquotedVariablePattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|\{\{[^{}]+\}\}`)
+
+// postmanCollection is a Postman collection in the v2.1 format
+type postmanCollection struct {
+	Info struct {
+		Name        string      `json:"name"`
+		Description postmanText `json:"description"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+}
+
+// postmanItem is a request or a folder of items
+type postmanItem struct {
+	Name        string            `json:"name"`
+	Description postmanText       `json:"description"`
+	Item        []postmanItem     `json:"item"`
+	Request     json.RawMessage   `json:"request"` // A request object or just its URL
+	Response    []postmanResponse `json:"response"`
+	Auth        *postmanAuth      `json:"auth"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Description postmanText       `json:"description"`
+	Header      []postmanKeyValue `json:"header"`
+	URL         postmanURL        `json:"url"`
+	Body        *postmanBody      `json:"body"`
+	Auth        *postmanAuth      `json:"auth"`
+}
+
+type postmanKeyValue struct {
+	Key         string      `json:"key"`
+	Value       interface{} `json:"value"`
+	Type        string      `json:"type"` // "file" for form data file fields
+	Disabled    bool        `json:"disabled"`
+	Description postmanText `json:"description"`
+}
+
+// value returns the value as a string
+func (kv postmanKeyValue) value() string {
+	if kv.Value == nil {
+		return ""
+	}
+	return formatScalar(kv.Value)
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"` // raw, urlencoded, formdata, file or graphql
+	Raw        string            `json:"raw"`
+	URLEncoded []postmanKeyValue `json:"urlencoded"`
+	FormData   []postmanKeyValue `json:"formdata"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"` // json, xml, text, html or javascript
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanResponse struct {
+	Code   int               `json:"code"`
+	Header []postmanKeyValue `json:"header"`
+	Body   string            `json:"body"`
+}
+
+// postmanAuth is the authentication of a request, folder or collection. Its parameters are listed
+// under the name of its type, e.g. "bearer": [{"key": "token", "value": "{{token}}"}].
+type postmanAuth struct {
+	Type   string
+	Params map[string]string
+}
+
+func (a *postmanAuth) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw["type"], &a.Type); err != nil {
+		return fmt.Errorf("invalid auth type: %w", err)
+	}
+	var params []postmanKeyValue
+	if len(raw[a.Type]) > 0 {
+		if err := json.Unmarshal(raw[a.Type], &params); err != nil {
+			return fmt.Errorf("invalid %s auth: %w", a.Type, err)
+		}
+	}
+	a.Params = map[string]string{}
+	for _, param := range params {
+		a.Params[param.Key] = param.value()
+	}
+	return nil
+}
+
+// postmanText is a description, given as a string or as an object with the text in "content"
+type postmanText string
+
+func (t *postmanText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = postmanText(text)
+		return nil
+	}
+	var object struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*t = postmanText(object.Content)
+	return nil
+}
+
+// postmanURL is the URL of a request, given as a string or as an object with its parts
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol"`
+	Host     postmanParts      `json:"host"`
+	Port     string            `json:"port"`
+	Path     postmanParts      `json:"path"`
+	Query    []postmanKeyValue `json:"query"`
+	Variable []postmanKeyValue `json:"variable"` // Values of the :name path variables
+}
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = parsePostmanRawURL(raw)
+		return nil
+	}
+	type plain postmanURL
+	if err := json.Unmarshal(data, (*plain)(u)); err != nil {
+		return err
+	}
+	if len(u.Host) == 0 && len(u.Path) == 0 && u.Raw != "" {
+		parsed := parsePostmanRawURL(u.Raw)
+		u.Protocol, u.Host, u.Port, u.Path = parsed.Protocol, parsed.Host, parsed.Port, parsed.Path
+		if len(u.Query) == 0 {
+			u.Query = parsed.Query
+		}
+	}
+	return nil
+}
+
+// postmanParts are the host labels or path segments of a URL, given as a list or a single string
+type postmanParts []string
+
+func (p *postmanParts) UnmarshalJSON(data []byte) error {
+	var parts []string
+	if err := json.Unmarshal(data, &parts); err == nil {
+		*p = parts
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	*p = strings.Split(strings.Trim(single, "/"), "/")
+	return nil
+}
+
+// parsePostmanRawURL splits a URL such as "{{baseUrl}}/pets/:id?limit=10" into its parts
+func parsePostmanRawURL(raw string) postmanURL {
+	u := postmanURL{Raw: raw}
+	rest := raw
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, "?"); i >= 0 {
+		for _, pair := range strings.Split(rest[i+1:], "&") {
+			if pair == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(pair, "=")
+			u.Query = append(u.Query, postmanKeyValue{Key: key, Value: value})
+		}
+		rest = rest[:i]
+	}
+	if protocol, after, ok := strings.Cut(rest, "://"); ok {
+		u.Protocol, rest = protocol, after
+	}
+	host, path, _ := strings.Cut(rest, "/")
+	if name, port, ok := strings.Cut(host, ":"); ok && !strings.Contains(port, "}") {
+		host, u.Port = name, port
+	}
+	u.Host = postmanParts{host}
+	if path != "" {
+		u.Path = strings.Split(path, "/")
+	}
+	return u
+}
+
+// PostmanParser exposes the requests of a Postman v2.1 collection as operations. Folders become tags
+// and saved example responses become responses. Collection and environment variables used in the
+// host become server variables, and their values the defaults of the parameters using them.
+type PostmanParser struct {
+	collection postmanCollection
+	variables  map[string]string
+	source     []byte
+	apis       []APIEndpoint
+	servers    []Server
+	schemes    map[string]SecurityScheme
+}
+
+// ParsePostmanCollection parses a Postman v2.1 collection. The optional environment, an exported
+// Postman environment, supplies variable values that take precedence over the collection's.
+func ParsePostmanCollection(collection []byte, environment []byte) (*PostmanParser, error) {
+	p := &PostmanParser{variables: map[string]string{}, source: collection, schemes: map[string]SecurityScheme{}}
+	if err := json.Unmarshal(collection, &p.collection); err != nil {
+		return nil, fmt.Errorf("invalid Postman collection: %w", err)
+	}
+	for _, variable := range p.collection.Variable {
+		if !variable.Disabled {
+			p.variables[variable.Key] = variable.value()
+		}
+	}
+	if len(environment) > 0 {
+		var env struct {
+			Values []struct {
+				Key     string      `json:"key"`
+				Value   interface{} `json:"value"`
+				Enabled *bool       `json:"enabled"`
+			} `json:"values"`
+		}
+		if err := json.Unmarshal(environment, &env); err != nil {
+			return nil, fmt.Errorf("invalid Postman environment: %w", err)
+		}
+		for _, value := range env.Values {
+			if value.Enabled == nil || *value.Enabled {
+				p.variables[value.Key] = postmanKeyValue{Value: value.Value}.value()
+			}
+		}
+	}
+
+	if err := p.addItems(p.collection.Item, nil, p.collection.Auth); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Source returns the collection the parser was created from
+func (p *PostmanParser) Source() ([]byte, string) {
+	return p.source, "application/json"
+}
+
+// Servers returns the server of the first request. Requests on other hosts declare their own
+// base URL, or their own server if its URL has variables.
+func (p *PostmanParser) Servers() []Server {
+	return p.servers
+}
+
+// Info returns the name and description of the collection
+func (p *PostmanParser) Info() APIInfo {
+	return APIInfo{Title: p.collection.Info.Name, Description: string(p.collection.Info.Description)}
+}
+
+// APIs returns one operation per request of the collection
+func (p *PostmanParser) APIs() []APIEndpoint {
+	return p.apis
+}
+
+// SecuritySchemes returns the bearer, basic and API key authentication used by the collection
+func (p *PostmanParser) SecuritySchemes() map[string]SecurityScheme {
+	return p.schemes
+}
+
+// addItems adds the requests of the items, recursing into folders with their name added to tags
+func (p *PostmanParser) addItems(items []postmanItem, tags []string, auth *postmanAuth) error {
+	for _, item := range items {
+		itemAuth := auth
+		if item.Auth != nil {
+			itemAuth = item.Auth
+		}
+		if len(item.Request) == 0 {
+			folderTags := tags
+			if item.Name != "" {
+				folderTags = append(append([]string{}, tags...), item.Name)
+			}
+			if err := p.addItems(item.Item, folderTags, itemAuth); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var request postmanRequest
+		var rawURL string
+		if err := json.Unmarshal(item.Request, &rawURL); err == nil {
+			request = postmanRequest{Method: "GET", URL: parsePostmanRawURL(rawURL)}
+		} else if err := json.Unmarshal(item.Request, &request); err != nil {
+			return fmt.Errorf("invalid request %q: %w", item.Name, err)
+		}
+		if request.Auth != nil {
+			itemAuth = request.Auth
+		}
+		p.apis = append(p.apis, p.operation(item, request, tags, itemAuth))
+	}
+	return nil
+}
+
+// operation converts a request to an API endpoint
+func (p *PostmanParser) operation(item postmanItem, request postmanRequest, tags []string, auth *postmanAuth) APIEndpoint {
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = "GET"
+	}
+	api := APIEndpoint{
+		Method:      method,
+		OperationID: item.Name,
+		Summary:     item.Name,
+		Description: string(request.Description),
+		Tags:        tags,
+		Responses:   p.responses(item.Response),
+		Security:    p.security(auth),
+	}
+	if api.Description == "" {
+		api.Description = string(item.Description)
+	}
+
+	server := p.server(request.URL)
+	switch {
+	case len(p.servers) == 0:
+		p.servers = []Server{server}
+	case server.URL == p.servers[0].URL:
+	case len(server.Variables) == 0 && !strings.Contains(server.URL, "{"):
+		api.BaseURL = strings.TrimSuffix(server.URL, "/")
+	default:
+		api.Servers = []Server{server}
+	}
+
+	pathVariables := map[string]postmanKeyValue{}
+	for _, variable := range request.URL.Variable {
+		pathVariables[variable.Key] = variable
+	}
+	segments := make([]string, 0, len(request.URL.Path))
+	for _, segment := range request.URL.Path {
+		switch {
+		case strings.HasPrefix(segment, ":") && len(segment) > 1:
+			name := segment[1:]
+			variable := pathVariables[name]
+			api.Parameters = append(api.Parameters, p.parameter(name, "path", variable.value(), string(variable.Description)))
+			segments = append(segments, "{"+name+"}")
+		default:
+			for _, match := range postmanVariablePattern.FindAllStringSubmatch(segment, -1) {
+				api.Parameters = append(api.Parameters, p.parameter(match[1], "path", match[0], ""))
+			}
+			segments = append(segments, postmanVariablePattern.ReplaceAllString(segment, "{$1}"))
+		}
+	}
+	api.Path = "/" + strings.Join(segments, "/")
+
+	for _, query := range request.URL.Query {
+		if !query.Disabled && query.Key != "" {
+			api.Parameters = append(api.Parameters, p.parameter(query.Key, "query", query.value(), string(query.Description)))
+		}
+	}
+	var contentType string
+	for _, header := range request.Header {
+		switch {
+		case header.Disabled || header.Key == "":
+		case strings.EqualFold(header.Key, "Content-Type"):
+			contentType = header.value()
+		case !isReservedHeaderParam(header.Key):
+			api.Parameters = append(api.Parameters, p.parameter(header.Key, "header", header.value(), string(header.Description)))
+		}
+	}
+	api.RequestBody = p.requestBody(request.Body, contentType)
+	return api
+}
+
+// parameter returns an optional string parameter whose default is its value in the collection.
+// Path parameters are required.
+func (p *PostmanParser) parameter(name, in, value, description string) Parameter {
+	schema := &Schema{Type: "string"}
+	if resolved, ok := p.resolve(value); ok && resolved != "" {
+		schema.Default = resolved
+	}
+	return Parameter{Name: name, In: in, Required: in == "path", Description: description, Schema: schema}
+}
+
+// resolve substitutes the variables of a value, failing if one of them has no value
+func (p *PostmanParser) resolve(value string) (string, bool) {
+	ok := true
+	resolved := postmanVariablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		variable, found := p.variables[strings.TrimSpace(match[2:len(match)-2])]
+		ok = ok && found
+		return variable
+	})
+	return resolved, ok
+}
+
+// server returns the server of a request URL. Variables become server variables, with a default
+// if they have a value.
+func (p *PostmanParser) server(u postmanURL) Server {
+	url := strings.Join(u.Host, ".")
+	if u.Protocol != "" {
+		url = u.Protocol + "://" + url
+	}
+	if u.Port != "" {
+		url += ":" + u.Port
+	}
+	server := Server{URL: postmanVariablePattern.ReplaceAllString(url, "{$1}")}
+	for _, match := range postmanVariablePattern.FindAllStringSubmatch(url, -1) {
+		if value, ok := p.variables[match[1]]; ok {
+			if server.Variables == nil {
+				server.Variables = map[string]ServerVariable{}
+			}
+			server.Variables[match[1]] = ServerVariable{Default: value}
+		}
+	}
+	return server
+}
+
+// requestBody describes the body of a request, inferring the schema of JSON bodies from their content
+func (p *PostmanParser) requestBody(body *postmanBody, contentType string) *RequestBody {
+	if body == nil {
+		return nil
+	}
+	var mediaType string
+	var schema Schema
+	switch body.Mode {
+	case "raw":
+		if strings.TrimSpace(body.Raw) == "" {
+			return nil
+		}
+		mediaType = contentType
+		if mediaType == "" {
+			mediaType = map[string]string{
+				"json": "application/json", "xml": "application/xml", "html": "text/html", "javascript": "application/javascript",
+			}[body.Options.Raw.Language]
+		}
+		if mediaType == "" {
+			mediaType = "text/plain"
+		}
+		schema = Schema{Type: "string", Example: body.Raw}
+		if strings.Contains(mediaType, "json") {
+			// Unquoted placeholders such as {"count": {{count}}} are not valid JSON
+			raw := unquotedVariablePattern.ReplaceAllStringFunc(body.Raw, func(match string) string {
+				if strings.HasPrefix(match, `"`) {
+					return match
+				}
+				return "null"
+			})
+			var example interface{}
+			if err := json.Unmarshal([]byte(raw), &example); err == nil {
+				schema = schemaFromExample(example)
+				schema.Example = example
+			}
+		}
+	case "urlencoded", "formdata":
+		mediaType = "application/x-www-form-urlencoded"
+		fields := body.URLEncoded
+		if body.Mode == "formdata" {
+			mediaType, fields = "multipart/form-data", body.FormData
+		}
+		schema = Schema{Type: "object", Properties: map[string]Schema{}}
+		for _, field := range fields {
+			if field.Disabled || field.Key == "" {
+				continue
+			}
+			prop := Schema{Type: "string", Description: string(field.Description)}
+			if field.Type == "file" {
+				prop.Format = "binary"
+			} else if value, ok := p.resolve(field.value()); ok && value != "" {
+				prop.Default = value
+			}
+			schema.Properties[field.Key] = prop
+			schema.PropertyOrder = append(schema.PropertyOrder, field.Key)
+		}
+	case "file":
+		mediaType, schema = "application/octet-stream", Schema{Type: "string", Format: "binary"}
+	default:
+		return nil
+	}
+	return &RequestBody{Content: map[string]MediaType{mediaType: {Schema: &schema}}}
+}
+
+// responses converts saved example responses, keeping the first example of each status
+func (p *PostmanParser) responses(examples []postmanResponse) map[string]Response {
+	responses := map[string]Response{}
+	for _, example := range examples {
+		status := strconv.Itoa(example.Code)
+		if example.Code == 0 {
+			status = "default"
+		}
+		if _, exists := responses[status]; exists {
+			continue
+		}
+		mediaType := "text/plain"
+		for _, header := range example.Header {
+			if strings.EqualFold(header.Key, "Content-Type") {
+				mediaType = strings.TrimSpace(strings.Split(header.value(), ";")[0])
+			}
+		}
+		content := MediaType{Example: example.Body}
+		var parsed interface{}
+		if strings.Contains(mediaType, "json") && json.Unmarshal([]byte(example.Body), &parsed) == nil {
+			schema := schemaFromExample(parsed)
+			content = MediaType{Schema: &schema, Example: parsed}
+		}
+		response := Response{}
+		if example.Body != "" {
+			response.Content = map[string]MediaType{mediaType: content}
+		}
+		responses[status] = response
+	}
+	return responses
+}
+
+// security converts the authentication of a request to a security requirement, registering its scheme
+func (p *PostmanParser) security(auth *postmanAuth) []SecurityRequirement {
+	if auth == nil {
+		return nil
+	}
+	var scheme SecurityScheme
+	switch auth.Type {
+	case "noauth":
+		return []SecurityRequirement{}
+	case "bearer":
+		scheme = SecurityScheme{Type: "http", Scheme: "bearer"}
+	case "basic":
+		scheme = SecurityScheme{Type: "http", Scheme: "basic"}
+	case "apikey":
+		scheme = SecurityScheme{Type: "apiKey", Name: auth.Params["key"], In: auth.Params["in"]}
+		if scheme.In == "" {
+			scheme.In = "header"
+		}
+	default:
+		return nil
+	}
+	name := auth.Type
+	if scheme.Type == "apiKey" {
+		name += "_" + scheme.In + "_" + scheme.Name
+	}
+	p.schemes[name] = scheme
+	return []SecurityRequirement{{name: {}}}
+}
+
+// schemaFromExample infers a schema from an example value
+func schemaFromExample(value interface{}) Schema {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		schema := Schema{Type: "object", Properties: map[string]Schema{}}
+		for name, prop := range v {
+			schema.Properties[name] = schemaFromExample(prop)
+		}
+		return schema
+	case []interface{}:
+		schema := Schema{Type: "array"}
+		if len(v) > 0 {
+			items := schemaFromExample(v[0])
+			schema.Items = &items
+		}
+		return schema
+	case string:
+		return Schema{Type: "string"}
+	case float64:
+		if v == float64(int64(v)) {
+			return Schema{Type: "integer"}
+		}
+		return Schema{Type: "number"}
+	case bool:
+		return Schema{Type: "boolean"}
+	}
+	return Schema{}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import "testing"
+
+const testPostmanCollection = `{
+	"info": {"name": "Pet Store", "description": {"content": "Pets API"}, "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+	"variable": [{"key": "baseUrl", "value": "https://api.example.com/v1"}, {"key": "limit", "value": 20}],
+	"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+	"item": [{
+		"name": "Pets",
+		"item": [{
+			"name": "Get pet",
+			"request": {
+				"method": "GET",
+				"header": [{"key": "X-Trace", "value": "on"}, {"key": "Accept", "value": "application/json"}],
+				"url": {"raw": "{{baseUrl}}/pets/:petId?limit={{limit}}", "host": ["{{baseUrl}}"], "path": ["pets", ":petId"],
+					"query": [{"key": "limit", "value": "{{limit}}"}, {"key": "debug", "value": "1", "disabled": true}],
+					"variable": [{"key": "petId", "value": "7", "description": "ID of the pet"}]}
+			},
+			"response": [{"code": 200, "header": [{"key": "Content-Type", "value": "application/json"}], "body": "{\"id\": 7, \"name\": \"Rex\"}"}]
+		}, {
+			"name": "Create pet",
+			"request": {
+				"auth": {"type": "noauth"},
+				"method": "POST",
+				"url": "https://other.example.com/pets",
+				"body": {"mode": "raw", "raw": "{\"name\": \"{{name}}\", \"age\": {{age}}}", "options": {"raw": {"language": "json"}}}
+			}
+		}]
+	}]
+}`
+
+func Test_PostmanParser(t *testing.T) {
+	parser, err := ParsePostmanCollection([]byte(testPostmanCollection), []byte(`{"values": [{"key": "limit", "value": "50", "enabled": true}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := parser.Info(); info.Title != "Pet Store" || info.Description != "Pets API" {
+		t.Errorf("Got info %+v", info)
+	}
+	servers := parser.Servers()
+	if url, err := servers[0].expandURL(nil); err != nil || url != "https://api.example.com/v1" {
+		t.Errorf("Got server %q, %v", url, err)
+	}
+
+	apis := parser.APIs()
+	if len(apis) != 2 {
+		t.Fatalf("Got %d operations; want 2", len(apis))
+	}
+	get, create := apis[0], apis[1]
+	if get.Method != "GET" || get.Path != "/pets/{petId}" || get.Tags[0] != "Pets" || len(get.Servers) != 0 {
+		t.Errorf("Got operation %+v", get)
+	}
+	params := map[string]Parameter{}
+	for _, param := range get.Parameters {
+		params[param.In+"."+param.Name] = param
+	}
+	if len(params) != 3 || !params["path.petId"].Required || params["path.petId"].Schema.Default != "7" ||
+		params["query.limit"].Schema.Default != "50" || params["header.X-Trace"].Schema.Default != "on" {
+		t.Errorf("Got parameters %+v", get.Parameters)
+	}
+	if example := get.Responses["200"].Content["application/json"].Schema.Properties["id"]; example.Type != "integer" {
+		t.Errorf("Got response %+v", get.Responses["200"])
+	}
+	if len(get.Security) != 1 || parser.SecuritySchemes()["bearer"].Scheme != "bearer" {
+		t.Errorf("Got security %v, schemes %v", get.Security, parser.SecuritySchemes())
+	}
+
+	if create.Security == nil || len(create.Security) != 0 || create.BaseURL != "https://other.example.com" {
+		t.Errorf("Got operation %+v", create)
+	}
+	body := create.RequestBody.Content["application/json"].Schema
+	if body.Properties["name"].Type != "string" || body.Properties["age"].Type != "" {
+		t.Errorf("Got body schema %+v", body)
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// rangeArgument is the tool argument selecting the byte range of a response
+const rangeArgument = "range"
+
+// WithRangeRequests adds an optional "range" argument to the tools of GET operations, sent as the
+// Range header, e.g. "bytes=0-1023" to preview the start of a large file. Partial responses,
+// 206 Partial Content, are returned with their Content-Range. With a response size limit,
+// an open-ended range such as "bytes=1000-" only asks for as many bytes as the limit allows.
+// OperationConfig.Range sets the range sent when the argument is not given.
+func WithRangeRequests(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.rangeRequests = enabled
+	}
+}
+
+// byteRangeFor returns the Range header of a call: the range argument if given, else the
+// operation's default. It fails for anything but a single range of bytes.
+func (e toolEndpoint) byteRangeFor(params map[string]interface{}) (string, error) {
+	value := e.byteRange
+	if e.rangeArg {
+		if arg, ok := params[rangeArgument].(string); ok && strings.TrimSpace(arg) != "" {
+			value = arg
+		}
+	}
+	if value == "" {
+		return "", nil
+	}
+	return normalizeRange(value, e.maxResponse)
+}
+
+// normalizeRange validates a single byte range, such as "bytes=0-99", "bytes=100-" or "bytes=-100",
+// and bounds an open-ended range by the response size limit
+func normalizeRange(value string, limit int64) (string, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(value), "bytes=")
+	startText, endText, found := strings.Cut(spec, "-")
+	if !ok || !found || strings.Contains(spec, ",") || (startText == "" && endText == "") {
+		return "", fmt.Errorf("invalid range %q, expected a single byte range such as bytes=0-1023", value)
+	}
+	var start, end int64 = -1, -1
+	var err error
+	if startText != "" {
+		if start, err = strconv.ParseInt(startText, 10, 64); err != nil || start < 0 {
+			return "", fmt.Errorf("invalid range start in %q", value)
+		}
+	}
+	if endText != "" {
+		if end, err = strconv.ParseInt(endText, 10, 64); err != nil || end < 0 {
+			return "", fmt.Errorf("invalid range end in %q", value)
+		}
+	}
+	if start >= 0 && end >= 0 && end < start {
+		return "", fmt.Errorf("invalid range %q, the end precedes the start", value)
+	}
+	if start >= 0 && end < 0 && limit > 0 {
+		end = start + limit - 1
+	}
+	return "bytes=" + rangeBound(start) + "-" + rangeBound(end), nil
+}
+
+// rangeBound formats a range bound, which is left out if negative
+func rangeBound(bound int64) string {
+	if bound < 0 {
+		return ""
+	}
+	return strconv.FormatInt(bound, 10)
+}
+
+// partialContentNote describes the part of the resource a 206 Partial Content response holds
+func partialContentNote(resp *http.Response) string {
+	if resp.StatusCode != http.StatusPartialContent {
+		return ""
+	}
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		return fmt.Sprintf("\n\n[Partial content: %s]", contentRange)
+	}
+	return "\n\n[Partial content]"
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func Test_NormalizeRange(t *testing.T) {
+	tests := []struct {
+		value string
+		limit int64
+		want  string
+	}{
+		{"bytes=0-99", 0, "bytes=0-99"},
+		{"bytes=100-", 0, "bytes=100-"},
+		{"bytes=100-", 50, "bytes=100-149"},
+		{"bytes=-100", 50, "bytes=-100"},
+	}
+	for _, tt := range tests {
+		if got, err := normalizeRange(tt.value, tt.limit); err != nil || got != tt.want {
+			t.Errorf("normalizeRange(%q, %d) = %q, %v; want %q", tt.value, tt.limit, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"0-99", "bytes=-", "bytes=0-1,5-9", "bytes=9-1", "items=0-9"} {
+		if _, err := normalizeRange(value, 0); err == nil {
+			t.Errorf("normalizeRange(%q) succeeded; want an error", value)
+		}
+	}
+}
+
+func Test_RangeArgument(t *testing.T) {
+	content := strings.Repeat("0123456789", 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.txt", time.Time{}, bytes.NewReader([]byte(content)))
+	}))
+	defer ts.Close()
+
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"},
+		"paths": {"/data": {"get": {"operationId": "getData"}}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := buildTools(newAdapterConfig(WithRangeRequests(true)), server.NewMCPServer("t", "1"), "t", ts.URL, nil, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tools[0].Tool.InputSchema.Properties[rangeArgument]; !ok {
+		t.Fatalf("GET tool has no range argument")
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{rangeArgument: "bytes=10-14"}
+	result, err := tools[0].Handler(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultText(t, result); got != "01234\n\n[Partial content: bytes 10-14/100]" {
+		t.Errorf("Got %q; want the requested bytes and their range", got)
+	}
+
+	request.Params.Arguments = map[string]interface{}{rangeArgument: "lines=1-2"}
+	if result, _ := tools[0].Handler(context.Background(), request); !result.IsError {
+		t.Errorf("Got %q; want an error for an invalid range", resultText(t, result))
+	}
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit throttles upstream requests with a token bucket
+type RateLimit struct {
+	Rate  float64 // Sustained requests per second
+	Burst int     // Requests allowed at once before throttling starts; values below 1 allow one
+	// FailFast returns an error instead of waiting when no request is currently allowed.
+	// Otherwise calls wait for their turn, until the tool call is cancelled or times out.
+	FailFast bool
+}
+
+// WithRateLimit throttles the requests of all tools together.
+// An operation can replace this limit with OperationConfig.RateLimit.
+func WithRateLimit(limit RateLimit) AdapterOption {
+	return func(c *adapterConfig) {
+		if err := limit.validate(); err != nil {
+			c.setError(err)
+			return
+		}
+		c.rateLimit = &limit
+	}
+}
+
+// WithHostRateLimit throttles the requests sent to the given host, e.g. "api.example.com",
+// in addition to the global or per-operation limit
+func WithHostRateLimit(host string, limit RateLimit) AdapterOption {
+	return func(c *adapterConfig) {
+		if err := limit.validate(); err != nil {
+			c.setError(err)
+			return
+		}
+		if c.hostRateLimits == nil {
+			c.hostRateLimits = map[string]RateLimit{}
+		}
+		c.hostRateLimits[strings.ToLower(host)] = limit
+	}
+}
+
+// validate checks that the limit allows any request at all
+func (l RateLimit) validate() error {
+	if l.Rate <= 0 {
+		return fmt.Errorf("rate limit must be positive, got %v", l.Rate)
+	}
+	return nil
+}
+
+// rateLimiters holds the token buckets shared by all handlers of an adapter
+type rateLimiters struct {
+	global     *tokenBucket
+	hosts      map[string]*tokenBucket
+	operations map[string]*tokenBucket
+}
+
+// newRateLimiters creates the buckets for the configured limits
+func (c *adapterConfig) newRateLimiters() *rateLimiters {
+	limiters := &rateLimiters{hosts: map[string]*tokenBucket{}, operations: map[string]*tokenBucket{}}
+	if c.rateLimit != nil {
+		limiters.global = newTokenBucket(*c.rateLimit)
+	}
+	for host, limit := range c.hostRateLimits {
+		limiters.hosts[host] = newTokenBucket(limit)
+	}
+	for operationID, opCfg := range c.operations {
+		if opCfg.RateLimit == nil {
+			continue
+		}
+		if err := opCfg.RateLimit.validate(); err != nil {
+			c.setError(fmt.Errorf("operation %s: %w", operationID, err))
+			continue
+		}
+		limiters.operations[operationID] = newTokenBucket(*opCfg.RateLimit)
+	}
+	return limiters
+}
+
+// limitersFor returns the buckets a request of the given operation to rawURL must pass
+func (c *adapterConfig) limitersFor(operationID, rawURL string) []*tokenBucket {
+	var buckets []*tokenBucket
+	if bucket, ok := c.limiters.operations[operationID]; ok {
+		buckets = append(buckets, bucket)
+	} else if c.limiters.global != nil {
+		buckets = append(buckets, c.limiters.global)
+	}
+	if u, err := neturl.Parse(rawURL); err == nil {
+		if bucket, ok := c.limiters.hosts[strings.ToLower(u.Host)]; ok {
+			buckets = append(buckets, bucket)
+		}
+	}
+	return buckets
+}
+
+// tokenBucket is a token bucket rate limiter. Callers reserve a token up front and wait
+// until it becomes available, so waiting callers are served in order.
+type tokenBucket struct {
+	mu     sync.Mutex
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+}
+
+// wait takes a token, waiting until one is available. It fails without taking a token if the
+// bucket is set to fail fast, or if ctx is done or would expire before the token is available.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-b.tokens / b.limit.Rate * float64(time.Second))
+	deadline, hasDeadline := ctx.Deadline()
+	if b.limit.FailFast || (hasDeadline && deadline.Before(now.Add(delay))) {
+		b.tokens++
+		b.mu.Unlock()
+		return fmt.Errorf("rate limit of %v requests per second exceeded, next request allowed in %s", b.limit.Rate, delay.Round(time.Millisecond))
+	}
+	b.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// waitAll takes a token from each bucket in turn
+func waitAll(ctx context.Context, buckets []*tokenBucket) error {
+	for _, bucket := range buckets {
+		if err := bucket.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_TokenBucket(t *testing.T) {
+	bucket := newTokenBucket(RateLimit{Rate: 1, Burst: 2, FailFast: true})
+	for i := 0; i < 2; i++ {
+		if err := bucket.wait(context.Background()); err != nil {
+			t.Fatalf("Request %d within the burst failed: %v", i+1, err)
+		}
+	}
+	if err := bucket.wait(context.Background()); err == nil {
+		t.Fatalf("Request beyond the burst succeeded; want a rate limit error")
+	}
+
+	// A waiting call gives up when its context expires before a token is available
+	bucket = newTokenBucket(RateLimit{Rate: 0.1})
+	bucket.wait(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := bucket.wait(ctx); err == nil {
+		t.Fatalf("Request succeeded; want an error for a deadline before the next token")
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Fatalf("Request waited %s; want it to fail without waiting", elapsed)
+	}
+}
+
+func Test_LimitersForOperation(t *testing.T) {
+	cfg := newAdapterConfig(
+		WithRateLimit(RateLimit{Rate: 10}),
+		WithHostRateLimit("api.example.com", RateLimit{Rate: 5}),
+		WithOperationConfig("report", OperationConfig{RateLimit: &RateLimit{Rate: 1}}),
+	)
+	if cfg.err != nil {
+		t.Fatal(cfg.err)
+	}
+
+	if got := cfg.limitersFor("list", "https://api.example.com/items"); len(got) != 2 || got[0] != cfg.limiters.global {
+		t.Errorf("Got %d limiters for list; want the global and host limiters", len(got))
+	}
+	if got := cfg.limitersFor("report", "https://other.example.com/report"); len(got) != 1 || got[0] != cfg.limiters.operations["report"] {
+		t.Errorf("Got %d limiters for report; want only the operation limiter", len(got))
+	}
+	if err := newAdapterConfig(WithRateLimit(RateLimit{})).err; err == nil {
+		t.Errorf("Got no error for a zero rate")
+	}
+}
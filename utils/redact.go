@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// redactedValue replaces secrets in logs and tool results
+const redactedValue = "[REDACTED]"
+
+// minSecretLength is the length below which a credential value is not searched for in free text,
+// since masking every occurrence of a very short value would garble unrelated output
+const minSecretLength = 4
+
+// defaultSensitiveHeaders are the headers whose values are redacted by default
+var defaultSensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-API-Key",
+	"Api-Key",
+	"X-Auth-Token",
+	"X-Access-Token",
+	"X-Amz-Security-Token",
+}
+
+// defaultSensitiveQueryParams are the query parameters whose values are redacted by default
+var defaultSensitiveQueryParams = []string{
+	"api_key",
+	"apikey",
+	"api-key",
+	"key",
+	"access_token",
+	"refresh_token",
+	"client_secret",
+	"secret",
+	"password",
+	"signature",
+	"sig",
+}
+
+// WithRedactedHeaders adds headers whose values are masked in logs and tool results,
+// on top of Authorization, Cookie and the common API key headers
+func WithRedactedHeaders(names ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.redactHeaders = append(c.redactHeaders, names...)
+	}
+}
+
+// WithRedactedQueryParams adds query parameters whose values are masked in logged and returned URLs,
+// on top of api_key, token, access_token and similar parameters
+func WithRedactedQueryParams(names ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.redactQueryParams = append(c.redactQueryParams, names...)
+	}
+}
+
+// WithUnredacted exempts headers or query parameters from the default redaction lists,
+// e.g. a "key" query parameter that holds a record key rather than a credential
+func WithUnredacted(names ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.unredacted = append(c.unredacted, names...)
+	}
+}
+
+// redactor masks credentials before anything is logged or returned to the client.
+// Names are matched case-insensitively.
+type redactor struct {
+	headers     map[string]bool
+	queryParams map[string]bool
+}
+
+// newRedactor builds the redaction lists from the defaults, the configured additions and exemptions,
+// and the names of the configured API keys
+func (c *adapterConfig) newRedactor() *redactor {
+	r := &redactor{headers: map[string]bool{}, queryParams: map[string]bool{}}
+	for _, name := range append(defaultSensitiveHeaders, c.redactHeaders...) {
+		r.headers[strings.ToLower(name)] = true
+	}
+	for _, name := range append(defaultSensitiveQueryParams, c.redactQueryParams...) {
+		r.queryParams[strings.ToLower(name)] = true
+	}
+	for _, name := range c.unredacted {
+		delete(r.headers, strings.ToLower(name))
+		delete(r.queryParams, strings.ToLower(name))
+	}
+
+	for _, auth := range c.configuredAuths() {
+		if auth == nil {
+			continue
+		}
+		if auth.HMAC != nil {
+			r.headers[strings.ToLower(auth.HMAC.Header)] = true
+		}
+		if auth.APIKey == nil {
+			continue
+		}
+		switch auth.APIKey.location() {
+		case APIKeyInHeader:
+			r.headers[strings.ToLower(auth.APIKey.Name)] = true
+		case APIKeyInQuery:
+			r.queryParams[strings.ToLower(auth.APIKey.Name)] = true
+		}
+	}
+	return r
+}
+
+// secrets returns the credential values sent with a request: the configured credentials and the
+// values of sensitive headers, cookies and query parameters. req may be nil if no request was built.
+func (r *redactor) secrets(req *http.Request, auths []*Auth) []string {
+	var secrets []string
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		secrets = append(secrets, auth.BearerToken)
+		if auth.Basic != nil {
+			secrets = append(secrets, auth.Basic.Password)
+		}
+		if auth.APIKey != nil {
+			secrets = append(secrets, auth.APIKey.Value)
+		}
+		if auth.SigV4 != nil {
+			secrets = append(secrets, auth.SigV4.SecretAccessKey, auth.SigV4.SessionToken)
+		}
+		if auth.HMAC != nil {
+			secrets = append(secrets, auth.HMAC.Secret)
+		}
+	}
+
+	if req != nil {
+		for name, values := range req.Header {
+			if !r.headers[strings.ToLower(name)] {
+				continue
+			}
+			for _, value := range values {
+				secrets = append(secrets, value)
+				// The credentials of "Bearer <token>" or "Basic <credentials>"
+				if _, credentials, ok := strings.Cut(value, " "); ok {
+					secrets = append(secrets, credentials)
+				}
+			}
+		}
+		for _, cookie := range req.Cookies() {
+			secrets = append(secrets, cookie.Value)
+		}
+		for name, values := range req.URL.Query() {
+			if r.queryParams[strings.ToLower(name)] {
+				secrets = append(secrets, values...)
+			}
+		}
+	}
+
+	// Longer values first, so a token is masked as a whole even if it contains another secret
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	return secrets
+}
+
+// redactText masks every occurrence of the given secrets
+func (r *redactor) redactText(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			text = strings.ReplaceAll(text, secret, redactedValue)
+			// Secrets also show up URL-encoded, e.g. in URLs quoted by transport errors
+			if escaped := neturl.QueryEscape(secret); escaped != secret {
+				text = strings.ReplaceAll(text, escaped, redactedValue)
+			}
+		}
+	}
+	return text
+}
+
+// redactURL masks the values of sensitive query parameters and any secrets in a URL
+func (r *redactor) redactURL(rawURL string, secrets []string) string {
+	u, err := neturl.Parse(rawURL)
+	if err == nil && u.RawQuery != "" {
+		q := u.Query()
+		for name := range q {
+			if r.queryParams[strings.ToLower(name)] {
+				q[name] = []string{redactedValue}
+			}
+		}
+		u.RawQuery = q.Encode()
+		rawURL = u.String()
+	}
+	return r.redactText(rawURL, secrets)
+}
+
+// redactHeaders returns the headers as a flat map, masking the values of sensitive headers
+func (r *redactor) redactHeaders(header http.Header) map[string]string {
+	redacted := make(map[string]string, len(header))
+	for name, values := range header {
+		if r.headers[strings.ToLower(name)] {
+			redacted[name] = redactedValue
+			continue
+		}
+		redacted[name] = strings.Join(values, ", ")
+	}
+	return redacted
+}
+
+// redactResult masks secrets in the text and resource URIs of a tool result
+func (r *redactor) redactResult(result *mcp.CallToolResult, secrets []string) *mcp.CallToolResult {
+	if result == nil {
+		return nil
+	}
+	for i, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			c.Text = r.redactText(c.Text, secrets)
+			result.Content[i] = c
+		case mcp.EmbeddedResource:
+			switch resource := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				resource.URI = r.redactURL(resource.URI, secrets)
+				resource.Text = r.redactText(resource.Text, secrets)
+				c.Resource = resource
+			case mcp.BlobResourceContents:
+				resource.URI = r.redactURL(resource.URI, secrets)
+				c.Resource = resource
+			}
+			result.Content[i] = c
+		}
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_RedactErrorResults(t *testing.T) {
+	// The upstream echoes the credentials it received in its error message
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("invalid key " + r.URL.Query().Get("api_key") + " for " + r.Header.Get("X-Tenant-Secret")))
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, map[string]string{"X-Tenant-Secret": "tenant-secret"},
+		WithAuth(Auth{APIKey: &APIKeyAuth{Name: "api_key", Value: "key-12345", In: APIKeyInQuery}}),
+		WithRedactedHeaders("X-Tenant-Secret"),
+	)
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	if strings.Contains(text, "key-12345") || strings.Contains(text, "tenant-secret") {
+		t.Fatalf("Result %q leaks credentials", text)
+	}
+	if !strings.Contains(text, redactedValue) {
+		t.Fatalf("Result %q has no redacted values", text)
+	}
+}
+
+func Test_RedactURL(t *testing.T) {
+	cfg := newAdapterConfig(WithRedactedQueryParams("session"), WithUnredacted("key"))
+	got := cfg.redactor.redactURL("https://api.example.com/items?api_key=abc&key=item-1&session=s1&page=2", nil)
+	want := "https://api.example.com/items?api_key=%5BREDACTED%5D&key=item-1&page=2&session=%5BREDACTED%5D"
+	if got != want {
+		t.Fatalf("Got URL %q; want %q", got, want)
+	}
+}
+
+func Test_RedactSigningHeaders(t *testing.T) {
+	cfg := newAdapterConfig(WithAuth(Auth{HMAC: &HMACAuth{Secret: "secret", Header: "X-Signature"}}))
+	header := http.Header{}
+	header.Set("X-Amz-Security-Token", "session-token")
+	header.Set("X-Signature", "signature")
+	header.Set("Accept", "application/json")
+
+	redacted := cfg.redactor.redactHeaders(header)
+	if redacted["X-Amz-Security-Token"] != redactedValue || redacted["X-Signature"] != redactedValue {
+		t.Errorf("Signing headers are not redacted: %v", redacted)
+	}
+	if redacted["Accept"] != "application/json" {
+		t.Errorf("Unexpected redaction of Accept: %v", redacted)
+	}
+}
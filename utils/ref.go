@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxRefDepth bounds how many nested $ref references are expanded along a single path,
+// so that deeply recursive schemas cannot blow up the size of the resolved document
+const maxRefDepth = 32
+
+// refResolver expands local $ref references ("#/components/schemas/Pet") in a decoded document.
+// References that point outside the document are left in place.
+type refResolver struct {
+	root     interface{}
+	resolved map[string]interface{} // Expanded target of each reference, shared by all its uses
+	visiting map[string]bool        // References whose targets are being expanded
+}
+
+// resolveRefs returns a copy of the document with every local $ref replaced by its target.
+// Each target is expanded once and shared by every reference to it. A reference to a target
+// that is still being expanded, that is a circular one, or that exceeds maxRefDepth, is kept
+// as a bare {"$ref": ...} object instead of being expanded again.
+func resolveRefs(document interface{}) interface{} {
+	r := &refResolver{root: document, resolved: map[string]interface{}{}, visiting: map[string]bool{}}
+	return r.resolve(document, 0)
+}
+
+// resolve expands references in node; depth is the number of references being expanded
+func (r *refResolver) resolve(node interface{}, depth int) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			return r.resolveRef(ref, v, depth)
+		}
+		// Keys are walked in order, since which reference of a cycle is kept depends on it
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := make(map[string]interface{}, len(v))
+		for _, key := range keys {
+			result[key] = r.resolve(v[key], depth)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			result[i] = r.resolve(value, depth)
+		}
+		return result
+	}
+	return node
+}
+
+// resolveRef expands a single reference object. Sibling keywords of the $ref, such as a
+// description, take precedence over the keywords of the referenced schema.
+func (r *refResolver) resolveRef(ref string, node map[string]interface{}, depth int) interface{} {
+	resolved, ok := r.resolveTarget(ref, depth)
+	if !ok {
+		return map[string]interface{}{"$ref": ref}
+	}
+	if len(node) == 1 {
+		return resolved
+	}
+	targetMap, ok := resolved.(map[string]interface{})
+	if !ok {
+		return resolved
+	}
+	merged := make(map[string]interface{}, len(targetMap)+len(node))
+	for key, value := range targetMap {
+		merged[key] = value
+	}
+	for key, value := range node {
+		if key != "$ref" {
+			merged[key] = r.resolve(value, depth)
+		}
+	}
+	return merged
+}
+
+// resolveTarget returns the expanded target of a reference, expanding it on first use.
+// It returns false for references that are left in place.
+func (r *refResolver) resolveTarget(ref string, depth int) (interface{}, bool) {
+	if resolved, ok := r.resolved[ref]; ok {
+		return resolved, true
+	}
+	if !strings.HasPrefix(ref, "#") || depth >= maxRefDepth || r.visiting[ref] {
+		return nil, false
+	}
+	target, ok := lookupPointer(r.root, strings.TrimPrefix(ref, "#"))
+	if !ok {
+		return nil, false
+	}
+
+	r.visiting[ref] = true
+	resolved := r.resolve(target, depth+1)
+	delete(r.visiting, ref)
+	r.resolved[ref] = resolved
+	return resolved, true
+}
+
+// lookupPointer returns the value addressed by a JSON pointer (RFC 6901) in the document.
+// The pointer may be percent-encoded, as it is when taken from a URI fragment.
+func lookupPointer(document interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return document, true
+	}
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	current := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ResolveCircularRefs(t *testing.T) {
+	spec := []byte(`{
+		"openapi": "3.0.0",
+		"paths": {
+			"/nodes": {
+				"post": {
+					"operationId": "createNode",
+					"requestBody": {
+						"content": {
+							"application/json": {"schema": {"$ref": "#/components/schemas/Node"}}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Node": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"children": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}}
+					}
+				}
+			}
+		}
+	}`)
+
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	apis := parser.APIs()
+	if len(apis) != 1 {
+		t.Fatalf("Expected 1 API, got %d", len(apis))
+	}
+	schema := apis[0].RequestBody.Content["application/json"].Schema
+	if schema.Properties["name"].Type != "string" {
+		t.Fatalf("Expected referenced properties to be resolved, got %+v", schema.Properties)
+	}
+	items := schema.Properties["children"].Items
+	if items == nil {
+		t.Fatalf("Expected array items to be parsed")
+	}
+	if ref := items.Ref; ref != "#/components/schemas/Node" {
+		t.Fatalf("Expected the circular reference to be kept, got %q", ref)
+	}
+}
+
+func Test_ResolveMutuallyReferencingSchemas(t *testing.T) {
+	// Every schema refers to every other one, which takes factorial time if each path is expanded
+	const count = 12
+	schemas := map[string]interface{}{}
+	for i := 0; i < count; i++ {
+		properties := map[string]interface{}{"id": map[string]interface{}{"type": "string"}}
+		for j := 0; j < count; j++ {
+			if j != i {
+				properties[fmt.Sprintf("s%d", j)] = map[string]interface{}{"$ref": fmt.Sprintf("#/components/schemas/S%d", j)}
+			}
+		}
+		schemas[fmt.Sprintf("S%d", i)] = map[string]interface{}{"type": "object", "properties": properties}
+	}
+	spec, err := json.Marshal(map[string]interface{}{
+		"openapi": "3.0.0",
+		"paths": map[string]interface{}{"/s": map[string]interface{}{"post": map[string]interface{}{
+			"operationId": "createS",
+			"requestBody": map[string]interface{}{"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/S0"}},
+			}},
+		}}},
+		"components": map[string]interface{}{"schemas": schemas},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	apis := parser.APIs()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Expected mutually referencing schemas to parse quickly, took %s", elapsed)
+	}
+
+	schema := apis[0].RequestBody.Content["application/json"].Schema
+	if schema.Properties["s2"].Properties["id"].Type != "string" {
+		t.Errorf("Expected references to be expanded, got %+v", schema.Properties["s2"])
+	}
+	if ref := schema.Properties["s2"].Properties["s0"].Ref; ref != "#/components/schemas/S0" {
+		t.Errorf("Expected the reference back to S0 to be kept, got %q", ref)
+	}
+}
+
+func Test_ParseSchemaComposition(t *testing.T) {
+	spec := []byte(`{
+		"openapi": "3.0.0",
+		"paths": {
+			"/pets": {
+				"post": {
+					"operationId": "createPet",
+					"requestBody": {
+						"content": {
+							"application/json": {"schema": {
+								"allOf": [
+									{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]},
+									{
+										"oneOf": [
+											{"properties": {"kind": {"type": "string", "enum": ["cat"]}, "lives": {"type": "integer"}}, "required": ["kind"]},
+											{"properties": {"kind": {"type": "string", "enum": ["dog"]}, "breed": {"type": "string"}}, "required": ["kind", "breed"]}
+										],
+										"discriminator": {"propertyName": "kind"}
+									}
+								]
+							}}
+						}
+					}
+				}
+			}
+		}
+	}`)
+
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	schema := parser.APIs()[0].RequestBody.Content["application/json"].Schema
+	if schema.Type != "object" {
+		t.Errorf("Expected type object, got %q", schema.Type)
+	}
+	for _, name := range []string{"name", "kind", "lives", "breed"} {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("Expected property %q to be merged", name)
+		}
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"name", "kind"}) {
+		t.Errorf("Unexpected required properties: %v", schema.Required)
+	}
+	if !reflect.DeepEqual(schema.Properties["kind"].Enum, []interface{}{"cat", "dog"}) {
+		t.Errorf("Unexpected discriminator values: %v", schema.Properties["kind"].Enum)
+	}
+}
+
+func Test_MergeAllOfKeywords(t *testing.T) {
+	spec := []byte(`{
+		"openapi": "3.0.0",
+		"components": {"schemas": {
+			"Status": {"type": "string", "enum": ["open", "closed", "draft"], "default": "open", "example": "closed", "nullable": true}
+		}},
+		"paths": {
+			"/tickets": {
+				"get": {
+					"operationId": "listTickets",
+					"parameters": [
+						{"name": "status", "in": "query", "schema": {"allOf": [{"$ref": "#/components/schemas/Status"}], "description": "Ticket status"}},
+						{"name": "published", "in": "query", "schema": {"allOf": [{"$ref": "#/components/schemas/Status"}, {"enum": ["open", "closed"]}]}}
+					]
+				}
+			}
+		}
+	}`)
+
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	params := parser.APIs()[0].Parameters
+	status := params[0].Schema
+	if status.Type != "string" || status.Description != "Ticket status" || status.Default != "open" || status.Example != "closed" || !status.Nullable {
+		t.Errorf("Expected the keywords of the referenced schema to be merged, got %+v", status)
+	}
+	if !reflect.DeepEqual(status.Enum, []interface{}{"open", "closed", "draft"}) {
+		t.Errorf("Unexpected enum: %v", status.Enum)
+	}
+	if published := params[1].Schema; !reflect.DeepEqual(published.Enum, []interface{}{"open", "closed"}) || published.Nullable {
+		t.Errorf("Expected the enums to be intersected and null to be rejected, got %+v", published)
+	}
+}
+
+func Test_MergeAlternativesWithStructuredEnums(t *testing.T) {
+	schema := Schema{Properties: map[string]Schema{}}
+	mergeAlternatives(&schema, []Schema{
+		{Type: "object", Properties: map[string]Schema{"point": {Type: "array", Enum: []interface{}{[]interface{}{0.0, 0.0}}}}},
+		{Type: "object", Properties: map[string]Schema{"point": {Type: "array", Enum: []interface{}{[]interface{}{0.0, 0.0}, []interface{}{1.0, 1.0}}}}},
+	})
+	if got := schema.Properties["point"].Enum; len(got) != 2 {
+		t.Errorf("Expected the two distinct array values, got %v", got)
+	}
+}
+
+func Test_ParseNullableTypes(t *testing.T) {
+	spec := []byte(`{
+		"openapi": "3.1.0",
+		"paths": {
+			"/items": {
+				"post": {
+					"operationId": "createItem",
+					"requestBody": {
+						"content": {
+							"application/json": {"schema": {
+								"type": "object",
+								"properties": {
+									"name": {"type": ["string", "null"]},
+									"legacy": {"type": "integer", "nullable": true},
+									"kind": {"const": "item"},
+									"note": {"oneOf": [{"type": "string"}, {"type": "null"}]}
+								}
+							}}
+						}
+					}
+				}
+			}
+		}
+	}`)
+
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	props := parser.APIs()[0].RequestBody.Content["application/json"].Schema.Properties
+	for _, name := range []string{"name", "legacy", "note"} {
+		if !props[name].Nullable || props[name].Type == "" {
+			t.Errorf("Expected %q to be a nullable typed schema, got %+v", name, props[name])
+		}
+	}
+	if !reflect.DeepEqual(props["kind"].Enum, []interface{}{"item"}) {
+		t.Errorf("Expected const to become a single-valued enum, got %v", props["kind"].Enum)
+	}
+
+	encoded, err := json.Marshal(props["name"])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"type":["string","null"]`) {
+		t.Errorf("Expected a type array, got %s", encoded)
+	}
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultReloadInterval is how often WatchSpecFile checks the specification file for changes
+const defaultReloadInterval = time.Second
+
+// WithReloadInterval sets how often WatchSpecFile checks the specification file for changes.
+// It is given to NewMCPFromFile, along with the other options of the server.
+func WithReloadInterval(interval time.Duration) AdapterOption {
+	return func(c *adapterConfig) {
+		if interval <= 0 {
+			c.setError(fmt.Errorf("reload interval must be positive, got %s", interval))
+			return
+		}
+		c.reloadInterval = interval
+	}
+}
+
+// WithReloadErrorHandler sets the function WatchSpecFile reports failed reloads to, for example
+// a document that no longer parses. By default they are written to the standard logger.
+func WithReloadErrorHandler(handler func(err error)) AdapterOption {
+	return func(c *adapterConfig) {
+		c.reloadErrors = handler
+	}
+}
+
+// watchableServers maps the servers created by NewMCPFromFile to their watchers
+var watchableServers sync.Map
+
+// WatchSpecFile keeps the tools of s, a server created with NewMCPFromFile, in sync with its
+// specification file. The server's options are used, including its HTTP client, caches and limits.
+// The file is checked every reload interval; once a change has settled, that is the content is the
+// same on two consecutive checks, the document is parsed again and the tools are rebuilt. Tools and
+// resources of removed operations are deleted and new or changed ones registered. If the changed
+// document cannot be parsed, the error is reported, see WithReloadErrorHandler, and the last good
+// tool set stays in place. WatchSpecFile blocks until ctx is done.
+func WatchSpecFile(ctx context.Context, s *server.MCPServer) error {
+	value, ok := watchableServers.Load(s)
+	if !ok {
+		return errors.New("server was not created by NewMCPFromFile")
+	}
+	w := value.(*specWatcher)
+
+	data, err := os.ReadFile(w.filename)
+	if err != nil {
+		return fmt.Errorf("failed to read specification: %w", err)
+	}
+	current, err := w.build(data)
+	if err != nil {
+		return err
+	}
+	loaded := sha256.Sum256(data)
+	var pending [sha256.Size]byte // Content seen changed on the last check, not yet reloaded
+
+	ticker := time.NewTicker(w.cfg.reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// The file may be missing or partially written while an editor saves it
+		data, err := os.ReadFile(w.filename)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if sum == loaded {
+			pending = [sha256.Size]byte{}
+			continue
+		}
+		if sum != pending {
+			pending = sum
+			continue
+		}
+		loaded = sum
+
+		tools, err := w.build(data)
+		if err != nil {
+			w.reportError(err)
+			continue
+		}
+		w.apply(current, tools)
+		current = tools
+	}
+}
+
+// specWatcher rebuilds the tools of a server from a specification file
+type specWatcher struct {
+	cfg          *adapterConfig
+	server       *server.MCPServer
+	filename     string
+	baseURL      string
+	extraHeaders map[string]string
+}
+
+// build parses the document and creates its tools. Resources of operations that are no longer
+// in the document are retired once the build succeeds.
+func (w *specWatcher) build(data []byte) ([]server.ServerTool, error) {
+	previous := w.cfg.resources.reset()
+	tools, err := w.buildTools(data)
+	w.cfg.resources.finish(previous, err == nil)
+	return tools, err
+}
+
+// buildTools parses the document and creates its tools, registering its resources
+func (w *specWatcher) buildTools(data []byte) ([]server.ServerTool, error) {
+	parser, err := parseSpec(data, w.filename, "")
+	if err != nil {
+		return nil, err
+	}
+	if w.cfg.specResource {
+		if err := addSpecResource(w.server, parser, parser.Info()); err != nil {
+			return nil, err
+		}
+	}
+	return buildTools(w.cfg, w.server, sanitizeToolName(parser.Info().Title), w.baseURL, w.extraHeaders, parser)
+}
+
+// reportError reports a failed reload; the previous tools stay in place
+func (w *specWatcher) reportError(err error) {
+	err = fmt.Errorf("failed to reload %s: %w", w.filename, err)
+	if w.cfg.reloadErrors != nil {
+		w.cfg.reloadErrors(err)
+		return
+	}
+	log.Printf("[RELOAD] %v, keeping the previous tools", err)
+}
+
+// apply replaces the current tools of the server with the rebuilt ones
+func (w *specWatcher) apply(current, next []server.ServerTool) {
+	definitions := make(map[string]string, len(current))
+	for _, tool := range current {
+		definitions[tool.Tool.Name] = toolDefinition(tool)
+	}
+
+	var added, changed int
+	kept := map[string]bool{}
+	for _, tool := range next {
+		kept[tool.Tool.Name] = true
+		definition, ok := definitions[tool.Tool.Name]
+		switch {
+		case !ok:
+			added++
+		case definition != toolDefinition(tool):
+			changed++
+		}
+	}
+	var removed []string
+	for _, tool := range current {
+		if !kept[tool.Tool.Name] {
+			removed = append(removed, tool.Tool.Name)
+		}
+	}
+
+	if len(removed) > 0 {
+		w.server.DeleteTools(removed...)
+	}
+	// Unchanged tools are registered again too, since their handlers may call a different URL
+	if len(next) > 0 {
+		w.server.AddTools(next...)
+	}
+	w.cfg.logger.Info("specification reloaded", "file", w.filename, "added", added, "changed", changed, "removed", len(removed))
+}
+
+// toolDefinition returns the tool as advertised to clients, for comparison
+func toolDefinition(tool server.ServerTool) string {
+	encoded, _ := json.Marshal(tool.Tool)
+	return string(encoded)
+}
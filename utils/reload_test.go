@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_WatchSpecFile(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	writeSpec := func(operations string) {
+		spec := "openapi: 3.0.0\ninfo:\n  title: Pets\n  version: '1'\npaths:\n  /pets:\n" + operations
+		if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSpec("    get:\n      operationId: listPets\n")
+
+	var reloadErrs []error
+	var mu sync.Mutex
+	s, err := NewMCPFromFile(specPath, "http://localhost", nil, WithReloadInterval(5*time.Millisecond), WithGETResources(true),
+		WithReloadErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reloadErrs = append(reloadErrs, err)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchSpecFile(ctx, s)
+
+	listTools := func() string {
+		response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`))
+		encoded, _ := json.Marshal(response)
+		return string(encoded)
+	}
+	waitFor := func(want func(tools string) bool) string {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			tools := listTools()
+			if want(tools) || time.Now().After(deadline) {
+				return tools
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// Give the watcher time to load the initial document before changing it
+	time.Sleep(20 * time.Millisecond)
+	writeSpec("    post:\n      operationId: createPet\n")
+	tools := waitFor(func(tools string) bool { return strings.Contains(tools, "createpet") })
+	if !strings.Contains(tools, "createpet") || strings.Contains(tools, "listpets") {
+		t.Fatalf("Got tools %s; want only createpet after the reload", tools)
+	}
+	response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 2, "method": "resources/list"}`))
+	if encoded, _ := json.Marshal(response); strings.Contains(string(encoded), "openapi://pets/pets") {
+		t.Errorf("Got resources %s; want the resource of listPets to be removed", encoded)
+	}
+	response = s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 3, "method": "resources/read", "params": {"uri": "openapi://pets/pets"}}`))
+	if encoded, _ := json.Marshal(response); !strings.Contains(string(encoded), "no longer exists") {
+		t.Errorf("Got response %s; want reading the removed resource to fail", encoded)
+	}
+
+	// An invalid document keeps the last good tools
+	if err := os.WriteFile(specPath, []byte("{not valid"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if tools := listTools(); !strings.Contains(tools, "createpet") {
+		t.Fatalf("Got tools %s; want createpet to be kept", tools)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reloadErrs) == 0 || !strings.Contains(reloadErrs[0].Error(), specPath) {
+		t.Errorf("Got reload errors %v; want the failed reload to be reported", reloadErrs)
+	}
+}
+
+func Test_WatchSpecFileReusesServerConfig(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	writeSpec := func(summary string) {
+		spec := "openapi: 3.0.0\ninfo:\n  title: Pets\n  version: '1'\npaths:\n  /pets:\n    get:\n      operationId: listPets\n      summary: " + summary + "\n"
+		if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSpec("List pets")
+
+	s, err := NewMCPFromFile(specPath, ts.URL, nil, WithReloadInterval(5*time.Millisecond), WithResponseCache(time.Minute, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		WatchSpecFile(ctx, s)
+		close(done)
+	}()
+
+	callTool := func() {
+		s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "listpets", "arguments": {}}}`))
+	}
+	callTool()
+	time.Sleep(20 * time.Millisecond)
+	writeSpec("List all pets")
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`))
+		if encoded, _ := json.Marshal(response); strings.Contains(string(encoded), "List all pets") {
+			break
+		}
+	}
+
+	// The rebuilt tool shares the server's response cache
+	callTool()
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("Got %d upstream requests; want the cached response to be reused after the reload", got)
+	}
+	cancel()
+	<-done
+}
+
+func Test_WatchSpecFileUnknownServer(t *testing.T) {
+	parser, err := ParseOpenAPI([]byte(`{"openapi": "3.0.0", "info": {"title": "Pets", "version": "1"}, "paths": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewMCPFromCustomParser("http://localhost", nil, parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WatchSpecFile(context.Background(), s); err == nil {
+		t.Error("Got no error; want an error for a server not created by NewMCPFromFile")
+	}
+}
@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourceURIScheme is the scheme of the resources registered for GET operations
+const resourceURIScheme = "openapi"
+
+// WithGETResources also registers GET operations whose only required parameters are path
+// parameters as MCP resources, for clients that browse resources instead of calling tools.
+// Operations without path parameters become resources such as openapi://petstore/pets,
+// the others resource templates such as openapi://petstore/pets/{petId}.
+// Reads perform the same request as the operation's tool.
+func WithGETResources(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.getResources = enabled
+	}
+}
+
+// isResourceOperation reports whether an operation can be read as a resource
+func isResourceOperation(api APIEndpoint) bool {
+	if api.Method != "GET" {
+		return false
+	}
+	for _, param := range api.Parameters {
+		if param.Required && param.In != "path" {
+			return false
+		}
+	}
+	return true
+}
+
+// successMediaType returns the media type of the operation's success response, preferring
+// the lowest 2xx status code and application/json, or "" if none is declared
+func successMediaType(api APIEndpoint) string {
+	statuses := make([]string, 0, len(api.Responses))
+	for status := range api.Responses {
+		if len(status) == 3 && status[0] == '2' {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Strings(statuses)
+
+	for _, status := range statuses {
+		content := api.Responses[status].Content
+		if _, ok := content["application/json"]; ok {
+			return "application/json"
+		}
+		names := make([]string, 0, len(content))
+		for name := range content {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			return names[0]
+		}
+	}
+	return ""
+}
+
+// addResource registers the operation as a resource, or a resource template if it has path parameters
+func addResource(s *server.MCPServer, resources *resourceSet, prefix string, api APIEndpoint, description string, handler server.ToolHandlerFunc) {
+	uri := fmt.Sprintf("%s://%s%s", resourceURIScheme, prefix, api.Path)
+	resources.add(uri)
+	name := api.OperationID
+	if name == "" {
+		name = api.Method + " " + api.Path
+	}
+	mimeType := successMediaType(api)
+
+	read := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if resources.isRetired(uri) {
+			return nil, fmt.Errorf("resource %s no longer exists", request.Params.URI)
+		}
+		call := mcp.CallToolRequest{}
+		call.Params.Name = name
+		// Arguments other than pathNames would be taken as flat arguments, and sent in the body
+		call.Params.Arguments = map[string]interface{}{}
+		if pathNames := resourcePathArguments(request.Params.Arguments); len(pathNames) > 0 {
+			call.Params.Arguments["pathNames"] = pathNames
+		}
+
+		result, err := handler(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+		return resourceContents(request.Params.URI, mimeType, result)
+	}
+
+	if !strings.Contains(api.Path, "{") {
+		s.AddResource(mcp.NewResource(uri, name, mcp.WithResourceDescription(description), mcp.WithMIMEType(mimeType)), read)
+		return
+	}
+	s.AddResourceTemplate(mcp.NewResourceTemplate(uri, name, mcp.WithTemplateDescription(description), mcp.WithTemplateMIMEType(mimeType)), read)
+}
+
+// resourceSet tracks the operation resources registered on a server. mcp-go cannot unregister
+// resources, so those of operations removed by a reload are retired instead: they are left out
+// of the listings and refuse to be read.
+type resourceSet struct {
+	mu      sync.RWMutex
+	current map[string]bool // URIs registered by the latest build
+	retired map[string]bool
+}
+
+// newResourceSet creates an empty resource set
+func newResourceSet() *resourceSet {
+	return &resourceSet{current: map[string]bool{}, retired: map[string]bool{}}
+}
+
+// add records a registered resource, bringing it back if it was retired
+func (r *resourceSet) add(uri string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current[uri] = true
+	delete(r.retired, uri)
+}
+
+// isRetired reports whether the resource belongs to a removed operation
+func (r *resourceSet) isRetired(uri string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.retired[uri]
+}
+
+// reset starts a new build and returns the resources registered by the previous one
+func (r *resourceSet) reset() map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := r.current
+	r.current = map[string]bool{}
+	return previous
+}
+
+// finish ends the build started by reset. If it succeeded, the previous resources it did not
+// register again are retired; otherwise they are all kept.
+func (r *resourceSet) finish(previous map[string]bool, succeeded bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for uri := range previous {
+		switch {
+		case !succeeded:
+			r.current[uri] = true
+		case !r.current[uri]:
+			r.retired[uri] = true
+		}
+	}
+}
+
+// hooks returns server hooks removing retired resources from the listings
+func (r *resourceSet) hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterListResources(func(id any, message *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+		kept := result.Resources[:0]
+		for _, resource := range result.Resources {
+			if !r.isRetired(resource.URI) {
+				kept = append(kept, resource)
+			}
+		}
+		result.Resources = kept
+	})
+	hooks.AddAfterListResourceTemplates(func(id any, message *mcp.ListResourceTemplatesRequest, result *mcp.ListResourceTemplatesResult) {
+		kept := result.ResourceTemplates[:0]
+		for _, template := range result.ResourceTemplates {
+			if !r.isRetired(template.URITemplate.Raw()) {
+				kept = append(kept, template)
+			}
+		}
+		result.ResourceTemplates = kept
+	})
+	return hooks
+}
+
+// resourcePathArguments converts the variables matched by a resource template into path arguments.
+// Template variables are matched as lists of strings; single values are unwrapped.
+func resourcePathArguments(arguments map[string]interface{}) map[string]interface{} {
+	pathArgs := make(map[string]interface{}, len(arguments))
+	for name, value := range arguments {
+		if values, ok := value.([]string); ok && len(values) == 1 {
+			pathArgs[name] = values[0]
+			continue
+		}
+		pathArgs[name] = value
+	}
+	return pathArgs
+}
+
+// resourceContents converts the result of a tool call into the contents of a resource read
+func resourceContents(uri, mimeType string, result *mcp.CallToolResult) ([]mcp.ResourceContents, error) {
+	var contents []mcp.ResourceContents
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			if result.IsError {
+				return nil, fmt.Errorf("%s", c.Text)
+			}
+			contents = append(contents, mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: c.Text})
+		case mcp.ImageContent:
+			contents = append(contents, mcp.BlobResourceContents{URI: uri, MIMEType: c.MIMEType, Blob: c.Data})
+		case mcp.EmbeddedResource:
+			switch resource := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				resource.URI = uri
+				contents = append(contents, resource)
+			case mcp.BlobResourceContents:
+				resource.URI = uri
+				contents = append(contents, resource)
+			}
+		}
+	}
+	return contents, nil
+}
+
+// specResourceURI is the URI of the resource serving the OpenAPI document
+const specResourceURI = "openapi://spec"
+
+// SpecSource is implemented by parsers that keep the document they were created from,
+// such as SimpleOpenAPIParser
+type SpecSource interface {
+	// Source returns the document and its media type, e.g. application/json or application/yaml
+	Source() ([]byte, string)
+}
+
+// WithSpecResource registers the OpenAPI document as a read-only resource at openapi://spec,
+// in its original JSON or YAML form, so clients can read the full API description.
+// It requires a parser implementing SpecSource.
+func WithSpecResource(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.specResource = enabled
+	}
+}
+
+// addSpecResource registers the document of the parser as a resource
+func addSpecResource(s *server.MCPServer, parser OpenAPIParser, info APIInfo) error {
+	source, ok := parser.(SpecSource)
+	if !ok {
+		return fmt.Errorf("parser %T does not provide the source document", parser)
+	}
+	data, mimeType := source.Source()
+	if len(data) == 0 {
+		return fmt.Errorf("parser has no source document")
+	}
+
+	resource := mcp.NewResource(specResourceURI, "OpenAPI specification",
+		mcp.WithResourceDescription(fmt.Sprintf("OpenAPI document of %s %s", info.Title, info.Version)),
+		mcp.WithMIMEType(mimeType),
+	)
+	s.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: specResourceURI, MIMEType: mimeType, Text: string(data)},
+		}, nil
+	})
+	return nil
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_ReadGETResource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer ts.Close()
+
+	spec := `{"openapi": "3.0.0", "info": {"title": "Pet Store", "version": "1"}, "paths": {
+		"/pets/{petId}": {"get": {"operationId": "getPet", "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "string"}}],
+			"responses": {"200": {"content": {"application/json": {}}}}}},
+		"/search": {"get": {"operationId": "search", "parameters": [{"name": "q", "in": "query", "required": true, "schema": {"type": "string"}}]}}
+	}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewMCPFromCustomParser(ts.URL, nil, parser, WithGETResources(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": {"uri": "openapi://pet_store/pets/42"}}`))
+	encoded, _ := json.Marshal(response)
+	if !strings.Contains(string(encoded), `{\"path\":\"/pets/42\"}`) || !strings.Contains(string(encoded), `"mimeType":"application/json"`) {
+		t.Errorf("Got response %s; want the pet as JSON", encoded)
+	}
+
+	response = s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 2, "method": "resources/templates/list"}`))
+	encoded, _ = json.Marshal(response)
+	if strings.Contains(string(encoded), "search") {
+		t.Errorf("Got templates %s; want no resource for an operation with a required query parameter", encoded)
+	}
+}
+
+func Test_ReadParameterlessGETResource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"body":"` + string(body) + `","length":` + strconv.FormatInt(r.ContentLength, 10) + `}`))
+	}))
+	defer ts.Close()
+
+	spec := `{"openapi": "3.0.0", "info": {"title": "Pet Store", "version": "1"}, "paths": {
+		"/pets": {"get": {"operationId": "listPets", "responses": {"200": {"content": {"application/json": {}}}}}}
+	}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewMCPFromCustomParser(ts.URL, nil, parser, WithGETResources(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": {"uri": "openapi://pet_store/pets"}}`))
+	encoded, _ := json.Marshal(response)
+	if !strings.Contains(string(encoded), `{\"body\":\"\",\"length\":0}`) {
+		t.Errorf("Got response %s; want a request without a body", encoded)
+	}
+}
+
+func Test_SpecResource(t *testing.T) {
+	spec := "openapi: 3.0.0\ninfo:\n  title: Pet Store\n  version: 1.0\npaths: {}\n"
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewMCPFromCustomParser("http://localhost", nil, parser, WithSpecResource(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": {"uri": "openapi://spec"}}`))
+	encoded, _ := json.Marshal(response)
+	if !strings.Contains(string(encoded), `"mimeType":"application/yaml"`) || !strings.Contains(string(encoded), `title: Pet Store`) {
+		t.Errorf("Got response %s; want the original YAML document", encoded)
+	}
+}
+
+func Test_SpecResourceOfFilteredParser(t *testing.T) {
+	spec := "openapi: 3.0.0\ninfo:\n  title: Pet Store\n  version: 1.0\npaths: {}\n"
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered := &FilteredOpenAPIParser{BaseParser: parser}
+	s, err := NewMCPFromCustomParser("http://localhost", nil, filtered, WithSpecResource(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": {"uri": "openapi://spec"}}`))
+	encoded, _ := json.Marshal(response)
+	if !strings.Contains(string(encoded), `title: Pet Store`) {
+		t.Errorf("Got response %s; want the document of the wrapped parser", encoded)
+	}
+}
@@ -0,0 +1,474 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ContentDecoder wraps a compressed response body in a reader returning the decompressed bytes
+type ContentDecoder func(r io.Reader) (io.Reader, error)
+
+// defaultEnvelopeHeaders are the response headers included in a response envelope
+// when no explicit allow-list is configured
+var defaultEnvelopeHeaders = []string{
+	"Content-Type",
+	"Content-Length",
+	"Content-Range",
+	"Location",
+	"Link",
+	"ETag",
+	"Last-Modified",
+	"Retry-After",
+	"X-Total-Count",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+}
+
+// sensitiveResponseHeaders are never included by the "*" wildcard and must be listed explicitly
+var sensitiveResponseHeaders = []string{
+	"Set-Cookie",
+	"Set-Cookie2",
+	"WWW-Authenticate",
+	"Proxy-Authenticate",
+}
+
+// responseEnvelope is the structured tool result returned when response envelopes are enabled
+type responseEnvelope struct {
+	Status  int               `json:"status"`
+	URL     string            `json:"url,omitempty"` // Request URL, when enabled with WithRequestURL
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body"`
+	// DurationMs is the time spent on the upstream requests in milliseconds, when enabled with
+	// WithRequestDuration; it is left out for responses served from the cache or mocked
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// WithResponseEnvelope wraps every tool result in a JSON envelope holding the HTTP status,
+// the selected response headers and the body. Headers are matched case-insensitively;
+// "*" selects all headers except cookies and authentication challenges, which must be listed explicitly.
+// If no headers are given, a default set of content, caching, pagination and rate-limit headers is used.
+func WithResponseEnvelope(headers ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.envelope = true
+		c.envelopeHeaders = headers
+		if len(headers) == 0 {
+			c.envelopeHeaders = defaultEnvelopeHeaders
+		}
+	}
+}
+
+// WithRequestURL includes the URL each tool call requested, after path and query parameters,
+// defaults and server variables were applied, so callers can check where their arguments landed.
+// It is the "url" field of response envelopes and a leading "Request URL: ..." text otherwise.
+// Query parameters redacted in logs are masked; credentials sent by auth are not part of the URL.
+func WithRequestURL(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.showURL = enabled
+	}
+}
+
+// WithRequestDuration includes the time spent on the upstream requests of each tool call,
+// retries and further pages included, to help reason about slow calls and tune timeouts.
+// It is the "durationMs" field of response envelopes and a trailing "Duration: ..." text otherwise.
+// The duration is always logged and reported to metrics.
+func WithRequestDuration(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.showDuration = enabled
+	}
+}
+
+// selectHeaders returns the response headers matching the allow-list, joining repeated values with ", "
+func selectHeaders(header http.Header, allowList []string) map[string]string {
+	selected := map[string]string{}
+	for _, name := range allowList {
+		if name == "*" {
+			for key, values := range header {
+				if !isSensitiveResponseHeader(key) {
+					selected[key] = strings.Join(values, ", ")
+				}
+			}
+			continue
+		}
+		if values := header.Values(name); len(values) > 0 {
+			selected[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+		}
+	}
+	return selected
+}
+
+// isSensitiveResponseHeader reports whether a header is excluded from the "*" wildcard
+func isSensitiveResponseHeader(name string) bool {
+	for _, sensitive := range sensitiveResponseHeaders {
+		if strings.EqualFold(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// newResponseEnvelope builds the envelope for a response. JSON bodies are embedded as-is,
+// any other body is embedded as a string.
+func newResponseEnvelope(resp *http.Response, body []byte, allowList []string) responseEnvelope {
+	envelope := responseEnvelope{
+		Status:  resp.StatusCode,
+		Headers: selectHeaders(resp.Header, allowList),
+		Body:    string(body),
+	}
+	if json.Valid(body) {
+		envelope.Body = json.RawMessage(body)
+	}
+	return envelope
+}
+
+// optionsHeaders are the response headers of OPTIONS requests returned as their result
+var optionsHeaders = []string{
+	"Allow",
+	"Accept-Patch",
+	"Accept-Post",
+	"Access-Control-Allow-Origin",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+}
+
+// headersResult is the tool result of HEAD and OPTIONS requests, whose headers are what the caller is after
+type headersResult struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// newHeadersResult returns the status and headers of a HEAD or OPTIONS response: all headers except
+// cookies and authentication challenges for HEAD, the Allow and CORS headers for OPTIONS.
+// An OPTIONS response body, which some APIs use to describe the resource, is included as well.
+func newHeadersResult(method string, resp *http.Response, body []byte) *mcp.CallToolResult {
+	allowList := []string{"*"}
+	if method == http.MethodOptions {
+		allowList = optionsHeaders
+	}
+	result := headersResult{Status: resp.StatusCode, Headers: selectHeaders(resp.Header, allowList)}
+	if method == http.MethodOptions && len(body) > 0 {
+		result.Body = string(body)
+		if json.Valid(body) {
+			result.Body = json.RawMessage(body)
+		}
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Error marshaling response: %v", err))
+	}
+	return mcp.NewToolResultText(string(encoded))
+}
+
+// defaultEmptyResponseMessage is the result of successful responses without a body
+const defaultEmptyResponseMessage = "Request succeeded ({status})"
+
+// WithEmptyResponseMessage sets the result returned for successful responses without a body, such as
+// 204 No Content, which models tend to mistake for a failure when returned as an empty text.
+// "{status}" in the message is replaced by the status, e.g. "204 No Content", and the given response
+// headers, such as Location or ETag, are appended when present. They are matched like envelope headers.
+// An empty message returns the empty body unchanged.
+func WithEmptyResponseMessage(message string, headers ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.emptyMessage = message
+		c.emptyHeaders = headers
+	}
+}
+
+// newEmptyResult returns the message describing a successful response without a body
+func newEmptyResult(resp *http.Response, message string, allowList []string) *mcp.CallToolResult {
+	status := fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	text := strings.ReplaceAll(message, "{status}", strings.TrimSpace(status))
+
+	selected := selectHeaders(resp.Header, allowList)
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		text += fmt.Sprintf("\n%s: %s", name, selected[name])
+	}
+	return mcp.NewToolResultText(text)
+}
+
+// WithJSONContent returns JSON responses as embedded application/json resources instead of plain text,
+// so clients can treat results as data. The body is validated and re-emitted in compact form;
+// responses with other content types, or with invalid JSON, are still returned as text.
+func WithJSONContent(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.jsonContent = enabled
+	}
+}
+
+// isJSONContentType reports whether a Content-Type header denotes JSON, including +json suffixes
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// newJSONResult returns the JSON body as an embedded resource identified by the request URL,
+// or nil if the body is not valid JSON
+func newJSONResult(uri string, body []byte) *mcp.CallToolResult {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err != nil {
+		return nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewEmbeddedResource(mcp.TextResourceContents{
+				URI:      uri,
+				MIMEType: "application/json",
+				Text:     compact.String(),
+			}),
+		},
+	}
+}
+
+// defaultBinaryContentTypes are the Content-Type prefixes returned as binary content by default
+var defaultBinaryContentTypes = []string{
+	"image/",
+	"audio/",
+	"video/",
+	"application/octet-stream",
+	"application/pdf",
+	"application/zip",
+}
+
+// WithBinaryContentTypes replaces the Content-Type prefixes, such as "image/" or "application/pdf",
+// of responses that are returned as base64-encoded binary content instead of text.
+// Images become MCP image content, other binary types embedded blob resources.
+func WithBinaryContentTypes(prefixes ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.binaryContentTypes = prefixes
+	}
+}
+
+// isBinaryContentType reports whether a Content-Type header matches one of the binary prefixes
+func isBinaryContentType(contentType string, prefixes []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(mediaType, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// newBinaryResult returns a binary body as base64-encoded image content or, for other media types,
+// as an embedded blob resource identified by the request URL
+func newBinaryResult(uri, contentType string, body []byte) *mcp.CallToolResult {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	data := base64.StdEncoding.EncodeToString(body)
+
+	var content mcp.Content
+	if strings.HasPrefix(mediaType, "image/") {
+		content = mcp.NewImageContent(data, mediaType)
+	} else {
+		content = mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+			URI:      uri,
+			MIMEType: mediaType,
+			Blob:     data,
+		})
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{content}}
+}
+
+// defaultContentDecoders are the content codings decoded without any extra configuration
+var defaultContentDecoders = map[string]ContentDecoder{
+	"gzip":    decodeGzip,
+	"x-gzip":  decodeGzip,
+	"deflate": decodeDeflate,
+	"br":      decodeBrotli,
+}
+
+// WithContentDecoder registers a decoder for a Content-Encoding value, e.g. "zstd".
+// gzip, deflate and br are decoded out of the box; registering one of them replaces the built-in decoder.
+func WithContentDecoder(encoding string, decoder ContentDecoder) AdapterOption {
+	return func(c *adapterConfig) {
+		c.decoders[strings.ToLower(encoding)] = decoder
+	}
+}
+
+func decodeGzip(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func decodeBrotli(r io.Reader) (io.Reader, error) {
+	return brotli.NewReader(r), nil
+}
+
+// decodeDeflate handles both zlib-wrapped deflate, as mandated by HTTP, and the raw deflate
+// streams some servers send instead
+func decodeDeflate(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodeResponseBody wraps the response body according to its Content-Encoding header.
+// Codings are undone in reverse order of application. If any coding has no registered decoder,
+// the body is returned untouched so the raw bytes are still available.
+func decodeResponseBody(resp *http.Response, decoders map[string]ContentDecoder) (io.Reader, error) {
+	var encodings []string
+	for _, value := range resp.Header.Values("Content-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding != "" && encoding != "identity" {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+	if len(encodings) == 0 {
+		return resp.Body, nil
+	}
+
+	for _, encoding := range encodings {
+		if _, ok := decoders[encoding]; !ok {
+			return resp.Body, nil
+		}
+	}
+
+	var reader io.Reader = resp.Body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		decoded, err := decoders[encodings[i]](reader)
+		if err != nil {
+			return nil, err
+		}
+		reader = decoded
+	}
+
+	// The headers now describe the decoded body, matching what net/http does for transparent gzip
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return reader, nil
+}
+
+// readLimited reads the body up to limit bytes and reports whether it was cut off.
+// A zero or negative limit reads the whole body.
+func readLimited(r io.Reader, limit int64) ([]byte, bool, error) {
+	if limit <= 0 {
+		body, err := io.ReadAll(r)
+		return body, false, err
+	}
+	// Read one extra byte to tell a body of exactly limit bytes from a longer one
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(body)) > limit {
+		return body[:limit], true, nil
+	}
+	return body, false, nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence left at the end of a truncated body
+func trimPartialRune(body []byte) []byte {
+	for i := len(body) - 1; i >= 0 && i >= len(body)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if !utf8.FullRune(body[i:]) {
+				return body[:i]
+			}
+			break
+		}
+	}
+	return body
+}
+
+// totalSizeNote describes the full size of a response when the server declared it
+func totalSizeNote(resp *http.Response) string {
+	if resp.ContentLength > 0 {
+		return fmt.Sprintf(" (total size %d bytes)", resp.ContentLength)
+	}
+	return ""
+}
+
+// truncationNote is appended to a text body that was cut off at the size limit
+func truncationNote(limit int64, resp *http.Response) string {
+	return fmt.Sprintf("\n\n[Response truncated after %d bytes%s]", limit, totalSizeNote(resp))
+}
+
+// maxShapeDepth limits how many levels of nested objects are spelled out by describeSchemaShape
+const maxShapeDepth = 3
+
+// describeSchemaShape renders a compact, TypeScript-like outline of a schema for tool descriptions,
+// e.g. {id: integer, name: string, tags?: string[]}. Optional properties are marked with "?".
+func describeSchemaShape(schema *Schema, depth int) string {
+	if schema == nil {
+		return "any"
+	}
+
+	var shape string
+	switch {
+	case schema.Type == "array" || (schema.Type == "" && schema.Items != nil):
+		shape = describeSchemaShape(schema.Items, depth) + "[]"
+	case len(schema.Properties) > 0:
+		if depth >= maxShapeDepth {
+			shape = "object"
+			break
+		}
+		fields := make([]string, 0, len(schema.Properties))
+		for _, name := range sortedSchemaNames(schema.Properties) {
+			prop := schema.Properties[name]
+			if !isRequiredField(name, schema.Required) {
+				name += "?"
+			}
+			fields = append(fields, name+": "+describeSchemaShape(&prop, depth+1))
+		}
+		shape = "{" + strings.Join(fields, ", ") + "}"
+	case len(schema.Enum) > 0:
+		values := make([]string, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			encoded, _ := json.Marshal(value)
+			values = append(values, string(encoded))
+		}
+		shape = strings.Join(values, " | ")
+	case schema.Type != "":
+		shape = schema.Type
+	default:
+		shape = "any"
+	}
+
+	if schema.Nullable {
+		shape += " | null"
+	}
+	return shape
+}
+
+// sortedSchemaNames returns the property names of a schema in sorted order
+func sortedSchemaNames(properties map[string]Schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed upstream requests are retried
+type RetryPolicy struct {
+	MaxAttempts        int           // Total number of attempts including the first one; values below 2 disable retries
+	BaseDelay          time.Duration // Delay before the first retry, doubled on each subsequent retry
+	MaxDelay           time.Duration // Upper bound for the delay between two attempts; zero means no bound
+	RetryNonIdempotent bool          // If true, POST and PATCH requests are retried as well
+	MaxRetryAfter      time.Duration // Longest Retry-After wait to honor; zero falls back to MaxDelay, and no cap if both are zero
+}
+
+// retryAfterError is returned when the upstream asks to wait longer than the policy allows
+type retryAfterError struct {
+	wait    time.Duration
+	maxWait time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return fmt.Sprintf("upstream rate limited the request and asked to retry after %s, which exceeds the maximum wait of %s", e.wait, e.maxWait)
+}
+
+// WithRetryPolicy enables retrying transient upstream failures with exponential backoff and jitter
+func WithRetryPolicy(policy RetryPolicy) AdapterOption {
+	return func(c *adapterConfig) {
+		c.retry = policy
+	}
+}
+
+// retryableStatus reports whether a response status indicates a transient upstream failure
+func retryableStatus(status int) bool {
+	return status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+// isIdempotentMethod reports whether repeating a request with this method has no additional side effects
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether a transport error is likely to succeed on retry
+func isTransientError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses a Retry-After header value in either delta-seconds or HTTP-date form
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// isRetryAfterResponse reports whether the response asks the client to come back later.
+// Such requests were rejected before being processed, so they are safe to retry for any method.
+func isRetryAfterResponse(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "")
+}
+
+// maxRetryAfter returns the longest Retry-After wait the policy honors, or zero for no limit
+func (p RetryPolicy) maxRetryAfter() time.Duration {
+	if p.MaxRetryAfter > 0 {
+		return p.MaxRetryAfter
+	}
+	return p.MaxDelay
+}
+
+// canRetry reports whether requests with the given method may be retried under this policy
+func (p RetryPolicy) canRetry(method string) bool {
+	return p.MaxAttempts > 1 && (isIdempotentMethod(method) || p.RetryNonIdempotent)
+}
+
+// backoff returns the delay before the given retry (1 for the first retry), with jitter applied
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Use "equal jitter": half of the delay is fixed, the other half random
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doWithRetry sends the request built by newRequest, retrying transient failures according to the policy.
+// newRequest is called once per attempt so that the request body can be replayed.
+// Rate-limited responses honor the Retry-After header and count against the same attempt limit.
+// It returns the final response or error along with the number of attempts made.
+func doWithRetry(ctx context.Context, client *http.Client, policy RetryPolicy, method string, newRequest func() (*http.Request, error)) (*http.Response, int, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, attempt, err
+		}
+
+		resp, err := client.Do(req)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return resp, attempt, err
+		}
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if !policy.canRetry(method) || !isTransientError(err) {
+				return nil, attempt, err
+			}
+			delay = policy.backoff(attempt)
+		case isRetryAfterResponse(resp):
+			wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				wait = policy.backoff(attempt)
+			}
+			if maxWait := policy.maxRetryAfter(); maxWait > 0 && wait > maxWait {
+				resp.Body.Close()
+				return nil, attempt, &retryAfterError{wait: wait, maxWait: maxWait}
+			}
+			delay = wait
+		case retryableStatus(resp.StatusCode) && policy.canRetry(method):
+			delay = policy.backoff(attempt)
+		default:
+			return resp, attempt, nil
+		}
+
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, attempt, err
+		}
+	}
+}
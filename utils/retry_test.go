@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_RetryTransientStatus(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, nil, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+	}))
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", calls.Load())
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "ok" {
+		t.Fatalf("Unexpected result: %q", text)
+	}
+}
+
+func Test_RetrySkipsNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodPost, ts.URL, nil, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+	}))
+
+	if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("Expected POST to be attempted once, got %d", calls.Load())
+	}
+}
+
+func Test_IdempotencyKeyReusedOnRetry(t *testing.T) {
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("X-Idempotency-Key"))
+		if len(keys)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodPost, ts.URL, nil, WithIdempotencyKeys("X-Idempotency-Key"), WithRetryPolicy(RetryPolicy{
+		MaxAttempts:        2,
+		BaseDelay:          time.Millisecond,
+		RetryNonIdempotent: true,
+	}))
+	for i := 0; i < 2; i++ {
+		if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if len(keys) != 4 || keys[0] == "" || keys[0] != keys[1] || keys[2] != keys[3] || keys[1] == keys[2] {
+		t.Fatalf("Expected one key per call reused on retry, got %q", keys)
+	}
+}
+
+func Test_ParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func Test_RetryAfterExceedsMaxWait(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodGet, ts.URL, nil, WithRetryPolicy(RetryPolicy{
+		MaxAttempts:   3,
+		MaxRetryAfter: time.Second,
+	}))
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("Expected a single attempt, got %d", calls.Load())
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "exceeds the maximum wait") {
+		t.Fatalf("Unexpected result: %q", text)
+	}
+}
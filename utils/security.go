@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// Credentials are secrets matched against the security schemes declared by the spec.
+// Only the fields relevant to the scheme type are used.
+type Credentials struct {
+	Token        string // Used for http bearer schemes, and for oauth2/openIdConnect schemes as a ready-made access token
+	Username     string // Used for http basic schemes
+	Password     string
+	APIKey       string // Used for apiKey schemes, sent where the scheme declares
+	ClientID     string // Used for oauth2 schemes with a clientCredentials flow
+	ClientSecret string
+}
+
+// WithSecurityCredentials supplies credentials for the security scheme with the given name,
+// as declared in components.securitySchemes. Use "*" to supply credentials for any scheme.
+// Operations requiring a scheme with matching credentials get them attached automatically,
+// unless explicit credentials are configured with WithAuth or OperationConfig.Auth.
+func WithSecurityCredentials(schemeName string, creds Credentials) AdapterOption {
+	return func(c *adapterConfig) {
+		c.credentials[schemeName] = creds
+	}
+}
+
+// SecuritySchemeSource is implemented by parsers whose API declares security schemes,
+// such as SimpleOpenAPIParser. Credentials supplied with WithSecurityCredentials are only
+// attached to the operations of parsers implementing it.
+type SecuritySchemeSource interface {
+	// SecuritySchemes returns the security schemes declared by the API, keyed by name
+	SecuritySchemes() map[string]SecurityScheme
+}
+
+// securitySchemes returns the security schemes of the parser, or nil if it declares none
+func securitySchemes(parser OpenAPIParser) map[string]SecurityScheme {
+	if source, ok := parser.(SecuritySchemeSource); ok {
+		return source.SecuritySchemes()
+	}
+	return nil
+}
+
+// credentialsFor returns the credentials supplied for a scheme, falling back to the "*" entry
+func (c *adapterConfig) credentialsFor(schemeName string) (Credentials, bool) {
+	if creds, ok := c.credentials[schemeName]; ok {
+		return creds, true
+	}
+	creds, ok := c.credentials["*"]
+	return creds, ok
+}
+
+// schemeAuth converts a security scheme and the supplied credentials into an Auth.
+// It returns nil if the credentials do not fit the scheme.
+func schemeAuth(scheme SecurityScheme, creds Credentials, scopes []string) *Auth {
+	switch strings.ToLower(scheme.Type) {
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "bearer":
+			if creds.Token != "" {
+				return &Auth{BearerToken: creds.Token}
+			}
+		case "basic":
+			if creds.Username != "" || creds.Password != "" {
+				return &Auth{Basic: &BasicAuth{Username: creds.Username, Password: creds.Password}}
+			}
+		}
+	case "apikey":
+		if creds.APIKey != "" && scheme.Name != "" {
+			return &Auth{APIKey: &APIKeyAuth{Name: scheme.Name, Value: creds.APIKey, In: scheme.In}}
+		}
+	case "oauth2", "openidconnect":
+		if creds.Token != "" {
+			return &Auth{BearerToken: creds.Token}
+		}
+		if creds.ClientID != "" && scheme.TokenURL != "" {
+			return &Auth{OAuth2: &OAuth2ClientCredentials{
+				TokenURL:     scheme.TokenURL,
+				ClientID:     creds.ClientID,
+				ClientSecret: creds.ClientSecret,
+				Scopes:       scopes,
+			}}
+		}
+	}
+	return nil
+}
+
+// securityResolver derives the credentials of each operation from its security requirements
+type securityResolver struct {
+	cfg     *adapterConfig
+	schemes map[string]SecurityScheme
+	cache   map[string]*Auth // Auths already built, so OAuth2 token caches are shared between operations
+}
+
+// newSecurityResolver creates a resolver for the schemes declared by a spec
+func newSecurityResolver(cfg *adapterConfig, schemes map[string]SecurityScheme) *securityResolver {
+	return &securityResolver{
+		cfg:     cfg,
+		schemes: schemes,
+		cache:   map[string]*Auth{},
+	}
+}
+
+// resolve returns the credentials for the first security requirement that can be fully satisfied,
+// or nil if the operation needs no auth or no matching credentials were supplied
+func (r *securityResolver) resolve(requirements []SecurityRequirement) []*Auth {
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		auths := []*Auth{}
+		for _, name := range names {
+			auth := r.schemeAuth(name, requirement[name])
+			if auth == nil {
+				auths = nil
+				break
+			}
+			auths = append(auths, auth)
+		}
+		if auths != nil {
+			return auths
+		}
+	}
+	return nil
+}
+
+// schemeAuth returns the credentials for a single scheme, building them at most once
+func (r *securityResolver) schemeAuth(name string, scopes []string) *Auth {
+	key := name + "|" + strings.Join(scopes, " ")
+	if auth, ok := r.cache[key]; ok {
+		return auth
+	}
+
+	var auth *Auth
+	if scheme, ok := r.schemes[name]; ok {
+		if creds, ok := r.cfg.credentialsFor(name); ok {
+			auth = schemeAuth(scheme, creds, scopes)
+			auth.prepare(r.cfg.httpClient)
+		}
+	}
+	r.cache[key] = auth
+	return auth
+}
+
+// securityDescription describes the security requirements of an operation for the tool description
+func securityDescription(requirements []SecurityRequirement) string {
+	var alternatives []string
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		alternatives = append(alternatives, strings.Join(names, " + "))
+	}
+	if len(alternatives) == 0 {
+		return ""
+	}
+	return "[requires auth: " + strings.Join(alternatives, " or ") + "]"
+}
@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPI parameter serialization styles
+const (
+	styleForm           = "form"
+	styleSpaceDelimited = "spaceDelimited"
+	stylePipeDelimited  = "pipeDelimited"
+	styleDeepObject     = "deepObject"
+)
+
+// queryStyle returns the serialization style of a query parameter, defaulting to form
+func (p Parameter) queryStyle() string {
+	if p.Style == "" {
+		return styleForm
+	}
+	return p.Style
+}
+
+// explode reports whether arrays and objects are serialized as separate parameters.
+// Per the OpenAPI specification this defaults to true for the form style and false otherwise.
+func (p Parameter) explode() bool {
+	if p.Explode != nil {
+		return *p.Explode
+	}
+	return p.queryStyle() == styleForm
+}
+
+// formatScalar converts a primitive argument value to its string form in a URL.
+// Numbers are formatted without exponents, since JSON numbers are decoded as float64.
+func formatScalar(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// unixDateTimeFormat writes date-time parameters as seconds since the epoch
+const unixDateTimeFormat = "unix"
+
+// WithBooleanFormat sets how boolean path, query and header parameters are written, e.g. "1" and "0"
+// for APIs that do not accept "true" and "false". Booleans in request bodies keep their JSON form.
+func WithBooleanFormat(trueValue, falseValue string) AdapterOption {
+	return func(c *adapterConfig) {
+		c.boolFormat = &[2]string{falseValue, trueValue}
+	}
+}
+
+// WithDateTimeFormat sets how path, query and header parameters with the date-time format are written
+// when given as a timestamp: a Go time layout such as time.RFC1123, or "unix" for seconds since the
+// epoch. Timestamps are RFC 3339 strings or numbers of seconds since the epoch; other strings pass
+// through unchanged. By default numbers are written as RFC 3339 in UTC and strings are kept as they are.
+func WithDateTimeFormat(layout string) AdapterOption {
+	return func(c *adapterConfig) {
+		if layout == "" {
+			c.setError(fmt.Errorf("date-time format must not be empty"))
+			return
+		}
+		c.dateTimeFormat = layout
+	}
+}
+
+// formatParams returns the arguments of the parameters in the given location with booleans and
+// timestamps written as configured. Parameters with the date format take the date part of timestamps.
+func (c *adapterConfig) formatParams(args map[string]interface{}, in string, endpoint toolEndpoint) map[string]interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	formatted := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		param, _ := endpoint.parameter(in, name)
+		formatted[name] = c.formatParam(value, param.Schema)
+	}
+	return formatted
+}
+
+// formatParam formats a single argument value, recursing into arrays
+func (c *adapterConfig) formatParam(value interface{}, schema *Schema) interface{} {
+	switch v := value.(type) {
+	case bool:
+		if c.boolFormat == nil {
+			return v
+		}
+		if v {
+			return c.boolFormat[1]
+		}
+		return c.boolFormat[0]
+	case []interface{}:
+		var items *Schema
+		if schema != nil {
+			items = schema.Items
+		}
+		formatted := make([]interface{}, len(v))
+		for i, item := range v {
+			formatted[i] = c.formatParam(item, items)
+		}
+		return formatted
+	}
+	if schema == nil || (schema.Format != "date" && schema.Format != "date-time") {
+		return value
+	}
+
+	var t time.Time
+	switch v := value.(type) {
+	case float64:
+		sec, frac := math.Modf(v)
+		t = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+		if err != nil || (schema.Format == "date-time" && c.dateTimeFormat == "") {
+			return value
+		}
+		t = parsed
+	default:
+		return value
+	}
+
+	switch {
+	case schema.Format == "date":
+		return t.Format(time.DateOnly)
+	case c.dateTimeFormat == unixDateTimeFormat:
+		return strconv.FormatInt(t.Unix(), 10)
+	case c.dateTimeFormat != "":
+		return t.Format(c.dateTimeFormat)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// addQueryParam serializes a query parameter into q according to its OpenAPI style and explode settings
+func addQueryParam(q neturl.Values, name string, value interface{}, param Parameter) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatScalar(item))
+		}
+
+		switch param.queryStyle() {
+		case styleSpaceDelimited:
+			q.Add(name, strings.Join(items, " "))
+		case stylePipeDelimited:
+			q.Add(name, strings.Join(items, "|"))
+		default:
+			if param.explode() {
+				for _, item := range items {
+					q.Add(name, item)
+				}
+			} else {
+				q.Add(name, strings.Join(items, ","))
+			}
+		}
+	case map[string]interface{}:
+		switch {
+		case param.queryStyle() == styleDeepObject:
+			addDeepObject(q, name, v)
+		case param.explode():
+			// form style with explode: each property becomes its own parameter
+			for _, key := range sortedKeys(v) {
+				addQueryParam(q, key, v[key], Parameter{})
+			}
+		default:
+			// form style without explode: name=key1,value1,key2,value2
+			pairs := make([]string, 0, len(v)*2)
+			for _, key := range sortedKeys(v) {
+				pairs = append(pairs, key, formatScalar(v[key]))
+			}
+			q.Add(name, strings.Join(pairs, ","))
+		}
+	default:
+		q.Add(name, formatScalar(v))
+	}
+}
+
+// addDeepObject serializes an object as bracketed keys, e.g. filter[name]=x&filter[age]=30.
+// Nested objects add further brackets and array items repeat the key with a trailing [].
+func addDeepObject(q neturl.Values, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			addDeepObject(q, prefix+"["+key+"]", v[key])
+		}
+	case []interface{}:
+		for _, item := range v {
+			addDeepObject(q, prefix+"[]", item)
+		}
+	default:
+		q.Add(prefix, formatScalar(v))
+	}
+}
+
+// sortedKeys returns the keys of a map in lexical order, so serialization is deterministic
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePathRemainder escapes a multi-segment path value such as "docs/read me.md",
+// keeping the slashes that separate its segments. A leading slash is dropped since the
+// path template already provides the separator, and dot segments are escaped so the value
+// cannot climb out of its position in the path.
+func escapePathRemainder(value string) string {
+	segments := strings.Split(strings.TrimLeft(value, "/"), "/")
+	for i, segment := range segments {
+		if segment == "." || segment == ".." {
+			segments[i] = strings.ReplaceAll(segment, ".", "%2E")
+			continue
+		}
+		segments[i] = neturl.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_AddQueryParamArrayStyles(t *testing.T) {
+	explodeFalse := false
+	value := []interface{}{"blue", "black", float64(3)}
+
+	tests := []struct {
+		param Parameter
+		want  string
+	}{
+		{Parameter{}, "color=blue&color=black&color=3"},
+		{Parameter{Style: "form", Explode: &explodeFalse}, "color=blue%2Cblack%2C3"},
+		{Parameter{Style: "spaceDelimited"}, "color=blue+black+3"},
+		{Parameter{Style: "pipeDelimited"}, "color=blue%7Cblack%7C3"},
+	}
+
+	for _, tt := range tests {
+		q := neturl.Values{}
+		addQueryParam(q, "color", value, tt.param)
+		if got := q.Encode(); got != tt.want {
+			t.Errorf("style %q: got %s, want %s", tt.param.Style, got, tt.want)
+		}
+	}
+}
+
+func Test_AddQueryParamDeepObject(t *testing.T) {
+	value := map[string]interface{}{
+		"name": "x",
+		"age":  float64(30),
+		"address": map[string]interface{}{
+			"city": "Seoul",
+		},
+		"tags": []interface{}{"a", "b"},
+	}
+
+	q := neturl.Values{}
+	addQueryParam(q, "filter", value, Parameter{Style: "deepObject"})
+
+	want := neturl.Values{
+		"filter[name]":          {"x"},
+		"filter[age]":           {"30"},
+		"filter[address][city]": {"Seoul"},
+		"filter[tags][]":        {"a", "b"},
+	}
+	if got, expected := q.Encode(), want.Encode(); got != expected {
+		t.Fatalf("got %s, want %s", got, expected)
+	}
+}
+
+func Test_PathParamsAreEscaped(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.EscapedPath()
+	}))
+	defer ts.Close()
+
+	call := func(opts ...AdapterOption) {
+		handler := NewToolHandler(http.MethodGet, ts.URL+"/files/{name}/{ref}", nil, opts...)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"pathNames": map[string]interface{}{"name": "a b/c#d", "ref": "x/y"},
+		}
+		if _, err := handler(context.Background(), request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	call()
+	if got != "/files/a%20b%2Fc%23d/x%2Fy" {
+		t.Errorf("Unexpected escaped path: %q", got)
+	}
+
+	call(WithUnescapedPathParams("ref"))
+	if got != "/files/a%20b%2Fc%23d/x/y" {
+		t.Errorf("Unexpected path with unescaped parameter: %q", got)
+	}
+}
+
+func Test_EscapePathRemainder(t *testing.T) {
+	tests := map[string]string{
+		"docs/read me.md": "docs/read%20me.md",
+		"/src/main.go":    "src/main.go",
+		"a/../etc/passwd": "a/%2E%2E/etc/passwd",
+		"file#1?.txt":     "file%231%3F.txt",
+	}
+	for value, want := range tests {
+		if got := escapePathRemainder(value); got != want {
+			t.Errorf("escapePathRemainder(%q) = %q; want %q", value, got, want)
+		}
+	}
+}
+
+func Test_FormatParam(t *testing.T) {
+	date := &Schema{Type: "string", Format: "date"}
+	dateTime := &Schema{Type: "string", Format: "date-time"}
+	tests := []struct {
+		opts   []AdapterOption
+		value  interface{}
+		schema *Schema
+		want   interface{}
+	}{
+		{nil, true, nil, true},
+		{[]AdapterOption{WithBooleanFormat("1", "0")}, true, nil, "1"},
+		{[]AdapterOption{WithBooleanFormat("1", "0")}, []interface{}{false, true}, nil, []interface{}{"0", "1"}},
+		{nil, "2024-05-01", date, "2024-05-01"},
+		{nil, "2024-05-01T23:30:00+02:00", date, "2024-05-01"},
+		{nil, float64(1714600000), date, "2024-05-01"},
+		{nil, "2024-05-01T10:00:00Z", dateTime, "2024-05-01T10:00:00Z"},
+		{nil, "tomorrow", dateTime, "tomorrow"},
+		{nil, float64(1714557600), dateTime, "2024-05-01T10:00:00Z"},
+		{[]AdapterOption{WithDateTimeFormat("unix")}, "2024-05-01T10:00:00Z", dateTime, "1714557600"},
+		{[]AdapterOption{WithDateTimeFormat(time.RFC1123)}, "2024-05-01T10:00:00Z", dateTime, "Wed, 01 May 2024 10:00:00 UTC"},
+	}
+	for _, tt := range tests {
+		cfg := newAdapterConfig(tt.opts...)
+		if got := cfg.formatParam(tt.value, tt.schema); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("formatParam(%v) = %#v; want %#v", tt.value, got, tt.want)
+		}
+	}
+}
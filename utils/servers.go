@@ -0,0 +1,83 @@
+package utils
+
+import (
+	neturl "net/url"
+	"strings"
+)
+
+// WithServerVariables sets the values of the variables of server URL templates, such as
+// {"region": "us"} for "https://{region}.example.com". Variables without a value use the
+// default declared by the specification.
+func WithServerVariables(values map[string]string) AdapterOption {
+	return func(c *adapterConfig) {
+		for name, value := range values {
+			c.serverVariables[name] = value
+		}
+	}
+}
+
+// serverURL returns the URL of a server with its variables expanded. Relative server URLs,
+// such as "/v1", are resolved against the URL the specification was loaded from, if known.
+func (c *adapterConfig) serverURL(server Server) (string, error) {
+	url, err := server.expandURL(c.serverVariables)
+	if err != nil || strings.Contains(url, "://") {
+		return url, err
+	}
+	return resolveServerURL(c.specURL, url), nil
+}
+
+// operationBaseURL returns the base URL of an operation. Servers declared by the operation or its
+// path item take precedence over the base URL given by the caller, which takes precedence over the
+// document-level default. A relative operation server, such as "/write", is resolved against the
+// caller's base URL if given, else against the URL the specification was loaded from, else against
+// the default.
+func (c *adapterConfig) operationBaseURL(explicit string, defaultURL string, api APIEndpoint) (string, error) {
+	base := explicit
+	if base == "" {
+		base = defaultURL
+	}
+	if len(api.Servers) == 0 {
+		return base, nil
+	}
+	url, err := api.Servers[0].expandURL(c.serverVariables)
+	if err != nil || strings.Contains(url, "://") {
+		return url, err
+	}
+	if explicit == "" && c.specURL != "" {
+		return c.serverURL(api.Servers[0])
+	}
+	return resolveServerURL(base, url), nil
+}
+
+// resolveServerURL resolves a relative server URL against a base URL, returning it unchanged if the
+// base URL is unknown or invalid
+func resolveServerURL(base string, url string) string {
+	if base == "" {
+		return url
+	}
+	baseURL, err := neturl.Parse(base)
+	if err != nil {
+		return url
+	}
+	ref, err := neturl.Parse(url)
+	if err != nil {
+		return url
+	}
+	return strings.TrimSuffix(baseURL.ResolveReference(ref).String(), "/")
+}
+
+// operationURL returns the URL template of an operation. The operation's own absolute base URL
+// takes precedence over every other base URL, and absolute paths are used without a prefix.
+func (c *adapterConfig) operationURL(explicit string, defaultURL string, api APIEndpoint) (string, error) {
+	if api.BaseURL != "" {
+		return strings.TrimSuffix(api.BaseURL, "/") + api.Path, nil
+	}
+	if strings.Contains(api.Path, "://") {
+		return api.Path, nil
+	}
+	baseURL, err := c.operationBaseURL(explicit, defaultURL, api)
+	if err != nil {
+		return "", err
+	}
+	return baseURL + api.Path, nil
+}
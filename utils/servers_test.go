@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ServerURLs(t *testing.T) {
+	spec := []byte(`{
+		"openapi": "3.0.0",
+		"servers": [{
+			"url": "https://{region}.example.com/{version}/",
+			"variables": {
+				"region": {"default": "eu", "enum": ["eu", "us"]},
+				"version": {"default": "v2"}
+			}
+		}],
+		"paths": {
+			"/users": {
+				"servers": [{"url": "https://users.example.com"}],
+				"get": {"operationId": "listUsers"},
+				"post": {"operationId": "createUser", "servers": [{"url": "/write"}]}
+			},
+			"/orders": {"get": {"operationId": "listOrders"}}
+		}
+	}`)
+
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cfg := newAdapterConfig()
+	cfg.specURL = "https://docs.example.com/specs/openapi.json"
+	defaultURL, err := cfg.serverURL(parser.Servers()[0])
+	if err != nil || defaultURL != "https://eu.example.com/v2" {
+		t.Fatalf("Unexpected default server URL: %q (%v)", defaultURL, err)
+	}
+
+	want := map[string]string{
+		"listUsers":  "https://users.example.com",
+		"createUser": "https://docs.example.com/write",
+		"listOrders": "https://eu.example.com/v2",
+	}
+	for _, api := range parser.APIs() {
+		if got, _ := cfg.operationBaseURL("", defaultURL, api); got != want[api.OperationID] {
+			t.Errorf("%s: got base URL %q; want %q", api.OperationID, got, want[api.OperationID])
+		}
+	}
+
+	// Operation servers take precedence over an explicit base URL, relative ones being resolved against it
+	overridden := map[string]string{
+		"listUsers":  "https://users.example.com",
+		"createUser": "http://override/write",
+		"listOrders": "http://override/api",
+	}
+	for _, api := range parser.APIs() {
+		if got, _ := cfg.operationBaseURL("http://override/api", defaultURL, api); got != overridden[api.OperationID] {
+			t.Errorf("%s: got base URL %q with an explicit base URL; want %q", api.OperationID, got, overridden[api.OperationID])
+		}
+	}
+}
+
+func Test_OperationURL(t *testing.T) {
+	cfg := newAdapterConfig()
+	tests := []struct {
+		api  APIEndpoint
+		want string
+	}{
+		{APIEndpoint{Path: "/users"}, "http://override/users"},
+		{APIEndpoint{Path: "/invoices", BaseURL: "https://billing.example.com/"}, "https://billing.example.com/invoices"},
+		{APIEndpoint{Path: "https://files.example.com/upload"}, "https://files.example.com/upload"},
+	}
+	for _, tt := range tests {
+		if got, err := cfg.operationURL("http://override", "https://api.example.com", tt.api); err != nil || got != tt.want {
+			t.Errorf("%+v: got %q (%v); want %q", tt.api, got, err, tt.want)
+		}
+	}
+}
+
+func Test_ServerVariables(t *testing.T) {
+	server := Server{
+		URL: "https://{region}.example.com/{version}",
+		Variables: map[string]ServerVariable{
+			"region":  {Default: "eu", Enum: []string{"eu", "us"}},
+			"version": {Default: "v1"},
+		},
+	}
+
+	url, err := server.expandURL(map[string]string{"region": "us"})
+	if err != nil || url != "https://us.example.com/v1" {
+		t.Errorf("Unexpected URL: %q (%v)", url, err)
+	}
+	if _, err := server.expandURL(map[string]string{"region": "mars"}); err == nil {
+		t.Errorf("Expected an error for a value outside the enum")
+	}
+
+	undeclared := Server{URL: "https://{tenant}.example.com"}
+	if _, err := undeclared.expandURL(nil); err == nil || !strings.Contains(err.Error(), "tenant") {
+		t.Errorf("Expected an error naming the unresolved variable, got %v", err)
+	}
+	if url, err := undeclared.expandURL(map[string]string{"tenant": "acme"}); err != nil || url != "https://acme.example.com" {
+		t.Errorf("Unexpected URL: %q (%v)", url, err)
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_SigV4Sign(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	auth := SigV4Auth{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.sign(req, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Expected Authorization %q, got %q", want, got)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("Expected X-Amz-Date 20150830T123600Z, got %q", got)
+	}
+}
+
+func Test_SigV4Handler(t *testing.T) {
+	var got *http.Request
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		data, _ := readRequestBody(r)
+		body = string(data)
+	}))
+	defer ts.Close()
+
+	handler := NewToolHandler(http.MethodPost, ts.URL+"/bucket/key", nil, WithAuth(Auth{SigV4: &SigV4Auth{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+		Region:          "eu-west-1",
+		Service:         "s3",
+	}}))
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"body": map[string]interface{}{"name": "value"}}
+	if _, err := handler(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got == nil {
+		t.Fatal("Expected a request")
+	}
+	if hash := got.Header.Get("X-Amz-Content-Sha256"); hash != sha256Hex([]byte(body)) {
+		t.Errorf("Expected the body hash %s, got %s", sha256Hex([]byte(body)), hash)
+	}
+	if token := got.Header.Get("X-Amz-Security-Token"); token != "session" {
+		t.Errorf("Expected the session token, got %q", token)
+	}
+	authorization := got.Header.Get("Authorization")
+	if !strings.Contains(authorization, "/eu-west-1/s3/aws4_request") ||
+		!strings.Contains(authorization, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token") {
+		t.Errorf("Unexpected Authorization %q", authorization)
+	}
+}
+
+func Test_SigV4Validate(t *testing.T) {
+	auth := Auth{SigV4: &SigV4Auth{AccessKeyID: "id", SecretAccessKey: "secret"}}
+	if err := auth.validate(); err == nil {
+		t.Error("Expected an error without region and service")
+	}
+}
@@ -0,0 +1,339 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isSwagger2 reports whether a decoded document is a Swagger 2.0 specification
+func isSwagger2(document map[string]interface{}) bool {
+	version, _ := document["swagger"].(string)
+	return strings.HasPrefix(version, "2.")
+}
+
+// convertSwagger2 maps a Swagger 2.0 document, with its references already resolved,
+// onto the OpenAPI 3 structure understood by SimpleOpenAPIParser:
+//   - schemes, host and basePath become servers
+//   - body parameters become a JSON request body
+//   - formData parameters become a form-encoded (or multipart, for files) request body
+//   - collectionFormat becomes the equivalent style and explode settings
+//   - response schemas and security definitions move to their OpenAPI 3 locations
+func convertSwagger2(document map[string]interface{}) map[string]interface{} {
+	converted := map[string]interface{}{
+		"openapi": "3.0.0",
+		"servers": swaggerServers(document),
+	}
+	for _, key := range []string{"info", "security", "tags"} {
+		if value, ok := document[key]; ok {
+			converted[key] = value
+		}
+	}
+
+	components := map[string]interface{}{}
+	if definitions, ok := document["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = definitions
+	}
+	if securityDefinitions, ok := document["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := map[string]interface{}{}
+		for name, definition := range securityDefinitions {
+			if definitionMap, ok := definition.(map[string]interface{}); ok {
+				schemes[name] = convertSwaggerSecurityScheme(definitionMap)
+			}
+		}
+		components["securitySchemes"] = schemes
+	}
+	converted["components"] = components
+
+	consumes := stringList(document["consumes"])
+	produces := stringList(document["produces"])
+
+	paths := map[string]interface{}{}
+	if pathsObj, ok := document["paths"].(map[string]interface{}); ok {
+		for path, pathItem := range pathsObj {
+			pathItemObj, ok := pathItem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			pathParams, _ := pathItemObj["parameters"].([]interface{})
+
+			convertedItem := map[string]interface{}{}
+			for method, operation := range pathItemObj {
+				operationObj, ok := operation.(map[string]interface{})
+				if !ok || !isHTTPMethod(method) {
+					continue
+				}
+				convertedItem[method] = convertSwaggerOperation(operationObj, pathParams, consumes, produces)
+			}
+			paths[path] = convertedItem
+		}
+	}
+	converted["paths"] = paths
+
+	return converted
+}
+
+// swaggerServers derives the server URLs from schemes, host and basePath.
+// Without a host the servers are relative to the location of the document.
+func swaggerServers(document map[string]interface{}) []interface{} {
+	host, _ := document["host"].(string)
+	basePath, _ := document["basePath"].(string)
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	if host == "" {
+		if basePath == "" {
+			return []interface{}{}
+		}
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+
+	schemes := stringList(document["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	servers := []interface{}{}
+	for _, scheme := range schemes {
+		servers = append(servers, map[string]interface{}{"url": scheme + "://" + host + basePath})
+	}
+	return servers
+}
+
+// convertSwaggerSecurityScheme maps a Swagger 2.0 security definition to an OpenAPI 3 security scheme
+func convertSwaggerSecurityScheme(definition map[string]interface{}) map[string]interface{} {
+	scheme := map[string]interface{}{}
+	for _, key := range []string{"description", "name", "in"} {
+		if value, ok := definition[key]; ok {
+			scheme[key] = value
+		}
+	}
+
+	switch definition["type"] {
+	case "basic":
+		scheme["type"] = "http"
+		scheme["scheme"] = "basic"
+	case "apiKey":
+		scheme["type"] = "apiKey"
+	case "oauth2":
+		scheme["type"] = "oauth2"
+		flow := map[string]interface{}{}
+		for _, key := range []string{"tokenUrl", "authorizationUrl", "scopes"} {
+			if value, ok := definition[key]; ok {
+				flow[key] = value
+			}
+		}
+		flows := map[string]interface{}{}
+		switch definition["flow"] {
+		case "application":
+			flows["clientCredentials"] = flow
+		case "password":
+			flows["password"] = flow
+		case "accessCode":
+			flows["authorizationCode"] = flow
+		case "implicit":
+			flows["implicit"] = flow
+		}
+		scheme["flows"] = flows
+	}
+	return scheme
+}
+
+// convertSwaggerOperation maps a Swagger 2.0 operation to an OpenAPI 3 operation.
+// Path-level parameters apply unless the operation redefines them.
+func convertSwaggerOperation(operation map[string]interface{}, pathParams []interface{}, consumes, produces []string) map[string]interface{} {
+	converted := map[string]interface{}{}
+	for _, key := range []string{"summary", "description", "operationId", "security", "tags", "deprecated"} {
+		if value, ok := operation[key]; ok {
+			converted[key] = value
+		}
+	}
+	for key, value := range parseExtensions(operation) {
+		converted[key] = value
+	}
+
+	if opConsumes := stringList(operation["consumes"]); len(opConsumes) > 0 {
+		consumes = opConsumes
+	}
+	if opProduces := stringList(operation["produces"]); len(opProduces) > 0 {
+		produces = opProduces
+	}
+
+	opParams, _ := operation["parameters"].([]interface{})
+	var parameters []interface{}
+	var body map[string]interface{}
+	formProps := map[string]interface{}{}
+	var formRequired []interface{}
+	hasFile := false
+
+	for _, param := range mergeSwaggerParameters(pathParams, opParams) {
+		name, _ := param["name"].(string)
+		switch param["in"] {
+		case "body":
+			body = param
+		case "formData":
+			if param["type"] == "file" {
+				hasFile = true
+			}
+			formProps[name] = swaggerParameterSchema(param)
+			if required, _ := param["required"].(bool); required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			parameters = append(parameters, convertSwaggerParameter(param))
+		}
+	}
+	if len(parameters) > 0 {
+		converted["parameters"] = parameters
+	}
+
+	switch {
+	case body != nil:
+		mediaType := "application/json"
+		if len(consumes) > 0 && !containsString(consumes, mediaType) {
+			mediaType = consumes[0]
+		}
+		requestBody := map[string]interface{}{
+			"content": map[string]interface{}{
+				mediaType: map[string]interface{}{"schema": body["schema"]},
+			},
+		}
+		if required, ok := body["required"].(bool); ok {
+			requestBody["required"] = required
+		}
+		converted["requestBody"] = requestBody
+	case len(formProps) > 0:
+		mediaType := "application/x-www-form-urlencoded"
+		if hasFile || (containsString(consumes, "multipart/form-data") && !containsString(consumes, mediaType)) {
+			mediaType = "multipart/form-data"
+		}
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": formProps,
+		}
+		if len(formRequired) > 0 {
+			schema["required"] = formRequired
+		}
+		converted["requestBody"] = map[string]interface{}{
+			"required": len(formRequired) > 0,
+			"content": map[string]interface{}{
+				mediaType: map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+
+	if responsesObj, ok := operation["responses"].(map[string]interface{}); ok {
+		responses := map[string]interface{}{}
+		for status, response := range responsesObj {
+			responseMap, ok := response.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			convertedResponse := map[string]interface{}{}
+			if description, ok := responseMap["description"]; ok {
+				convertedResponse["description"] = description
+			}
+			content := map[string]interface{}{}
+			if schema, ok := responseMap["schema"]; ok {
+				mediaType := "application/json"
+				if len(produces) > 0 && !containsString(produces, mediaType) {
+					mediaType = produces[0]
+				}
+				content[mediaType] = map[string]interface{}{"schema": schema}
+			}
+			// Swagger 2.0 keys response examples by media type
+			if examples, ok := responseMap["examples"].(map[string]interface{}); ok {
+				for mediaType, example := range examples {
+					entry := map[string]interface{}{"example": example}
+					if schema, ok := responseMap["schema"]; ok {
+						entry["schema"] = schema
+					}
+					content[mediaType] = entry
+				}
+			}
+			if len(content) > 0 {
+				convertedResponse["content"] = content
+			}
+			responses[status] = convertedResponse
+		}
+		converted["responses"] = responses
+	}
+
+	return converted
+}
+
+// mergeSwaggerParameters combines path-level and operation-level parameters;
+// an operation parameter overrides a path parameter with the same name and location
+func mergeSwaggerParameters(pathParams, opParams []interface{}) []map[string]interface{} {
+	var merged []map[string]interface{}
+	overridden := map[string]bool{}
+	for _, param := range opParams {
+		if paramMap, ok := param.(map[string]interface{}); ok {
+			merged = append(merged, paramMap)
+			overridden[fmt.Sprint(paramMap["in"], ":", paramMap["name"])] = true
+		}
+	}
+	for _, param := range pathParams {
+		if paramMap, ok := param.(map[string]interface{}); ok && !overridden[fmt.Sprint(paramMap["in"], ":", paramMap["name"])] {
+			merged = append(merged, paramMap)
+		}
+	}
+	return merged
+}
+
+// convertSwaggerParameter maps a non-body Swagger 2.0 parameter, whose type information
+// is stored inline, to an OpenAPI 3 parameter with a schema
+func convertSwaggerParameter(param map[string]interface{}) map[string]interface{} {
+	converted := map[string]interface{}{
+		"schema": swaggerParameterSchema(param),
+	}
+	for _, key := range []string{"name", "in", "description", "required"} {
+		if value, ok := param[key]; ok {
+			converted[key] = value
+		}
+	}
+
+	if param["type"] == "array" {
+		// Swagger 2.0 defaults to comma separated values, unlike the exploded OpenAPI 3 form style
+		switch param["collectionFormat"] {
+		case "multi":
+			converted["style"] = styleForm
+			converted["explode"] = true
+		case "ssv":
+			converted["style"] = styleSpaceDelimited
+			converted["explode"] = false
+		case "pipes":
+			converted["style"] = stylePipeDelimited
+			converted["explode"] = false
+		default:
+			converted["style"] = styleForm
+			converted["explode"] = false
+		}
+	}
+	return converted
+}
+
+// swaggerParameterSchema collects the schema keywords stored inline on a Swagger 2.0 parameter
+func swaggerParameterSchema(param map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	for _, key := range []string{"type", "format", "items", "enum", "default", "description",
+		"minimum", "maximum", "multipleOf", "minLength", "maxLength", "pattern"} {
+		if value, ok := param[key]; ok {
+			schema[key] = value
+		}
+	}
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	}
+	return schema
+}
+
+// stringList converts a decoded JSON array of strings, ignoring other values
+func stringList(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var result []string
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseSwagger2(t *testing.T) {
+	spec := []byte(`{
+		"swagger": "2.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"host": "api.example.com",
+		"basePath": "/v1",
+		"schemes": ["https"],
+		"paths": {
+			"/pets": {
+				"get": {
+					"operationId": "listPets",
+					"parameters": [
+						{"name": "tags", "in": "query", "type": "array", "items": {"type": "string"}}
+					],
+					"responses": {"200": {"description": "ok", "schema": {"type": "array", "items": {"$ref": "#/definitions/Pet"}}}}
+				},
+				"post": {
+					"operationId": "createPet",
+					"parameters": [
+						{"name": "pet", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Pet"}}
+					]
+				}
+			},
+			"/pets/{id}/photo": {
+				"parameters": [{"name": "id", "in": "path", "required": true, "type": "integer"}],
+				"put": {
+					"operationId": "uploadPhoto",
+					"consumes": ["multipart/form-data"],
+					"parameters": [
+						{"name": "file", "in": "formData", "type": "file", "required": true}
+					]
+				}
+			}
+		},
+		"definitions": {
+			"Pet": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+		},
+		"securityDefinitions": {
+			"basicAuth": {"type": "basic"}
+		}
+	}`)
+
+	parser, err := NewSimpleOpenAPIParser(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if servers := parser.Servers(); len(servers) != 1 || servers[0].URL != "https://api.example.com/v1" {
+		t.Fatalf("Unexpected servers: %+v", servers)
+	}
+	if scheme := parser.SecuritySchemes()["basicAuth"]; scheme.Type != "http" || scheme.Scheme != "basic" {
+		t.Fatalf("Unexpected security scheme: %+v", scheme)
+	}
+
+	apis := map[string]APIEndpoint{}
+	for _, api := range parser.APIs() {
+		apis[api.OperationID] = api
+	}
+
+	tags := apis["listPets"].Parameters[0]
+	if tags.Style != styleForm || tags.Explode == nil || *tags.Explode {
+		t.Errorf("Expected csv collection format, got style %q explode %v", tags.Style, tags.Explode)
+	}
+
+	body := apis["createPet"].RequestBody
+	if body == nil || !body.Required || body.Content["application/json"].Schema.Properties["name"].Type != "string" {
+		t.Errorf("Unexpected request body: %+v", body)
+	}
+
+	upload := apis["uploadPhoto"]
+	if len(upload.Parameters) != 1 || upload.Parameters[0].Name != "id" || upload.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("Expected the path-level parameter to be inherited, got %+v", upload.Parameters)
+	}
+	form := upload.RequestBody.Content["multipart/form-data"].Schema
+	if form == nil || form.Properties["file"].Format != "binary" || !reflect.DeepEqual(form.Required, []string{"file"}) {
+		t.Errorf("Unexpected form body: %+v", form)
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available to response templates, in addition to the
+// text/template builtins such as len, index and printf
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+	"join": func(separator string, values []interface{}) string {
+		parts := make([]string, len(values))
+		for i, value := range values {
+			parts[i] = formatScalar(value)
+		}
+		return strings.Join(parts, separator)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"truncate": func(length int, s string) string {
+		if runes := []rune(s); len(runes) > length {
+			return string(runes[:length]) + "…"
+		}
+		return s
+	},
+	"default": func(fallback, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// compileResponseTemplate parses a response template. Referencing a field missing from the
+// response is an error when the template is executed.
+func compileResponseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// templateFor returns the compiled response template of the given operation, or nil if it has none.
+// The template has been validated by WithOperationConfig.
+func (c *adapterConfig) templateFor(operationID string, opCfg OperationConfig) *template.Template {
+	if opCfg.Template == "" {
+		return nil
+	}
+	tmpl, _ := compileResponseTemplate(operationID, opCfg.Template)
+	return tmpl
+}
+
+// renderTemplate executes the template with the response, parsed if it is JSON and as a string otherwise
+func renderTemplate(tmpl *template.Template, body []byte) ([]byte, error) {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		data = string(body)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_RenderTemplate(t *testing.T) {
+	body := []byte(`{"items": [{"id": 1, "name": "apple", "tags": ["red", "sweet"]}, {"id": 2, "name": "lemon", "tags": []}]}`)
+	tests := []struct {
+		template string
+		want     string
+		wantErr  string
+	}{
+		{`{{range .items}}{{.id}}: {{upper .name}} [{{join ", " .tags}}]` + "\n" + `{{end}}`, "1: APPLE [red, sweet]\n2: LEMON []\n", ""},
+		{`{{len .items}} items, first {{json (index .items 0).tags}}`, `2 items, first ["red","sweet"]`, ""},
+		{`{{truncate 3 (index .items 1).name}}`, "lem…", ""},
+		{`{{.total}}`, "", `map has no entry for key "total"`},
+	}
+	for _, tt := range tests {
+		tmpl, err := compileResponseTemplate("test", tt.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := renderTemplate(tmpl, body)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: got error %v; want %q", tt.template, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: got %q, %v; want %q", tt.template, got, err, tt.want)
+		}
+	}
+
+	if cfg := newAdapterConfig(WithOperationConfig("listItems", OperationConfig{Template: "{{.items"})); cfg.err == nil {
+		t.Error("Want an error for an invalid template")
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	neturl "net/url"
+)
+
+// Tracer creates client spans for upstream calls and propagates their trace context, e.g. as a
+// W3C traceparent header. It mirrors the parts of OpenTelemetry the adapter needs, so an
+// OpenTelemetry tracer and propagator can be plugged in without this package depending on them.
+type Tracer interface {
+	// Start starts a span parented to the span in ctx and returns a context holding the new span
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+	// Inject writes the trace context of ctx into the outbound request headers
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	// SetAttribute records an attribute such as "http.response.status_code"
+	SetAttribute(key string, value interface{})
+	// RecordError marks the span as failed
+	RecordError(err error)
+	End()
+}
+
+// WithTracer creates a client span for every upstream call, parented to the context of the tool call,
+// and injects its trace context into the request headers. The span carries the standard
+// http.request.method, url.full, server.address and http.response.status_code attributes.
+func WithTracer(tracer Tracer) AdapterOption {
+	return func(c *adapterConfig) {
+		c.tracer = tracer
+	}
+}
+
+// noopSpan is used when no tracer is configured
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) RecordError(error)                {}
+func (noopSpan) End()                             {}
+
+// startSpan starts the client span of an upstream call, or a no-op span if tracing is disabled
+func (c *adapterConfig) startSpan(ctx context.Context, method, rawURL string) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := c.tracer.Start(ctx, method)
+	span.SetAttribute("http.request.method", method)
+	span.SetAttribute("url.full", c.redactor.redactURL(rawURL, nil))
+	if u, err := neturl.Parse(rawURL); err == nil {
+		span.SetAttribute("server.address", u.Hostname())
+	}
+	return ctx, span
+}
+
+// injectTraceContext adds the trace context of ctx to an outbound request
+func (c *adapterConfig) injectTraceContext(ctx context.Context, req *http.Request) {
+	if c.tracer != nil {
+		c.tracer.Inject(ctx, req.Header)
+	}
+}
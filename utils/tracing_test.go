@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type testSpan struct {
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)                      {}
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	span := &testSpan{attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (t *testTracer) Inject(ctx context.Context, header http.Header) {
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+}
+
+func Test_TraceUpstreamCalls(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	tracer := &testTracer{}
+	_, err := NewToolHandler(http.MethodPost, ts.URL, nil, WithTracer(tracer))(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if traceparent == "" {
+		t.Errorf("Request has no traceparent header")
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Got %d spans; want 1", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if !span.ended || span.attributes["http.request.method"] != http.MethodPost || span.attributes["http.response.status_code"] != http.StatusCreated {
+		t.Fatalf("Got span %+v; want an ended POST span with status 201", span)
+	}
+}
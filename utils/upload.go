@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithFileUploadDirs enables uploading local files for binary request bodies and multipart file parts.
+// The argument of such a property is then interpreted as a file path, which must resolve (after
+// following symbolic links) to a file inside one of the given directories.
+// Without this option binary arguments are sent as literal content and no local file is ever read.
+func WithFileUploadDirs(dirs ...string) AdapterOption {
+	return func(c *adapterConfig) {
+		for _, dir := range dirs {
+			resolved, err := filepath.Abs(dir)
+			if err == nil {
+				resolved, err = filepath.EvalSymlinks(resolved)
+			}
+			if err != nil {
+				c.setError(fmt.Errorf("invalid upload directory %q: %w", dir, err))
+				return
+			}
+			c.uploadDirs = append(c.uploadDirs, resolved)
+		}
+	}
+}
+
+// uploadFile is a local file that was checked against the upload allow-list
+type uploadFile struct {
+	path string
+	size int64
+}
+
+// newUploadFile resolves path and verifies that it is a regular file inside one of the allowed directories
+func newUploadFile(path string, allowedDirs []string) (*uploadFile, error) {
+	resolved, err := filepath.Abs(path)
+	if err == nil {
+		resolved, err = filepath.EvalSymlinks(resolved)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot access file %q: %w", path, err)
+	}
+	if !isWithinDirs(resolved, allowedDirs) {
+		return nil, fmt.Errorf("file %q is outside the allowed upload directories", path)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access file %q: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q is not a regular file", path)
+	}
+	return &uploadFile{path: resolved, size: info.Size()}, nil
+}
+
+// isWithinDirs reports whether path is located inside one of dirs
+func isWithinDirs(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// open opens the file for reading
+func (f *uploadFile) open() (io.ReadCloser, error) {
+	return os.Open(f.path)
+}
+
+// contentType guesses the media type of the file from its extension
+func (f *uploadFile) contentType() string {
+	if contentType := mime.TypeByExtension(filepath.Ext(f.path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// writePart streams the file into a multipart file part with the given field name
+func (f *uploadFile) writePart(writer *multipart.Writer, name string) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     name,
+		"filename": filepath.Base(f.path),
+	}))
+	header.Set("Content-Type", f.contentType())
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	file, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(part, file)
+	return err
+}
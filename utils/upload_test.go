@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func Test_FileUpload(t *testing.T) {
+	allowed := t.TempDir()
+	path := filepath.Join(allowed, "report.txt")
+	if err := os.WriteFile(path, []byte("file contents"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var contentType, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method:     http.MethodPut,
+		url:        ts.URL,
+		bodyMedia:  "application/octet-stream",
+		bodySchema: &Schema{Type: "string", Format: "binary"},
+	}, newAdapterConfig(WithFileUploadDirs(allowed)))
+
+	call := func(path string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"requestBody": map[string]interface{}{rawBodyProperty: path},
+		}
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return result
+	}
+
+	if result := call(path); result.IsError {
+		t.Fatalf("Unexpected error result: %s", resultText(t, result))
+	}
+	if body != "file contents" || !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Unexpected upload: %q with Content-Type %q", body, contentType)
+	}
+
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	body = ""
+	result := call(filepath.Join(allowed, "..", filepath.Base(filepath.Dir(outside)), "secret.txt"))
+	if !result.IsError || !strings.Contains(resultText(t, result), "outside the allowed upload directories") {
+		t.Errorf("Expected the file outside the allow-list to be rejected, got %q", resultText(t, result))
+	}
+	if body != "" {
+		t.Errorf("Expected no request to be sent")
+	}
+}
+
+func Test_MultipartFileUpload(t *testing.T) {
+	allowed := t.TempDir()
+	path := filepath.Join(allowed, "photo.png")
+	if err := os.WriteFile(path, []byte("png"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var filename, fileType, content, caption string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("photo")
+		if err != nil {
+			t.Errorf("Missing file part: %v", err)
+			return
+		}
+		defer file.Close()
+		raw, _ := io.ReadAll(file)
+		filename, fileType, content = header.Filename, header.Header.Get("Content-Type"), string(raw)
+		caption = r.FormValue("caption")
+	}))
+	defer ts.Close()
+
+	handler := newToolHandler(toolEndpoint{
+		method:    http.MethodPost,
+		url:       ts.URL,
+		bodyMedia: "multipart/form-data",
+		bodySchema: &Schema{
+			Type:       "object",
+			Properties: map[string]Schema{"photo": {Type: "string", Format: "binary"}},
+		},
+	}, newAdapterConfig(WithFileUploadDirs(allowed)))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"requestBody": map[string]interface{}{"photo": path, "caption": "hi"},
+	}
+	if _, err := handler(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if filename != "photo.png" || fileType != "image/png" || content != "png" || caption != "hi" {
+		t.Errorf("Unexpected upload: name %q type %q content %q caption %q", filename, fileType, content, caption)
+	}
+}
@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+)
+
+// defaultXMLRootName names the root element of XML request bodies whose schema does not name it
+const defaultXMLRootName = "request"
+
+// defaultXMLItemName names the elements of an array that is not a property and has no XML name
+const defaultXMLItemName = "item"
+
+// XMLObject holds the xml keyword of a schema, which adjusts how a value is represented in XML
+type XMLObject struct {
+	Name      string // Element or attribute name; defaults to the property name
+	Namespace string
+	Prefix    string
+	Attribute bool // Whether a property is an attribute of its parent rather than a child element
+	Wrapped   bool // Whether array items are wrapped in an element named after the array
+}
+
+// parseXMLObject returns the xml keyword of a schema, or nil if it has none
+func parseXMLObject(schemaObj map[string]interface{}) *XMLObject {
+	obj, ok := schemaObj["xml"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	x := &XMLObject{}
+	x.Name, _ = obj["name"].(string)
+	x.Namespace, _ = obj["namespace"].(string)
+	x.Prefix, _ = obj["prefix"].(string)
+	x.Attribute, _ = obj["attribute"].(bool)
+	x.Wrapped, _ = obj["wrapped"].(bool)
+	return x
+}
+
+// WithXMLToJSON converts XML responses to JSON before they are returned, which models handle more
+// reliably. Elements become objects keyed by child element name, repeated elements become arrays,
+// attributes are keyed "@name" and the text of elements that also have children or attributes "#text".
+// Text is kept as strings. By default XML responses are returned as is.
+func WithXMLToJSON(enabled bool) AdapterOption {
+	return func(c *adapterConfig) {
+		c.xmlToJSON = enabled
+	}
+}
+
+// isXMLMediaType reports whether a media type or Content-Type header denotes XML, including +xml suffixes
+func isXMLMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// encodeXMLBody encodes the body arguments as an XML document. The root element is named by the
+// xml keyword of the schema, or defaultXMLRootName; properties become child elements named after
+// them unless their schema says otherwise, in the order the schema declares them.
+func encodeXMLBody(mediaType string, schema *Schema, params map[string]interface{}) (*requestBody, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := writeXMLElement(enc, xmlStartElement(schema, defaultXMLRootName), params, schema); err != nil {
+		return nil, fmt.Errorf("failed to encode XML body: %w", err)
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to encode XML body: %w", err)
+	}
+	return newBytesBody(buf.Bytes(), mediaType), nil
+}
+
+// xmlStartElement returns the start tag of an element with the given default name, applying the
+// name, prefix and namespace of the schema's xml keyword
+func xmlStartElement(schema *Schema, defaultName string) xml.StartElement {
+	name := defaultName
+	var attrs []xml.Attr
+	if schema != nil && schema.XML != nil {
+		if schema.XML.Name != "" {
+			name = schema.XML.Name
+		}
+		if schema.XML.Prefix != "" {
+			name = schema.XML.Prefix + ":" + name
+		}
+		if schema.XML.Namespace != "" {
+			xmlns := "xmlns"
+			if schema.XML.Prefix != "" {
+				xmlns += ":" + schema.XML.Prefix
+			}
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: xmlns}, Value: schema.XML.Namespace})
+		}
+	}
+	return xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs}
+}
+
+// writeXMLElement writes a value as an element with the given start tag
+func writeXMLElement(enc *xml.Encoder, start xml.StartElement, value interface{}, schema *Schema) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var properties map[string]Schema
+		var declared []string
+		if schema != nil {
+			properties, declared = schema.Properties, schema.PropertyOrder
+		}
+		names := xmlPropertyNames(v, declared, properties)
+
+		// Attributes belong to the start tag, so they are collected first
+		var children []string
+		for _, name := range names {
+			prop, ok := properties[name]
+			if !ok || prop.XML == nil || !prop.XML.Attribute || isXMLContainer(v[name]) {
+				children = append(children, name)
+				continue
+			}
+			if v[name] != nil {
+				attr := xmlStartElement(&prop, name)
+				start.Attr = append(start.Attr, xml.Attr{Name: attr.Name, Value: formatScalar(v[name])})
+			}
+		}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, name := range children {
+			var prop *Schema
+			if propSchema, ok := properties[name]; ok {
+				prop = &propSchema
+			}
+			if err := writeXMLProperty(enc, name, v[name], prop); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case []interface{}:
+		var items *Schema
+		if schema != nil {
+			items = schema.Items
+		}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := writeXMLElement(enc, xmlStartElement(items, defaultXMLItemName), item, items); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if value != nil {
+		if err := enc.EncodeToken(xml.CharData(formatScalar(value))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// writeXMLProperty writes a property of an object. Array items are repeated elements named after
+// the property, wrapped in an element named after the property if the schema says so.
+func writeXMLProperty(enc *xml.Encoder, name string, value interface{}, schema *Schema) error {
+	if value == nil {
+		return nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return writeXMLElement(enc, xmlStartElement(schema, name), value, schema)
+	}
+
+	var items *Schema
+	if schema != nil {
+		items = schema.Items
+	}
+	if schema != nil && schema.XML != nil && schema.XML.Wrapped {
+		return writeXMLElement(enc, xmlStartElement(schema, name), value, &Schema{Items: withXMLName(items, name)})
+	}
+	if schema != nil && schema.XML != nil && schema.XML.Name != "" {
+		name = schema.XML.Name
+	}
+	for _, item := range list {
+		if err := writeXMLElement(enc, xmlStartElement(items, name), item, items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withXMLName returns the schema with a default element name, unless the schema names the element
+func withXMLName(schema *Schema, name string) *Schema {
+	named := Schema{}
+	if schema != nil {
+		named = *schema
+	}
+	if named.XML == nil || named.XML.Name == "" {
+		x := XMLObject{}
+		if named.XML != nil {
+			x = *named.XML
+		}
+		x.Name = name
+		named.XML = &x
+	}
+	return &named
+}
+
+// isXMLContainer reports whether a value is encoded as elements and so cannot be an attribute
+func isXMLContainer(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// xmlPropertyNames returns the names of the object's members, those the schema declares first in
+// their declared order and the others in lexical order
+func xmlPropertyNames(object map[string]interface{}, declared []string, properties map[string]Schema) []string {
+	names := make([]string, 0, len(object))
+	for _, name := range orderProperties(declared, properties) {
+		if _, ok := object[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range object {
+		if _, ok := properties[name]; !ok {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// xmlToJSON converts an XML document to JSON, as described for WithXMLToJSON. The result is an
+// object holding the root element under its name.
+func xmlToJSON(body []byte) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeXMLElement(dec, start)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]interface{}{start.Name.Local: value})
+		}
+	}
+}
+
+// decodeXMLElement decodes the content of an element whose start tag has been read
+func decodeXMLElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	object := map[string]interface{}{}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		object["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			// Element values are never arrays, so an array holds repeated elements
+			switch existing := object[t.Name.Local].(type) {
+			case nil:
+				object[t.Name.Local] = child
+			case []interface{}:
+				object[t.Name.Local] = append(existing, child)
+			default:
+				object[t.Name.Local] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(object) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				object["#text"] = content
+			}
+			return object, nil
+		}
+	}
+}
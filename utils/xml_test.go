@@ -0,0 +1,45 @@
+package utils
+
+import "testing"
+
+func Test_EncodeXMLBody(t *testing.T) {
+	spec := `{"openapi": "3.0.0", "info": {"title": "t", "version": "1"}, "paths": {"/pets": {"post": {
+		"operationId": "createPet",
+		"requestBody": {"content": {"application/xml": {"schema": {"type": "object", "xml": {"name": "pet"}, "properties": {
+			"id": {"type": "integer", "xml": {"attribute": true}},
+			"name": {"type": "string"},
+			"tags": {"type": "array", "xml": {"wrapped": true}, "items": {"type": "string", "xml": {"name": "tag"}}},
+			"photoUrls": {"type": "array", "items": {"type": "string"}}}}}}}}}}}`
+	parser, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, schema := requestBodySchema(parser.APIs()[0].RequestBody)
+	body, err := newAdapterConfig().encodeRequestBody(mediaType, schema, map[string]interface{}{
+		"id":        float64(7),
+		"name":      "Rex & Co",
+		"tags":      []interface{}{"good", "boy"},
+		"photoUrls": []interface{}{"a.png", "b.png"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<pet id="7"><name>Rex &amp; Co</name><tags><tag>good</tag><tag>boy</tag></tags>` +
+		`<photoUrls>a.png</photoUrls><photoUrls>b.png</photoUrls></pet>`
+	if string(body.data) != want || body.contentType != "application/xml" {
+		t.Errorf("Got %s body %s; want %s", body.contentType, body.data, want)
+	}
+}
+
+func Test_XMLToJSON(t *testing.T) {
+	got, err := xmlToJSON([]byte(`<?xml version="1.0"?>
+		<pets xmlns="urn:pets"><pet id="1"><name>Rex</name></pet><pet id="2"><name>Tom</name></pet><count>2</count></pets>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"pets":{"count":"2","pet":[{"@id":"1","name":"Rex"},{"@id":"2","name":"Tom"}]}}`
+	if string(got) != want {
+		t.Errorf("Got %s; want %s", got, want)
+	}
+}